@@ -4,11 +4,17 @@
 package v1alpha1
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/Masterminds/semver/v3"
 	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	fluxsourcev1beta1 "github.com/fluxcd/source-controller/api/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,9 +41,27 @@ const (
 	AppGroupNameKey   = "appgroup"
 	AppGroupFinalizer = "orkestra.azure.microsoft.com/finalizer"
 
+	// LastSuccessfulAnnotation held the last successful spec before the revision history was
+	// introduced. It is only read as a fallback for ApplicationGroups without any revisions.
 	LastSuccessfulAnnotation = "orkestra.azure.microsoft.com/last-successful-appgroup"
 	ParentChartAnnotation    = "orkestra.azure.microsoft.com/parent-chart"
 
+	// RollbackToAnnotation requests a rollback to the successful revision with the given generation
+	RollbackToAnnotation = "orkestra.azure.microsoft.com/rollback-to"
+
+	// ApproveAnnotation approves the pending approval gates of the forward workflow,
+	// given as a comma separated list of <application>/<executor> names
+	ApproveAnnotation = "orkestra.azure.microsoft.com/approve"
+
+	// SpecHashAnnotation holds the hash of the ApplicationGroup spec rolled out by the forward workflow
+	SpecHashAnnotation = "orkestra.azure.microsoft.com/spec-hash"
+
+	// DefaultRevisionHistoryLimit is the number of successful revisions kept for rollbacks
+	DefaultRevisionHistoryLimit = 10
+
+	// DefaultRemediationMaxAttempts is the number of times a remediation workflow is submitted for a failed generation
+	DefaultRemediationMaxAttempts = 1
+
 	HeritageLabel = "orkestra.azure.microsoft.com/heritage"
 	HeritageValue = "orkestra"
 
@@ -74,6 +98,58 @@ type ApplicationSpec struct {
 	// as a DAG
 	// +optional
 	Workflow []Executor `json:"workflow,omitempty"`
+
+	// RetryStrategy retries the failed executor tasks of the application before
+	// the workflow is marked as failed and remediation kicks in
+	// +optional
+	RetryStrategy *RetryStrategy `json:"retryStrategy,omitempty"`
+}
+
+// RetryPolicy specifies which executor task failures are retried
+type RetryPolicy string
+
+const (
+	// RetryOnFailure retries the executor tasks that failed
+	RetryOnFailure RetryPolicy = "OnFailure"
+	// RetryOnError retries the executor tasks that errored, i.e. failed due to the workflow engine or the cluster
+	RetryOnError RetryPolicy = "OnError"
+	// RetryOnTransientError retries the executor tasks that errored with a transient error
+	RetryOnTransientError RetryPolicy = "OnTransientError"
+	// RetryAlways retries the executor tasks that failed or errored
+	RetryAlways RetryPolicy = "Always"
+)
+
+// RetryStrategy describes how the failed executor tasks of an application are retried
+type RetryStrategy struct {
+	// Limit is the maximum number of times a failed executor task is retried
+	// +kubebuilder:validation:Minimum:=1
+	// +required
+	Limit int32 `json:"limit"`
+
+	// Backoff specifies the time to wait between the retries
+	// +optional
+	Backoff *Backoff `json:"backoff,omitempty"`
+
+	// RetryOn specifies which failures are retried. Defaults to OnFailure.
+	// +kubebuilder:validation:Enum=OnFailure;OnError;OnTransientError;Always
+	// +optional
+	RetryOn RetryPolicy `json:"retryOn,omitempty"`
+}
+
+// Backoff is the exponential backoff between the retries of an executor task
+type Backoff struct {
+	// Duration to wait before the first retry
+	// +required
+	Duration metav1.Duration `json:"duration"`
+
+	// Factor by which the duration is multiplied after each retry
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	Factor *int32 `json:"factor,omitempty"`
+
+	// MaxDuration is the maximum time spent on retrying the executor task
+	// +optional
+	MaxDuration *metav1.Duration `json:"maxDuration,omitempty"`
 }
 
 // ExecutorType can either refer to a native executor (helmrelease and/or keptn) or
@@ -92,6 +168,20 @@ const (
 	// CustomExecutor lets the user specify their own executor template to be used either as a deployment
 	// executor, a testing executor or for any other action to be taken on behalf of the application node.
 	CustomExecutor ExecutorType = "custom"
+	// CanaryExecutor deploys the application through the `HelmRelease` like the HelmReleaseExecutor and then
+	// watches its Flagger Canary or Argo Rollouts Rollout until the progressive rollout is promoted or aborted.
+	CanaryExecutor ExecutorType = "canary"
+	// ApprovalExecutor is a manual approval gate that suspends the workflow node until it is approved
+	// through the ApproveAnnotation. The executors that depend on it are only run once it is approved.
+	ApprovalExecutor ExecutorType = "approval"
+	// HealthCheckExecutor is a "testing" executor that waits for the Deployments, StatefulSets and DaemonSets
+	// of the release to be rolled out, for its Jobs to complete and for the configured HTTP endpoints to return
+	// a 2xx status code. It must depend on the deployment executor of the application.
+	HealthCheckExecutor ExecutorType = "healthcheck"
+	// HelmTestExecutor is a "testing" executor that runs the `helm test` hooks of the release and fails if
+	// one of the tests fails. The results are recorded as events of the ApplicationGroup.
+	// It must depend on the deployment executor of the application.
+	HelmTestExecutor ExecutorType = "helmtest"
 )
 
 type Executor struct {
@@ -100,7 +190,7 @@ type Executor struct {
 	DAG `json:",inline"`
 
 	// Type specifies the executor type to be run
-	// +kubebuilder:validation:Enum=helmrelease;keptn;custom
+	// +kubebuilder:validation:Enum=helmrelease;keptn;custom;canary;approval;healthcheck;helmtest
 	// +required
 	Type ExecutorType `json:"type,omitempty"`
 
@@ -112,6 +202,10 @@ type Executor struct {
 	// Params hold executor specific properties
 	// +optional
 	Params *apiextensionsv1.JSON `json:"params,omitempty"`
+
+	// RetryStrategy overrides the retry strategy of the application for this executor
+	// +optional
+	RetryStrategy *RetryStrategy `json:"retryStrategy,omitempty"`
 }
 
 type Release struct {
@@ -155,26 +249,111 @@ type Release struct {
 }
 
 type ChartRef struct {
-	// The Helm repository URL, a valid URL contains at least a protocol and host.
+	// The Helm repository URL, a valid URL contains at least a protocol and host. OCI registries are referenced with the oci:// scheme, e.g. oci://myregistry.azurecr.io/charts.
+	// For charts from a Git repository it is the HTTP/S or SSH URL of the repository and for charts from a bucket the endpoint of the S3 compatible storage
 	// +required
 	URL string `json:"url"`
 
-	// The name or path the Helm chart is available at in the SourceRef.
+	// The name of the Helm chart. The charts from Git repositories and buckets are staged with this name
 	// +required
 	Name string `json:"name"` //nolint: golint
 
-	// Version semver expression, ignored for charts from v1beta1.GitRepository and
-	// v1beta1.Bucket sources. Defaults to latest when omitted.
+	// Version semver expression, ignored for charts from Git repositories and buckets
+	// which are staged with the version of their Chart.yaml. Defaults to latest when omitted.
+	// Version ranges are resolved against the chart repository and the resolved version is reported in the status
 	// +kubebuilder:default:=*
 	// +optional
 	Version string `json:"version,omitempty"`
 
+	// Git fetches the chart from the Git repository at the URL using a Flux GitRepository source
+	// +optional
+	Git *GitChartSource `json:"git,omitempty"`
+
+	// Bucket fetches the chart from the S3 compatible bucket at the URL using a Flux Bucket source
+	// +optional
+	Bucket *BucketChartSource `json:"bucket,omitempty"`
+
+	// Verify verifies the chart before it is staged. Charts from helm repositories are verified
+	// with their provenance file and charts from OCI registries with their cosign signature
+	// +optional
+	Verify *ChartVerification `json:"verify,omitempty"`
+
 	// AuthSecretRef is a reference to the auth secret
-	// to access a private helm repository
+	// to access a private helm repository or OCI registry.
+	// The secret may have the username and password, the token and authHeader,
+	// and the PEM encoded tls.crt, tls.key and ca.crt fields.
+	// The secret is read in the orkestra namespace if the reference has no namespace.
+	// The secret of a Git repository or bucket must have the fields expected by the Flux source-controller
+	// and the source is created in the namespace of the secret
 	// +optional
 	AuthSecretRef *corev1.ObjectReference `json:"authSecretRef,omitempty"`
 }
 
+// ChartVerification holds the provider and the key that the chart is verified with
+type ChartVerification struct {
+	// Provider of the chart signature, 'pgp' verifies the provenance file (.prov) of the chart and 'cosign'
+	// the cosign signature of the chart in an OCI registry. Defaults to 'cosign' for OCI registries and to 'pgp' otherwise
+	// +kubebuilder:validation:Enum=pgp;cosign
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// SecretRef is a reference to the secret holding the PGP public keyring in the 'keyring' field
+	// or the cosign public key in the 'cosign.pub' field
+	// +required
+	SecretRef *corev1.ObjectReference `json:"secretRef"`
+}
+
+const (
+	// PGPVerificationProvider verifies the provenance file of the chart against a PGP keyring
+	PGPVerificationProvider = "pgp"
+	// CosignVerificationProvider verifies the cosign signature of the chart in an OCI registry
+	CosignVerificationProvider = "cosign"
+)
+
+// OCIScheme is the URL scheme of the OCI registries
+const OCIScheme = "oci"
+
+// IsOCI returns true if the URL refers to an OCI registry
+func IsOCI(url string) bool {
+	return strings.HasPrefix(url, OCIScheme+"://")
+}
+
+// GitChartSource holds the location of the chart in a Git repository
+type GitChartSource struct {
+	// Ref is the Git reference to checkout, defaults to the master branch
+	// +optional
+	Ref *fluxsourcev1beta1.GitRepositoryRef `json:"ref,omitempty"`
+
+	// Path is the path of the chart directory in the repository
+	// +required
+	Path string `json:"path"`
+}
+
+// BucketChartSource holds the location of the chart in an S3 compatible bucket
+type BucketChartSource struct {
+	// Provider is the S3 compatible storage provider name, default ('generic')
+	// +kubebuilder:validation:Enum=generic;aws
+	// +kubebuilder:default:=generic
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// BucketName is the name of the bucket
+	// +required
+	BucketName string `json:"bucketName"`
+
+	// Region is the region of the bucket
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Insecure allows connecting to a non-TLS S3 HTTP endpoint
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Path is the path of the chart directory in the bucket
+	// +required
+	Path string `json:"path"`
+}
+
 // ChartStatus shows the current status of the Application Reconciliation process
 type ChartStatus struct {
 	// Error string from the error during reconciliation (if any)
@@ -206,8 +385,75 @@ type ApplicationGroupSpec struct {
 	// Defaults to 5s for short requeue and 30s for long requeue
 	// +optional
 	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// RevisionHistoryLimit is the number of successfully rolled out revisions
+	// of the ApplicationGroup that are kept for rollbacks. Defaults to 10.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// Remediation configures how the ApplicationGroup is remediated when its forward workflow fails.
+	// Defaults to rolling back to the last successful revision.
+	// +optional
+	Remediation *Remediation `json:"remediation,omitempty"`
+
+	// AutoUpdateInterval is the interval at which the chart version ranges are resolved again against the
+	// chart repositories while the ApplicationGroup is ready. The ApplicationGroup is rolled out again
+	// when a newer chart version matching the range has been published. Disabled when omitted.
+	// +optional
+	AutoUpdateInterval *metav1.Duration `json:"autoUpdateInterval,omitempty"`
+
+	// Suspend pauses the rollout of the ApplicationGroup by suspending its in-flight workflow
+	// and stops the reconciliation until it is cleared. Clearing it resumes the suspended workflow.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// RemediationStrategy specifies the action taken when the forward workflow fails
+type RemediationStrategy string
+
+const (
+	// RemediationRollback rolls back to the last successful revision, or reverses the
+	// ApplicationGroup if it has never been successfully rolled out
+	RemediationRollback RemediationStrategy = "rollback"
+	// RemediationReverse uninstalls the applications of the ApplicationGroup
+	RemediationReverse RemediationStrategy = "reverse"
+	// RemediationPause leaves the failed releases in place for debugging and raises a warning event
+	RemediationPause RemediationStrategy = "pause"
+	// RemediationNone leaves the failed releases in place
+	RemediationNone RemediationStrategy = "none"
+)
+
+// Remediation configures the remediation of a failed forward workflow
+type Remediation struct {
+	// Strategy is the remediation action taken when the forward workflow fails. Defaults to rollback.
+	// +kubebuilder:validation:Enum=rollback;reverse;pause;none
+	// +optional
+	Strategy RemediationStrategy `json:"strategy,omitempty"`
+
+	// MaxAttempts is the number of times the remediation workflow is submitted for a failed generation.
+	// A failed remediation workflow is resubmitted until the attempts run out. Defaults to 1.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	MaxAttempts *int32 `json:"maxAttempts,omitempty"`
+
+	// RollbackMode specifies which applications the rollback strategy rolls back. Defaults to full.
+	// +kubebuilder:validation:Enum=full;partial
+	// +optional
+	RollbackMode RollbackMode `json:"rollbackMode,omitempty"`
 }
 
+// RollbackMode specifies which applications are rolled back when remediating a failed forward workflow
+type RollbackMode string
+
+const (
+	// FullRollback rolls back all the applications to the last successful revision
+	FullRollback RollbackMode = "full"
+	// PartialRollback only rolls back the applications whose tasks failed in the forward workflow
+	// and the applications that depend on them
+	PartialRollback RollbackMode = "partial"
+)
+
 // Application spec and dependency on other applications
 type Application struct {
 	// DAG contains the dependency information
@@ -254,14 +500,58 @@ type ApplicationGroupStatus struct {
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
+	// ObservedReferencesHash is the hash of the data and the existence of the Secrets and ConfigMaps
+	// referenced by the spec when the spec was last reconciled
+	// +optional
+	ObservedReferencesHash string `json:"observedReferencesHash,omitempty"`
+
 	// LastSucceededGeneration captures the last generation
 	// that has successfully completed a full workflow rollout of the application group
 	// +optional
 	LastSucceededGeneration int64 `json:"lastSucceededGeneration,omitempty"`
 
+	// RemediationAttempts counts the remediation workflows submitted for the observed generation
+	// +optional
+	RemediationAttempts int32 `json:"remediationAttempts,omitempty"`
+
 	// Conditions holds the conditions of the ApplicationGroup
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// PendingApprovals lists the approval gates of the forward workflow that are waiting to be approved
+	// +optional
+	PendingApprovals []PendingApproval `json:"pendingApprovals,omitempty"`
+
+	// Revisions lists the successfully rolled out revisions
+	// of the ApplicationGroup, starting from the most recent
+	// +optional
+	Revisions []RevisionStatus `json:"revisions,omitempty"`
+}
+
+// PendingApproval describes an approval gate of the forward workflow that is waiting to be approved
+type PendingApproval struct {
+	// Application whose workflow is gated
+	Application string `json:"application"`
+
+	// Executor is the name of the approval executor
+	Executor string `json:"executor"`
+
+	// Since is the time the workflow reached the approval gate
+	// +optional
+	Since metav1.Time `json:"since,omitempty"`
+}
+
+// RevisionStatus describes a successfully rolled out revision of the ApplicationGroup
+type RevisionStatus struct {
+	// Name of the ControllerRevision holding the ApplicationGroup spec of the revision
+	Name string `json:"name"`
+
+	// Generation of the ApplicationGroup that was rolled out
+	Generation int64 `json:"generation"`
+
+	// CreationTimestamp is the time the revision was recorded
+	// +optional
+	CreationTimestamp metav1.Time `json:"creationTimestamp,omitempty"`
 }
 
 // GetValues unmarshals the raw values to a map[string]interface{} and returns
@@ -274,6 +564,53 @@ func (in *Release) GetValues() map[string]interface{} {
 	return values
 }
 
+// GetSourceKind returns the kind of the Flux source that the chart is fetched from,
+// or an empty string if the chart is pulled from a helm repository or OCI registry
+func (in *ChartRef) GetSourceKind() string {
+	switch {
+	case in.Git != nil:
+		return fluxsourcev1beta1.GitRepositoryKind
+	case in.Bucket != nil:
+		return fluxsourcev1beta1.BucketKind
+	default:
+		return ""
+	}
+}
+
+// IsVersionRange returns true if the version is a semver range that is resolved
+// against the chart repository rather than an exact chart version
+func (in *ChartRef) IsVersionRange() bool {
+	_, err := semver.StrictNewVersion(in.Version)
+	return err != nil
+}
+
+// GetSourcePath returns the path of the chart directory in the Git repository or bucket
+func (in *ChartRef) GetSourcePath() string {
+	switch {
+	case in.Git != nil:
+		return in.Git.Path
+	case in.Bucket != nil:
+		return in.Bucket.Path
+	default:
+		return ""
+	}
+}
+
+// GetVerificationProvider returns the provider that the chart is verified with, or an empty string
+// if the chart is not verified. It defaults to cosign for OCI registries and to pgp otherwise
+func (in *ChartRef) GetVerificationProvider() string {
+	if in.Verify == nil {
+		return ""
+	}
+	if in.Verify.Provider != "" {
+		return in.Verify.Provider
+	}
+	if IsOCI(in.URL) {
+		return CosignVerificationProvider
+	}
+	return PGPVerificationProvider
+}
+
 // GetValues unmarshals the raw values to a map[string]interface{} and returns
 // the result.
 func (in *Application) GetValues() map[string]interface{} {
@@ -337,12 +674,30 @@ func (in *ApplicationGroup) ChartPullFailed(message string) {
 	meta.SetResourceCondition(in, meta.ReadyCondition, metav1.ConditionFalse, meta.ChartPullFailedReason, message)
 }
 
+// ChartVerificationFailed sets the meta.ReadyCondition to 'False' and
+// meta.ChartVerificationFailedReason reason and message
+func (in *ApplicationGroup) ChartVerificationFailed(message string) {
+	meta.SetResourceCondition(in, meta.ReadyCondition, metav1.ConditionFalse, meta.ChartVerificationFailedReason, message)
+}
+
 // WorkflowTemplateGenerationFailed sets the meta.ReadyCondition to 'False' and
 // meta.TemplateGenerationFailed reason and message
 func (in *ApplicationGroup) WorkflowTemplateGenerationFailed(message string) {
 	meta.SetResourceCondition(in, meta.ReadyCondition, metav1.ConditionFalse, meta.WorkflowTemplateGenerationFailedReason, message)
 }
 
+// DAGValidationFailed sets the meta.ReadyCondition to 'False' and
+// meta.DAGValidationFailedReason reason and message
+func (in *ApplicationGroup) DAGValidationFailed(message string) {
+	meta.SetResourceCondition(in, meta.ReadyCondition, metav1.ConditionFalse, meta.DAGValidationFailedReason, message)
+}
+
+// ReadySuspended sets the meta.ReadyCondition to 'False', with the
+// meta.SuspendedReason reason and message
+func (in *ApplicationGroup) ReadySuspended() {
+	meta.SetResourceCondition(in, meta.ReadyCondition, metav1.ConditionFalse, meta.SuspendedReason, "application group is suspended")
+}
+
 // GetReadyCondition gets the string condition.Reason of the
 // meta.ReadyCondition type
 func (in *ApplicationGroup) GetReadyCondition() string {
@@ -377,6 +732,7 @@ func (in *ChartStatus) GetStatusConditions() *[]metav1.Condition {
 	return &in.Conditions
 }
 
+// GetLastSuccessful returns the spec stored in the LastSuccessfulAnnotation, if any
 func (in *ApplicationGroup) GetLastSuccessful() *ApplicationGroupSpec {
 	lastSuccessful := &ApplicationGroupSpec{}
 	if s, ok := in.Annotations[LastSuccessfulAnnotation]; ok {
@@ -386,12 +742,92 @@ func (in *ApplicationGroup) GetLastSuccessful() *ApplicationGroupSpec {
 	return nil
 }
 
-func (in *ApplicationGroup) SetLastSuccessful() {
-	b, _ := json.Marshal(&in.Spec)
-	if in.Annotations == nil {
-		in.Annotations = make(map[string]string)
+// GetRollbackTo returns the generation of the revision requested by the RollbackToAnnotation
+func (in *ApplicationGroup) GetRollbackTo() (int64, bool, error) {
+	s, ok := in.Annotations[RollbackToAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	generation, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || generation <= 0 {
+		return 0, true, fmt.Errorf("%s annotation must be a positive generation, got %q", RollbackToAnnotation, s)
+	}
+	return generation, true, nil
+}
+
+// SpecHash returns the hash of the spec rolled out by the forward workflow.
+// The suspend flag doesn't change the rollout, so it is not part of the hash.
+func (in *ApplicationGroup) SpecHash() string {
+	spec := in.Spec.DeepCopy()
+	spec.Suspend = false
+	b, _ := json.Marshal(spec)
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// GetApplicationStatus returns the status of the application with the given name, if any
+func (in *ApplicationGroup) GetApplicationStatus(name string) *ApplicationStatus {
+	for i := range in.Status.Applications {
+		if in.Status.Applications[i].Name == name {
+			return &in.Status.Applications[i]
+		}
+	}
+	return nil
+}
+
+// GetApprovals returns the <application>/<executor> names of the approval gates approved through the ApproveAnnotation
+func (in *ApplicationGroup) GetApprovals() []string {
+	var approvals []string
+	for _, approval := range strings.Split(in.Annotations[ApproveAnnotation], ",") {
+		if approval = strings.TrimSpace(approval); approval != "" {
+			approvals = append(approvals, approval)
+		}
+	}
+	return approvals
+}
+
+// GetRevisionHistoryLimit returns the revision history limit if specified in the application group
+// Otherwise, it returns the DefaultRevisionHistoryLimit
+func (in *ApplicationGroup) GetRevisionHistoryLimit() int {
+	if in.Spec.RevisionHistoryLimit != nil && *in.Spec.RevisionHistoryLimit > 0 {
+		return int(*in.Spec.RevisionHistoryLimit)
+	}
+	return DefaultRevisionHistoryLimit
+}
+
+// GetAutoUpdateInterval returns the auto update interval if specified in the application group
+// Otherwise, it returns 0 since the chart versions are not updated automatically
+func (in *ApplicationGroup) GetAutoUpdateInterval() time.Duration {
+	if in.Spec.AutoUpdateInterval != nil {
+		return in.Spec.AutoUpdateInterval.Duration
+	}
+	return 0
+}
+
+// GetRemediationStrategy returns the remediation strategy if specified in the application group
+// Otherwise, it returns the RemediationRollback strategy
+func (in *ApplicationGroup) GetRemediationStrategy() RemediationStrategy {
+	if in.Spec.Remediation != nil && in.Spec.Remediation.Strategy != "" {
+		return in.Spec.Remediation.Strategy
+	}
+	return RemediationRollback
+}
+
+// GetRollbackMode returns the rollback mode if specified in the application group
+// Otherwise, it returns the FullRollback mode
+func (in *ApplicationGroup) GetRollbackMode() RollbackMode {
+	if in.Spec.Remediation != nil && in.Spec.Remediation.RollbackMode != "" {
+		return in.Spec.Remediation.RollbackMode
+	}
+	return FullRollback
+}
+
+// GetRemediationMaxAttempts returns the maximum number of remediation attempts if specified
+// in the application group. Otherwise, it returns the DefaultRemediationMaxAttempts
+func (in *ApplicationGroup) GetRemediationMaxAttempts() int32 {
+	if in.Spec.Remediation != nil && in.Spec.Remediation.MaxAttempts != nil && *in.Spec.Remediation.MaxAttempts > 0 {
+		return *in.Spec.Remediation.MaxAttempts
 	}
-	in.Annotations[LastSuccessfulAnnotation] = string(b)
+	return DefaultRemediationMaxAttempts
 }
 
 // +kubebuilder:object:root=true
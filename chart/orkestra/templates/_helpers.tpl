@@ -60,3 +60,31 @@ Create the name of the service account to use
 {{- default "default" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+URL of the staging repository for the selected staging store
+*/}}
+{{- define "orkestra.stagingRepoURL" -}}
+{{- if eq .Values.staging.store "local" -}}
+http://{{ include "orkestra.fullname" . }}-staging.{{ .Release.Namespace }}:8082
+{{- else if eq .Values.staging.store "oci" -}}
+{{ .Values.staging.url }}
+{{- else -}}
+http://{{ .Release.Name }}-chartmuseum.{{ .Release.Namespace }}:8080
+{{- end -}}
+{{- end -}}
+
+{{/*
+Whether the validating admission webhook is deployed
+The controller is not deployed in CI mode, so neither is the webhook
+*/}}
+{{- define "orkestra.webhookEnabled" -}}
+{{- if and .Values.webhook.enabled (not .Values.ci.enabled) }}true{{- end }}
+{{- end }}
+
+{{/*
+Create the name of the secret with the webhook serving certificates
+*/}}
+{{- define "orkestra.webhookCertSecret" -}}
+{{- default (printf "%s-webhook-cert" (include "orkestra.fullname" .)) .Values.webhook.certSecret }}
+{{- end }}
@@ -0,0 +1,75 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+// The healthcheck executor waits until the workloads of a helm release are rolled out,
+// its jobs completed and its HTTP endpoints return a 2xx status code.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/Orkestra/pkg/healthcheck"
+	"github.com/Azure/Orkestra/pkg/utils"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func main() {
+	var spec, endpoints string
+	var timeout, interval time.Duration
+	flag.StringVar(&spec, "spec", "", "The base64 encoded HelmRelease of the release to check")
+	flag.DurationVar(&timeout, "timeout", 5*time.Minute, "The time to wait for the release to be healthy")
+	flag.DurationVar(&interval, "interval", healthcheck.DefaultInterval, "The interval between the health checks")
+	flag.StringVar(&endpoints, "endpoints", "", "The comma separated HTTP endpoints that must return a 2xx status code")
+	opts := zap.Options{Development: true}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	log := ctrl.Log.WithName("healthcheck")
+
+	hr, err := utils.B64ToHr(spec)
+	if err != nil {
+		log.Error(err, "invalid helmrelease spec")
+		os.Exit(1)
+	}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		log.Error(err, "failed to get the kubeconfig")
+		os.Exit(1)
+	}
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		log.Error(err, "failed to create the client")
+		os.Exit(1)
+	}
+
+	checker := &healthcheck.Checker{
+		Client:           k8sClient,
+		Logger:           log,
+		ReleaseName:      hr.GetReleaseName(),
+		ReleaseNamespace: hr.GetReleaseNamespace(),
+	}
+	for _, endpoint := range strings.Split(endpoints, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			checker.Endpoints = append(checker.Endpoints, endpoint)
+		}
+	}
+
+	log.Info("waiting for the release to be healthy", "release", checker.ReleaseName, "namespace", checker.ReleaseNamespace, "endpoints", checker.Endpoints)
+	if err := checker.Wait(context.Background(), timeout, interval); err != nil {
+		log.Error(err, "release is not healthy")
+		os.Exit(1)
+	}
+	log.Info("release is healthy", "release", checker.ReleaseName)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+// The helmtest executor runs the test hooks of a helm release and records
+// their results and logs as events of the ApplicationGroup of the release.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/helmtest"
+	"github.com/Azure/Orkestra/pkg/utils"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func main() {
+	var spec, filters string
+	var timeout time.Duration
+	var logs bool
+	flag.StringVar(&spec, "spec", "", "The base64 encoded HelmRelease of the release to test")
+	flag.DurationVar(&timeout, "timeout", 5*time.Minute, "The time to wait for each test hook to complete")
+	flag.StringVar(&filters, "filter", "", "The comma separated name=<test> or !name=<test> filters of the tests to run")
+	flag.BoolVar(&logs, "logs", false, "Add the logs of the test pods to the events of the ApplicationGroup")
+	opts := zap.Options{Development: true}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	log := ctrl.Log.WithName("helmtest")
+
+	hr, err := utils.B64ToHr(spec)
+	if err != nil {
+		log.Error(err, "invalid helmrelease spec")
+		os.Exit(1)
+	}
+	var filterList []string
+	for _, f := range strings.Split(filters, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			filterList = append(filterList, f)
+		}
+	}
+
+	// The release is stored by the helm-controller in the storage namespace of the HelmRelease
+	os.Setenv("HELM_NAMESPACE", hr.GetStorageNamespace())
+	settings := cli.New()
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(settings.RESTClientGetter(), settings.Namespace(), os.Getenv("HELM_DRIVER"), func(format string, v ...interface{}) {}); err != nil {
+		log.Error(err, "failed to initialize helm")
+		os.Exit(1)
+	}
+
+	releaseName, releaseNamespace := hr.GetReleaseName(), hr.GetReleaseNamespace()
+	log.Info("running the release tests", "release", releaseName, "namespace", releaseNamespace, "filters", filterList)
+	results, runErr := helmtest.Run(actionConfig, releaseName, releaseNamespace, timeout, filterList)
+	if results == nil && runErr != nil {
+		log.Error(runErr, "failed to run the release tests")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	if logs {
+		clientset, err := actionConfig.KubernetesClientSet()
+		if err != nil {
+			log.Error(err, "failed to create the kubernetes clientset")
+		} else {
+			helmtest.CollectLogs(ctx, clientset, releaseNamespace, results)
+		}
+	}
+
+	failed := runErr != nil
+	for _, result := range results {
+		log.Info("release test completed", "test", result.Name, "phase", result.Phase, "logs", result.Logs)
+		failed = failed || result.Failed()
+	}
+
+	if appGroupName := hr.Labels[v1alpha1.OwnershipLabel]; appGroupName != "" {
+		if err := recordEvents(ctx, appGroupName, releaseName, results); err != nil {
+			// The results are still reported through the exit code of the executor
+			log.Error(err, "failed to record the test results")
+		}
+	}
+
+	if failed {
+		log.Error(runErr, "release tests failed", "release", releaseName)
+		os.Exit(1)
+	}
+	log.Info("release tests succeeded", "release", releaseName)
+}
+
+func recordEvents(ctx context.Context, appGroupName, releaseName string, results []helmtest.Result) error {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return err
+	}
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	return helmtest.RecordEvents(ctx, k8sClient, appGroupName, releaseName, results)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var errReadOnly = fmt.Errorf("the client is read-only")
+
+// readOnlyClient serves the reads from its reader and refuses every write
+type readOnlyClient struct {
+	client.Reader
+}
+
+func newReadOnlyClient(reader client.Reader) client.Client {
+	return &readOnlyClient{Reader: reader}
+}
+
+func (c *readOnlyClient) Create(context.Context, client.Object, ...client.CreateOption) error {
+	return errReadOnly
+}
+
+func (c *readOnlyClient) Delete(context.Context, client.Object, ...client.DeleteOption) error {
+	return errReadOnly
+}
+
+func (c *readOnlyClient) Update(context.Context, client.Object, ...client.UpdateOption) error {
+	return errReadOnly
+}
+
+func (c *readOnlyClient) Patch(context.Context, client.Object, client.Patch, ...client.PatchOption) error {
+	return errReadOnly
+}
+
+func (c *readOnlyClient) DeleteAllOf(context.Context, client.Object, ...client.DeleteAllOfOption) error {
+	return errReadOnly
+}
+
+func (c *readOnlyClient) Status() client.StatusWriter {
+	return c
+}
+
+func (c *readOnlyClient) Scheme() *runtime.Scheme {
+	return scheme
+}
+
+func (c *readOnlyClient) RESTMapper() meta.RESTMapper {
+	return nil
+}
+
+// emptyReader is the reader of a cluster without any object
+type emptyReader struct{}
+
+func (emptyReader) Get(_ context.Context, key client.ObjectKey, _ client.Object) error {
+	return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+}
+
+func (emptyReader) List(context.Context, client.ObjectList, ...client.ListOption) error {
+	return nil
+}
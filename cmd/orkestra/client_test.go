@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_readOnlyClient(t *testing.T) {
+	ctx := context.Background()
+	appGroup := &v1alpha1.ApplicationGroup{ObjectMeta: metav1.ObjectMeta{Name: "bookinfo"}}
+	c := newReadOnlyClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(appGroup).Build())
+
+	if err := c.Get(ctx, types.NamespacedName{Name: "bookinfo"}, &v1alpha1.ApplicationGroup{}); err != nil {
+		t.Fatalf("Get() error = %v, want the ApplicationGroup of the reader", err)
+	}
+	if err := c.Create(ctx, &v1alpha1.ApplicationGroup{ObjectMeta: metav1.ObjectMeta{Name: "ambassador"}}); !errors.Is(err, errReadOnly) {
+		t.Errorf("Create() error = %v, want %v", err, errReadOnly)
+	}
+	if err := c.Delete(ctx, appGroup); !errors.Is(err, errReadOnly) {
+		t.Errorf("Delete() error = %v, want %v", err, errReadOnly)
+	}
+	if err := c.Status().Update(ctx, appGroup); !errors.Is(err, errReadOnly) {
+		t.Errorf("Status().Update() error = %v, want %v", err, errReadOnly)
+	}
+}
+
+func Test_emptyReader(t *testing.T) {
+	ctx := context.Background()
+	c := newReadOnlyClient(emptyReader{})
+	if err := c.Get(ctx, types.NamespacedName{Name: "bookinfo"}, &v1alpha1.ApplicationGroup{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Get() error = %v, want not found", err)
+	}
+	list := &v1alpha1.ApplicationGroupList{}
+	if err := c.List(ctx, list); err != nil || len(list.Items) != 0 {
+		t.Errorf("List() = %v, %v, want no items", list.Items, err)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Azure/Orkestra/pkg/executor"
+	"github.com/Azure/Orkestra/pkg/templates"
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+func newDecodeCmd() *cobra.Command {
+	o := &workflowOptions{}
+	cmd := &cobra.Command{
+		Use:   "decode [WORKFLOW] TASK",
+		Short: "Print the HelmRelease passed to a workflow task as YAML",
+		Long: `Decode the base64 encoded helmrelease parameter of a workflow task and print it as YAML.
+
+The workflow is read from the workflow namespace of the cluster,
+or from a YAML file when --file is specified.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf, err := loadWorkflow(cmd.Context(), o, args[:len(args)-1])
+			if err != nil {
+				return err
+			}
+			hr, err := taskHelmRelease(wf, args[len(args)-1])
+			if err != nil {
+				return err
+			}
+			b, err := yaml.Marshal(hr)
+			if err != nil {
+				return fmt.Errorf("failed to marshal the helmrelease: %w", err)
+			}
+			_, err = cmd.OutOrStdout().Write(b)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&o.file, "file", "f", "", "Read the workflow from a YAML file instead of the cluster")
+	o.addNamespaceFlag(cmd)
+	return cmd
+}
+
+// loadWorkflow reads the workflow from the file of the options
+// or fetches the named workflow from the workflow namespace
+func loadWorkflow(ctx context.Context, o *workflowOptions, args []string) (*v1alpha13.Workflow, error) {
+	wf := &v1alpha13.Workflow{}
+	if o.file != "" {
+		b, err := os.ReadFile(o.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", o.file, err)
+		}
+		if err := yaml.Unmarshal(b, wf); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the workflow in %s: %w", o.file, err)
+		}
+		return wf, nil
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("either the workflow name or --file must be specified")
+	}
+	c, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: o.namespace, Name: args[0]}, wf); err != nil {
+		return nil, fmt.Errorf("failed to get workflow %s: %w", args[0], err)
+	}
+	return wf, nil
+}
+
+// taskHelmRelease decodes the helmrelease parameter of the named task. A task with several executors
+// runs a task sub-template of the same name whose executor tasks all share the helmrelease,
+// so the first executor task of the sub-template is used for those.
+func taskHelmRelease(wf *v1alpha13.Workflow, taskName string) (*fluxhelmv2beta1.HelmRelease, error) {
+	taskName = utils.ConvertToDNS1123(taskName)
+	for _, template := range wf.Spec.Templates {
+		if template.DAG == nil || template.Name == templates.EntrypointTemplateName {
+			continue
+		}
+		for _, task := range template.DAG.Tasks {
+			if task.Name != taskName {
+				continue
+			}
+			if hrStr := helmReleaseParam(task); hrStr != "" {
+				return utils.B64ToHr(hrStr)
+			}
+			// The task runs the executors in a task sub-template
+			for _, sub := range wf.Spec.Templates {
+				if sub.Name != task.Template || sub.DAG == nil {
+					continue
+				}
+				for _, executorTask := range sub.DAG.Tasks {
+					if hrStr := helmReleaseParam(executorTask); hrStr != "" {
+						return utils.B64ToHr(hrStr)
+					}
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("no task %s with a %s parameter found in workflow %s", taskName, executor.HelmReleaseArg, wf.Name)
+}
+
+func helmReleaseParam(task v1alpha13.DAGTask) string {
+	for _, param := range task.Arguments.Parameters {
+		if param.Name == executor.HelmReleaseArg && param.Value != nil {
+			return param.Value.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Azure/Orkestra/pkg/executor"
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func executorTask(name, template, hrName string) v1alpha13.DAGTask {
+	hr := &fluxhelmv2beta1.HelmRelease{ObjectMeta: metav1.ObjectMeta{Name: hrName}}
+	return v1alpha13.DAGTask{
+		Name:     name,
+		Template: template,
+		Arguments: v1alpha13.Arguments{
+			Parameters: []v1alpha13.Parameter{
+				{Name: executor.HelmReleaseArg, Value: utils.ToAnyStringPtr(utils.HrToB64(hr))},
+			},
+		},
+	}
+}
+
+func Test_taskHelmRelease(t *testing.T) {
+	wf := &v1alpha13.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "bookinfo"},
+		Spec: v1alpha13.WorkflowSpec{
+			Templates: []v1alpha13.Template{
+				{
+					Name: "bookinfo",
+					DAG: &v1alpha13.DAGTemplate{
+						Tasks: []v1alpha13.DAGTask{
+							executorTask("bookinfo-productpage", "helmrelease-forward-executor", "bookinfo-productpage"),
+							{Name: "bookinfo-bookinfo", Template: "bookinfo-bookinfo"},
+						},
+					},
+				},
+				{
+					Name: "bookinfo-bookinfo",
+					DAG: &v1alpha13.DAGTemplate{
+						Tasks: []v1alpha13.DAGTask{
+							executorTask("helmrelease", "helmrelease-forward-executor", "bookinfo"),
+						},
+					},
+				},
+				{
+					Name: "entry",
+					DAG: &v1alpha13.DAGTemplate{
+						Tasks: []v1alpha13.DAGTask{{Name: "bookinfo", Template: "bookinfo"}},
+					},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		taskName string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Executor Task",
+			taskName: "bookinfo-productpage",
+			want:     "bookinfo-productpage",
+		},
+		{
+			name:     "Task Sub-Template",
+			taskName: "bookinfo-bookinfo",
+			want:     "bookinfo",
+		},
+		{
+			name:     "Application Template",
+			taskName: "bookinfo",
+			wantErr:  true,
+		},
+		{
+			name:     "Unknown Task",
+			taskName: "ambassador-ambassador",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := taskHelmRelease(wf, tt.taskName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("taskHelmRelease() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Name != tt.want {
+				t.Errorf("taskHelmRelease() = %v, want %v", got.Name, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = v1alpha13.AddToScheme(scheme)
+	_ = fluxhelmv2beta1.AddToScheme(scheme)
+}
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "orkestra",
+		Short:        "Inspect and operate Orkestra ApplicationGroups",
+		SilenceUsage: true,
+	}
+	// Pick up the --kubeconfig flag registered by controller-runtime
+	cmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+	cmd.AddCommand(
+		newPlanCmd(),
+		newRenderCmd(),
+		newStatusCmd(),
+		newDecodeCmd(),
+		newRollbackCmd(),
+		newSuspendCmd(),
+		newResumeCmd(),
+	)
+	return cmd
+}
+
+// newClient returns a client for the cluster in the current kubeconfig context
+func newClient() (client.Client, error) {
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the kubeconfig: %w", err)
+	}
+	return client.New(cfg, client.Options{Scheme: scheme})
+}
+
+// loadApplicationGroup reads the ApplicationGroup from the given file
+// or fetches the named ApplicationGroup from the cluster
+func loadApplicationGroup(ctx context.Context, file string, args []string) (*v1alpha1.ApplicationGroup, error) {
+	appGroup := &v1alpha1.ApplicationGroup{}
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if err := yaml.UnmarshalStrict(b, appGroup); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the application group in %s: %w", file, err)
+		}
+		return appGroup, nil
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("either the application group name or --file must be specified")
+	}
+	c, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: args[0]}, appGroup); err != nil {
+		return nil, fmt.Errorf("failed to get application group %s: %w", args[0], err)
+	}
+	return appGroup, nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/helpers"
+	"github.com/Azure/Orkestra/pkg/workflow"
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// operation runs against the ApplicationGroup fetched from the cluster. Any condition
+// changes the operation makes to the ApplicationGroup are patched onto its status afterwards.
+type operation func(ctx context.Context, c client.Client, appGroup *v1alpha1.ApplicationGroup) error
+
+func runOperation(ctx context.Context, name string, op operation) error {
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	appGroup := &v1alpha1.ApplicationGroup{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, appGroup); err != nil {
+		return fmt.Errorf("failed to get application group %s: %w", name, err)
+	}
+	statusHelper := &helpers.StatusHelper{
+		Client:    c,
+		Logger:    logr.Discard(),
+		PatchFrom: client.MergeFrom(appGroup.DeepCopy()),
+	}
+	if err := op(ctx, c, appGroup); err != nil {
+		return err
+	}
+	if err := statusHelper.PatchStatus(ctx, appGroup); err != nil {
+		return fmt.Errorf("failed to patch the status of application group %s: %w", name, err)
+	}
+	return nil
+}
+
+func newRollbackCmd() *cobra.Command {
+	var toRevision int64
+	cmd := &cobra.Command{
+		Use:   "rollback NAME",
+		Short: "Roll an ApplicationGroup back to its last successful spec or to a previous revision",
+		Long: `Request the controller to roll an ApplicationGroup back to its most recent successful revision,
+or to the successful revision with the generation given by --to-revision, through the
+` + v1alpha1.RollbackToAnnotation + ` annotation. The controller suspends the forward
+workflow and submits the rollback workflow.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := newClient()
+			if err != nil {
+				return err
+			}
+			appGroup := &v1alpha1.ApplicationGroup{}
+			if err := c.Get(ctx, types.NamespacedName{Name: args[0]}, appGroup); err != nil {
+				return fmt.Errorf("failed to get application group %s: %w", args[0], err)
+			}
+			patch := client.MergeFrom(appGroup.DeepCopy())
+			generation, err := requestRollback(appGroup, toRevision)
+			if err != nil {
+				return err
+			}
+			if err := c.Patch(ctx, appGroup, patch); err != nil {
+				return fmt.Errorf("failed to request the rollback of application group %s: %w", appGroup.Name, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "applicationgroup %s rolling back to revision %d\n", appGroup.Name, generation)
+			return nil
+		},
+	}
+	cmd.Flags().Int64Var(&toRevision, "to-revision", 0, "The generation of the successful revision to roll back to")
+	return cmd
+}
+
+// requestRollback sets the RollbackToAnnotation to the requested revision, or to the most recent
+// successful revision if none is requested, and returns the generation of the revision
+func requestRollback(appGroup *v1alpha1.ApplicationGroup, toRevision int64) (int64, error) {
+	if toRevision == 0 {
+		if len(appGroup.Status.Revisions) == 0 {
+			return 0, fmt.Errorf("application group %s has no successful revision to roll back to", appGroup.Name)
+		}
+		toRevision = appGroup.Status.Revisions[0].Generation
+	}
+	if appGroup.Annotations == nil {
+		appGroup.Annotations = make(map[string]string)
+	}
+	appGroup.Annotations[v1alpha1.RollbackToAnnotation] = strconv.FormatInt(toRevision, 10)
+	return toRevision, nil
+}
+
+func newSuspendCmd() *cobra.Command {
+	o := &workflowOptions{}
+	cmd := &cobra.Command{
+		Use:   "suspend NAME",
+		Short: "Suspend a running workflow of an ApplicationGroup",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runOperation(cmd.Context(), args[0], func(ctx context.Context, c client.Client, appGroup *v1alpha1.ApplicationGroup) error {
+				wfClient, err := o.clusterClient(c, appGroup)
+				if err != nil {
+					return err
+				}
+				if err := workflow.Suspend(ctx, wfClient); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "workflow %s suspended\n", wfClient.GetName())
+				return nil
+			})
+		},
+	}
+	o.addTypeFlag(cmd)
+	o.addNamespaceFlag(cmd)
+	return cmd
+}
+
+func newResumeCmd() *cobra.Command {
+	o := &workflowOptions{}
+	cmd := &cobra.Command{
+		Use:   "resume NAME",
+		Short: "Resume a suspended workflow of an ApplicationGroup",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runOperation(cmd.Context(), args[0], func(ctx context.Context, c client.Client, appGroup *v1alpha1.ApplicationGroup) error {
+				wfClient, err := o.clusterClient(c, appGroup)
+				if err != nil {
+					return err
+				}
+				if err := workflow.Resume(ctx, wfClient); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "workflow %s resumed\n", wfClient.GetName())
+				return nil
+			})
+		},
+	}
+	o.addTypeFlag(cmd)
+	o.addNamespaceFlag(cmd)
+	return cmd
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_requestRollback(t *testing.T) {
+	tests := []struct {
+		name       string
+		revisions  []v1alpha1.RevisionStatus
+		toRevision int64
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:      "Most Recent Revision",
+			revisions: []v1alpha1.RevisionStatus{{Name: "bookinfo-5", Generation: 5}, {Name: "bookinfo-3", Generation: 3}},
+			want:      "5",
+		},
+		{
+			name:       "Requested Revision",
+			revisions:  []v1alpha1.RevisionStatus{{Name: "bookinfo-5", Generation: 5}, {Name: "bookinfo-3", Generation: 3}},
+			toRevision: 3,
+			want:       "3",
+		},
+		{
+			name:    "Without Revisions",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appGroup := &v1alpha1.ApplicationGroup{ObjectMeta: metav1.ObjectMeta{Name: "bookinfo"}}
+			appGroup.Status.Revisions = tt.revisions
+			_, err := requestRollback(appGroup, tt.toRevision)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("requestRollback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := appGroup.Annotations[v1alpha1.RollbackToAnnotation]; got != tt.want {
+				t.Errorf("requestRollback() annotation = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/workflow"
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type workflowOptions struct {
+	file         string
+	workflowType string
+	namespace    string
+	parallelism  int64
+}
+
+func (o *workflowOptions) addFileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.file, "file", "f", "", "Read the ApplicationGroup from a YAML file instead of the cluster")
+}
+
+func (o *workflowOptions) addTypeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.workflowType, "type", "t", string(v1alpha1.Forward), "The workflow type, one of forward, reverse or rollback")
+}
+
+func (o *workflowOptions) addNamespaceFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.namespace, "workflow-namespace", workflow.GetNamespace(), "The namespace the workflows run in")
+}
+
+func (o *workflowOptions) addParallelismFlag(cmd *cobra.Command) {
+	cmd.Flags().Int64Var(&o.parallelism, "workflow-parallelism", 10, "The max number of workflow pods that can be executed in parallel")
+}
+
+func (o *workflowOptions) getType() (v1alpha1.WorkflowType, error) {
+	wfType := v1alpha1.WorkflowType(o.workflowType)
+	if _, ok := v1alpha1.WorkflowConditionMap[wfType]; !ok {
+		return "", fmt.Errorf("unknown workflow type %q", o.workflowType)
+	}
+	return wfType, nil
+}
+
+func (o *workflowOptions) builder(c client.Client) *workflow.Builder {
+	return workflow.NewBuilder(c, logr.Discard()).WithParallelism(o.parallelism).InNamespace(o.namespace)
+}
+
+// generateClient builds a workflow client of the requested type on top of a read-only client, so that
+// generating the workflow never changes the workflows in the cluster. Only the rollback workflow of an
+// ApplicationGroup fetched from the cluster reads its revisions and forward workflow from the cluster.
+func (o *workflowOptions) generateClient(appGroup *v1alpha1.ApplicationGroup) (workflow.Client, error) {
+	wfType, err := o.getType()
+	if err != nil {
+		return nil, err
+	}
+	var reader client.Reader = emptyReader{}
+	if o.file == "" && wfType == v1alpha1.Rollback {
+		c, err := newClient()
+		if err != nil {
+			return nil, err
+		}
+		reader = c
+	}
+	return o.builder(newReadOnlyClient(reader)).Build(wfType, appGroup), nil
+}
+
+// clusterClient builds a workflow client of the requested type
+// that operates on the workflows in the cluster
+func (o *workflowOptions) clusterClient(c client.Client, appGroup *v1alpha1.ApplicationGroup) (workflow.Client, error) {
+	wfType, err := o.getType()
+	if err != nil {
+		return nil, err
+	}
+	return o.builder(c).Build(wfType, appGroup), nil
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package main
+
+import (
+	"github.com/Azure/Orkestra/pkg/plan"
+	"github.com/spf13/cobra"
+)
+
+func newPlanCmd() *cobra.Command {
+	o := &workflowOptions{}
+	cmd := &cobra.Command{
+		Use:   "plan [NAME]",
+		Short: "Render the workflow and HelmReleases of an ApplicationGroup without submitting them",
+		Long: `Render the Argo Workflow and the HelmReleases that the workflow executors would apply
+for an ApplicationGroup without submitting anything to the cluster.
+
+For the rollback workflow the combined graph of the rolled back and reversed
+applications is written as a comment at the top of the output.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appGroup, err := loadApplicationGroup(cmd.Context(), o.file, args)
+			if err != nil {
+				return err
+			}
+			wfClient, err := o.generateClient(appGroup)
+			if err != nil {
+				return err
+			}
+			p, err := plan.Generate(cmd.Context(), wfClient)
+			if err != nil {
+				return err
+			}
+			return p.Write(cmd.OutOrStdout())
+		},
+	}
+	o.addFileFlag(cmd)
+	o.addTypeFlag(cmd)
+	o.addNamespaceFlag(cmd)
+	o.addParallelismFlag(cmd)
+	return cmd
+}
@@ -0,0 +1,44 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+func newRenderCmd() *cobra.Command {
+	o := &workflowOptions{}
+	cmd := &cobra.Command{
+		Use:   "render [NAME]",
+		Short: "Print the workflow generated for an ApplicationGroup",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appGroup, err := loadApplicationGroup(cmd.Context(), o.file, args)
+			if err != nil {
+				return err
+			}
+			wfClient, err := o.generateClient(appGroup)
+			if err != nil {
+				return err
+			}
+			if err := wfClient.Generate(cmd.Context()); err != nil {
+				return fmt.Errorf("failed to generate workflow: %w", err)
+			}
+			b, err := yaml.Marshal(wfClient.GetWorkflow())
+			if err != nil {
+				return fmt.Errorf("failed to marshal the workflow: %w", err)
+			}
+			_, err = cmd.OutOrStdout().Write(b)
+			return err
+		},
+	}
+	o.addFileFlag(cmd)
+	o.addTypeFlag(cmd)
+	o.addNamespaceFlag(cmd)
+	o.addParallelismFlag(cmd)
+	return cmd
+}
@@ -0,0 +1,220 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/graph"
+	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/Azure/Orkestra/pkg/utils"
+	"github.com/Azure/Orkestra/pkg/workflow"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newStatusCmd() *cobra.Command {
+	o := &workflowOptions{}
+	cmd := &cobra.Command{
+		Use:   "status NAME",
+		Short: "Show the application, subchart and executor tree of an ApplicationGroup with its status",
+		Long: `Show the conditions of an ApplicationGroup along with the tree of its applications, subcharts
+and executors. Each node of the tree is annotated with the chart status of the ApplicationGroup
+and the phase of the matching node of the workflow selected by --type.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient()
+			if err != nil {
+				return err
+			}
+			appGroup := &v1alpha1.ApplicationGroup{}
+			if err := c.Get(cmd.Context(), types.NamespacedName{Name: args[0]}, appGroup); err != nil {
+				return fmt.Errorf("failed to get application group %s: %w", args[0], err)
+			}
+			wfClient, err := o.clusterClient(c, appGroup)
+			if err != nil {
+				return err
+			}
+			g, err := statusGraph(cmd.Context(), wfClient)
+			if err != nil {
+				return err
+			}
+			wf, err := workflow.GetWorkflow(cmd.Context(), wfClient)
+			if errors.IsNotFound(err) {
+				wf = nil
+			} else if err != nil {
+				return fmt.Errorf("failed to get workflow %s: %w", wfClient.GetName(), err)
+			}
+			return writeStatus(cmd.OutOrStdout(), appGroup, g, wfClient.GetName(), wf)
+		},
+	}
+	o.addTypeFlag(cmd)
+	o.addNamespaceFlag(cmd)
+	return cmd
+}
+
+// statusGraph returns the graph the workflow of the workflow client is generated from
+func statusGraph(ctx context.Context, wfClient workflow.Client) (*graph.Graph, error) {
+	appGroup := wfClient.GetAppGroup()
+	switch wfClient.GetType() {
+	case v1alpha1.Reverse:
+		return graph.NewReverseGraph(appGroup), nil
+	case v1alpha1.Rollback:
+		return workflow.RollbackGraph(ctx, wfClient)
+	default:
+		return graph.NewForwardGraph(appGroup), nil
+	}
+}
+
+func writeStatus(w io.Writer, appGroup *v1alpha1.ApplicationGroup, g *graph.Graph, wfName string, wf *v1alpha13.Workflow) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "NAME:\t%s\n", appGroup.Name)
+	fmt.Fprintf(tw, "GENERATION:\t%d (observed %d, last succeeded %d)\n", appGroup.Generation, appGroup.Status.ObservedGeneration, appGroup.Status.LastSucceededGeneration)
+	fmt.Fprintln(tw, "CONDITIONS:")
+	for _, condition := range appGroup.Status.Conditions {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+	}
+	if len(appGroup.Status.Revisions) > 0 {
+		fmt.Fprintln(tw, "REVISIONS:")
+		for _, revision := range appGroup.Status.Revisions {
+			fmt.Fprintf(tw, "  %d\t%s\t%s\n", revision.Generation, revision.Name, revision.CreationTimestamp.UTC().Format(time.RFC3339))
+		}
+	}
+	if wf == nil {
+		fmt.Fprintf(tw, "WORKFLOW:\t%s (not found)\n", wfName)
+	} else {
+		fmt.Fprintf(tw, "WORKFLOW:\t%s (%s)\n", wfName, phaseString(wf.Status.Phase))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	nodes := newNodeIndex(wf)
+	chartStatuses := make(map[string]v1alpha1.ApplicationStatus)
+	for _, appStatus := range appGroup.Status.Applications {
+		chartStatuses[appStatus.Name] = appStatus
+	}
+	for _, appName := range graph.SortedAppNames(g.Nodes) {
+		appNode := g.Nodes[appName]
+		appWfNode := nodes.child(nodes.rootID, appName)
+		fmt.Fprintf(w, "  app %s%s\n", appName, nodePhaseString(appWfNode))
+		for _, taskName := range graph.SortedTaskNames(appNode.Tasks) {
+			task := appNode.Tasks[taskName]
+			chartStatus := taskChartStatus(chartStatuses[appName], appName, task)
+			taskWfNode := nodes.child(idOf(appWfNode), taskName)
+			fmt.Fprintf(w, "    task %s chart %s:%s%s%s\n", taskName, task.ChartName, task.ChartVersion, chartStatusString(chartStatus), nodePhaseString(taskWfNode))
+			if len(task.Executors) == 1 {
+				// The executor runs as the task node itself when there is only one
+				for name := range task.Executors {
+					fmt.Fprintf(w, "      executor %s%s\n", name, nodePhaseString(taskWfNode))
+				}
+				continue
+			}
+			for _, executorName := range graph.SortedExecutorNames(task.Executors) {
+				fmt.Fprintf(w, "      executor %s%s\n", executorName, nodePhaseString(nodes.child(idOf(taskWfNode), executorName)))
+			}
+		}
+	}
+	return nil
+}
+
+// taskChartStatus returns the chart status of the application or subchart of the task node
+func taskChartStatus(appStatus v1alpha1.ApplicationStatus, appName string, task *graph.TaskNode) *v1alpha1.ChartStatus {
+	if task.Parent == "" {
+		if appStatus.Name == "" {
+			return nil
+		}
+		return &appStatus.ChartStatus
+	}
+	subchartStatus, ok := appStatus.Subcharts[strings.TrimPrefix(task.Name, appName+"-")]
+	if !ok {
+		return nil
+	}
+	return &subchartStatus
+}
+
+func chartStatusString(chartStatus *v1alpha1.ChartStatus) string {
+	if chartStatus == nil {
+		return ""
+	}
+	var parts []string
+	if chartStatus.Staged {
+		parts = append(parts, "staged")
+	}
+	for _, condition := range chartStatus.Conditions {
+		if condition.Type == meta.ReadyCondition {
+			parts = append(parts, fmt.Sprintf("%s=%s (%s)", condition.Type, condition.Status, condition.Reason))
+		}
+	}
+	if chartStatus.Error != "" {
+		parts = append(parts, fmt.Sprintf("error: %s", chartStatus.Error))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, " ")
+}
+
+func phaseString(phase v1alpha13.WorkflowPhase) string {
+	if phase == "" {
+		return string(v1alpha13.WorkflowPending)
+	}
+	return string(phase)
+}
+
+func nodePhaseString(node *v1alpha13.NodeStatus) string {
+	if node == nil {
+		return ""
+	}
+	if node.Message != "" {
+		return fmt.Sprintf(" [%s: %s]", node.Phase, node.Message)
+	}
+	return fmt.Sprintf(" [%s]", node.Phase)
+}
+
+// nodeIndex looks up the workflow nodes by the ID of the template
+// boundary they belong to and their display name
+type nodeIndex struct {
+	rootID string
+	nodes  map[string]map[string]*v1alpha13.NodeStatus
+}
+
+func newNodeIndex(wf *v1alpha13.Workflow) *nodeIndex {
+	idx := &nodeIndex{nodes: make(map[string]map[string]*v1alpha13.NodeStatus)}
+	if wf == nil {
+		return idx
+	}
+	// The root node of the entry template is identified by the workflow name
+	idx.rootID = wf.Name
+	for id := range wf.Status.Nodes {
+		node := wf.Status.Nodes[id]
+		if _, ok := idx.nodes[node.BoundaryID]; !ok {
+			idx.nodes[node.BoundaryID] = make(map[string]*v1alpha13.NodeStatus)
+		}
+		idx.nodes[node.BoundaryID][node.DisplayName] = &node
+	}
+	return idx
+}
+
+func (idx *nodeIndex) child(boundaryID, name string) *v1alpha13.NodeStatus {
+	if boundaryID == "" {
+		return nil
+	}
+	return idx.nodes[boundaryID][utils.ConvertToDNS1123(name)]
+}
+
+func idOf(node *v1alpha13.NodeStatus) string {
+	if node == nil {
+		return ""
+	}
+	return node.ID
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/graph"
+	"github.com/Azure/Orkestra/pkg/meta"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_writeStatus(t *testing.T) {
+	appGroup := &v1alpha1.ApplicationGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "bookinfo", Generation: 2},
+		Spec: v1alpha1.ApplicationGroupSpec{
+			Applications: []v1alpha1.Application{
+				{
+					DAG: v1alpha1.DAG{Name: "ambassador"},
+					Spec: v1alpha1.ApplicationSpec{
+						Chart:   &v1alpha1.ChartRef{Name: "ambassador", Version: "6.6.0"},
+						Release: &v1alpha1.Release{TargetNamespace: "ambassador"},
+					},
+				},
+				{
+					DAG: v1alpha1.DAG{Name: "bookinfo", Dependencies: []string{"ambassador"}},
+					Spec: v1alpha1.ApplicationSpec{
+						Chart:     &v1alpha1.ChartRef{Name: "bookinfo", Version: "v1"},
+						Release:   &v1alpha1.Release{TargetNamespace: "bookinfo"},
+						Subcharts: []v1alpha1.DAG{{Name: "productpage"}},
+						Workflow: []v1alpha1.Executor{
+							{DAG: v1alpha1.DAG{Name: "helmrelease"}, Type: v1alpha1.HelmReleaseExecutor},
+							{DAG: v1alpha1.DAG{Name: "keptn", Dependencies: []string{"helmrelease"}}, Type: v1alpha1.KeptnExecutor},
+						},
+					},
+				},
+			},
+		},
+		Status: v1alpha1.ApplicationGroupStatus{
+			ObservedGeneration:      2,
+			LastSucceededGeneration: 1,
+			Conditions: []metav1.Condition{
+				{Type: meta.ReadyCondition, Status: metav1.ConditionFalse, Reason: meta.WorkflowFailedReason, Message: "workflow in failed state"},
+			},
+			Applications: []v1alpha1.ApplicationStatus{
+				{
+					Name: "ambassador",
+					ChartStatus: v1alpha1.ChartStatus{
+						Version:    "6.6.0",
+						Staged:     true,
+						Conditions: []metav1.Condition{{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, Reason: "ReconciliationSucceeded"}},
+					},
+				},
+				{
+					Name:        "bookinfo",
+					ChartStatus: v1alpha1.ChartStatus{Version: "v1", Staged: true},
+					Subcharts: map[string]v1alpha1.ChartStatus{
+						"productpage": {Version: "v1", Staged: true},
+					},
+				},
+			},
+		},
+	}
+	wf := &v1alpha13.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "bookinfo"},
+		Status: v1alpha13.WorkflowStatus{
+			Phase: v1alpha13.WorkflowFailed,
+			Nodes: map[string]v1alpha13.NodeStatus{
+				"bookinfo":           {ID: "bookinfo", DisplayName: "bookinfo", Phase: v1alpha13.NodeFailed},
+				"ambassador-id":      {ID: "ambassador-id", BoundaryID: "bookinfo", DisplayName: "ambassador", Phase: v1alpha13.NodeSucceeded},
+				"ambassador-task-id": {ID: "ambassador-task-id", BoundaryID: "ambassador-id", DisplayName: "ambassador-ambassador", Phase: v1alpha13.NodeSucceeded},
+				"bookinfo-id":        {ID: "bookinfo-id", BoundaryID: "bookinfo", DisplayName: "bookinfo", Phase: v1alpha13.NodeFailed},
+				"productpage-id":     {ID: "productpage-id", BoundaryID: "bookinfo-id", DisplayName: "bookinfo-productpage", Phase: v1alpha13.NodeSucceeded},
+				"productpage-hr-id":  {ID: "productpage-hr-id", BoundaryID: "productpage-id", DisplayName: "helmrelease", Phase: v1alpha13.NodeSucceeded},
+				"productpage-k-id":   {ID: "productpage-k-id", BoundaryID: "productpage-id", DisplayName: "keptn", Phase: v1alpha13.NodeSucceeded},
+				"bookinfo-task-id":   {ID: "bookinfo-task-id", BoundaryID: "bookinfo-id", DisplayName: "bookinfo-bookinfo", Phase: v1alpha13.NodeFailed},
+				"bookinfo-hr-id":     {ID: "bookinfo-hr-id", BoundaryID: "bookinfo-task-id", DisplayName: "helmrelease", Phase: v1alpha13.NodeSucceeded},
+				"bookinfo-k-id":      {ID: "bookinfo-k-id", BoundaryID: "bookinfo-task-id", DisplayName: "keptn", Phase: v1alpha13.NodeFailed, Message: "evaluation failed"},
+			},
+		},
+	}
+
+	var sb strings.Builder
+	if err := writeStatus(&sb, appGroup, graph.NewForwardGraph(appGroup), wf.Name, wf); err != nil {
+		t.Fatalf("writeStatus() error = %v", err)
+	}
+	want := `NAME:        bookinfo
+GENERATION:  2 (observed 2, last succeeded 1)
+CONDITIONS:
+  Ready    False  WorkflowFailed  workflow in failed state
+WORKFLOW:  bookinfo (Failed)
+  app ambassador [Succeeded]
+    task ambassador-ambassador chart ambassador:6.6.0 staged Ready=True (ReconciliationSucceeded) [Succeeded]
+      executor helmrelease [Succeeded]
+  app bookinfo [Failed]
+    task bookinfo-bookinfo chart bookinfo:v1 staged [Failed]
+      executor helmrelease [Succeeded]
+      executor keptn [Failed: evaluation failed]
+    task bookinfo-productpage chart a82a4dac39-productpage:v1 staged [Succeeded]
+      executor helmrelease [Succeeded]
+      executor keptn [Succeeded]
+`
+	if sb.String() != want {
+		t.Errorf("writeStatus() diff = %v", cmp.Diff(sb.String(), want))
+	}
+}
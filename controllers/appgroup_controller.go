@@ -6,22 +6,43 @@ package controllers
 import (
 	"context"
 	"errors"
+	"time"
+
 	"github.com/Azure/Orkestra/pkg/meta"
 
 	"github.com/Azure/Orkestra/pkg/helpers"
+	"github.com/Azure/Orkestra/pkg/metrics"
 
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/Azure/Orkestra/api/v1alpha1"
 	"github.com/Azure/Orkestra/pkg/registry"
+	"github.com/Azure/Orkestra/pkg/revision"
 	"github.com/Azure/Orkestra/pkg/workflow"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// sourceNotReadyRequeueDelay is the delay before the charts are fetched again from the Flux sources that are not ready
+const sourceNotReadyRequeueDelay = 10 * time.Second
+
+const (
+	// secretRefIndexKey indexes the ApplicationGroups by the namespace/name of the secrets they reference
+	secretRefIndexKey = ".spec.applications.secretRefs"
+	// configMapRefIndexKey indexes the ApplicationGroups by the namespace/name of the configmaps they reference
+	configMapRefIndexKey = ".spec.applications.configMapRefs"
 )
 
 // ApplicationGroupReconciler reconciles a ApplicationGroup object
@@ -30,14 +51,26 @@ type ApplicationGroupReconciler struct {
 	Log    logr.Logger
 	Scheme *runtime.Scheme
 
+	// APIReader reads the referenced Secrets and ConfigMaps from the API server, since only their metadata is cached
+	APIReader client.Reader
+
 	// RegistryClient interacts with the helm registries to pull and push charts
 	RegistryClient *registry.Client
 
+	// StagingStore is the backend of the repository used for staging artifacts before being deployed using the HelmRelease object
+	StagingStore registry.StagingStore
+
 	// StagingRepoName is the nickname for the repository used for staging artifacts before being deployed using the HelmRelease object
 	StagingRepoName string
 
 	WorkflowClientBuilder *workflow.Builder
 
+	// RevisionHistory stores the successfully rolled out revisions of the ApplicationGroups
+	RevisionHistory *revision.History
+
+	// StagingGC removes the staged charts of the deleted ApplicationGroups
+	StagingGC *registry.StagingGC
+
 	// TargetDir to stage the charts before pushing
 	TargetDir string
 
@@ -52,10 +85,20 @@ type ApplicationGroupReconciler struct {
 	// CleanupDownloadedCharts signals the controller to delete the
 	// fetched charts after they have been repackaged and pushed to staging
 	CleanupDownloadedCharts bool
+
+	// MaxConcurrentCharts is the number of applications of an ApplicationGroup
+	// whose charts are pulled and staged concurrently
+	MaxConcurrentCharts int
+
+	// MaxConcurrentReconciles is the number of ApplicationGroups that are reconciled concurrently
+	MaxConcurrentReconciles int
 }
 
 // +kubebuilder:rbac:groups=orkestra.azure.microsoft.com,resources=applicationgroups,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=orkestra.azure.microsoft.com,resources=applicationgroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=gitrepositories;buckets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;list;watch
 
 func (r *ApplicationGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	appGroup := &v1alpha1.ApplicationGroup{}
@@ -65,6 +108,9 @@ func (r *ApplicationGroupReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	if err := r.Get(ctx, req.NamespacedName, appGroup); err != nil {
 		if kerrors.IsNotFound(err) {
 			logr.V(3).Info("skip reconciliation since AppGroup instance not found on the cluster")
+			metrics.SetProgressing(req.Name, false)
+			// The ApplicationGroup has been deleted, so its staged charts can be collected
+			r.StagingGC.Trigger()
 			return ctrl.Result{}, nil
 		}
 		logr.Error(err, "unable to fetch ApplicationGroup instance")
@@ -73,21 +119,26 @@ func (r *ApplicationGroupReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	patch := client.MergeFrom(appGroup.DeepCopy())
 
 	statusHelper := &helpers.StatusHelper{
-		Client:    r.Client,
-		Logger:    logr,
-		PatchFrom: patch,
-		Recorder:  r.Recorder,
+		Client:          r.Client,
+		Logger:          logr,
+		PatchFrom:       patch,
+		Recorder:        r.Recorder,
+		RevisionHistory: r.RevisionHistory,
 	}
 	reconcileHelper := helpers.ReconcileHelper{
 		Client:                r.Client,
 		Logger:                logr,
+		APIReader:             r.APIReader,
 		Instance:              appGroup,
 		WorkflowClientBuilder: r.WorkflowClientBuilder,
+		RevisionHistory:       r.RevisionHistory,
 		RegistryClient:        r.RegistryClient,
+		StagingStore:          r.StagingStore,
 		RegistryOptions: helpers.RegistryClientOptions{
 			StagingRepoName:         r.StagingRepoName,
 			TargetDir:               r.TargetDir,
 			CleanupDownloadedCharts: r.CleanupDownloadedCharts,
+			MaxConcurrentCharts:     r.MaxConcurrentCharts,
 		},
 		StatusHelper: statusHelper,
 	}
@@ -124,23 +175,137 @@ func (r *ApplicationGroupReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		}
 	}
 
+	// Suspend the in-flight workflows and stop reconciling until the suspend flag is cleared.
+	// The observed generation is left as is so that the workflows can be resumed afterwards
+	if appGroup.Spec.Suspend {
+		if err := reconcileHelper.Suspend(ctx); err != nil {
+			logr.Error(err, "failed to suspend the appgroup workflows")
+			return ctrl.Result{}, err
+		}
+		statusHelper.MarkSuspended(appGroup)
+		return ctrl.Result{}, nil
+	}
+
+	// Roll back to the revision requested through the annotation. If the spec was changed at the same
+	// time, the forward workflow of the new generation below takes over from the rollback workflow
+	if _, ok := appGroup.Annotations[v1alpha1.RollbackToAnnotation]; ok {
+		if err := reconcileHelper.RollbackToRevision(ctx); err != nil {
+			logr.Error(err, "failed to roll back to the requested revision")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Approve the pending approval gates of the forward workflow requested through the annotation
+	if _, ok := appGroup.Annotations[v1alpha1.ApproveAnnotation]; ok {
+		if err := reconcileHelper.Approve(ctx); err != nil {
+			logr.Error(err, "failed to approve the requested approval gates")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The referenced secrets and configmaps, such as the repository credentials and the keptn configmaps,
+	// are rolled out again when they change. The first hash recorded for a generation never triggers a rollout
+	referencesHash, err := reconcileHelper.ReferencesHash(ctx)
+	if err != nil {
+		logr.Error(err, "failed to read the referenced secrets and configmaps")
+		return ctrl.Result{}, err
+	}
+	referencesChanged := appGroup.Status.ObservedReferencesHash != "" && appGroup.Status.ObservedReferencesHash != referencesHash
+
 	// If we have not yet seen this generation, we should reconcile and create the workflow
 	// Only do this if we have successfully completed a rollback
-	if appGroup.Generation != appGroup.Status.ObservedGeneration {
-		// Change the app group spec into a progressing state
-		statusHelper.MarkProgressing(appGroup)
-		if err := reconcileHelper.CreateOrUpdate(ctx); err != nil {
-			logr.Error(err, "failed to reconcile creating or updating the appgroup")
-			return ctrl.Result{}, err
+	if appGroup.Generation != appGroup.Status.ObservedGeneration || referencesChanged {
+		// Resume the suspended workflows instead of regenerating them if only the suspend flag was cleared
+		resumed := false
+		if !referencesChanged {
+			if resumed, err = reconcileHelper.Resume(ctx); err != nil {
+				logr.Error(err, "failed to resume the appgroup workflows")
+				return ctrl.Result{}, err
+			}
+		}
+		if resumed {
+			statusHelper.MarkResumed(appGroup)
+		} else {
+			// Change the app group spec into a progressing state
+			if appGroup.Generation == appGroup.Status.ObservedGeneration {
+				statusHelper.MarkReferencesChanged(appGroup)
+			} else {
+				statusHelper.MarkProgressing(appGroup)
+			}
+			if err := reconcileHelper.CreateOrUpdate(ctx); errors.Is(err, meta.ErrChartSourceNotReady) {
+				logr.V(1).Info("waiting for the chart sources to be ready", "reason", err.Error())
+				return ctrl.Result{RequeueAfter: sourceNotReadyRequeueDelay}, nil
+			} else if err != nil {
+				logr.Error(err, "failed to reconcile creating or updating the appgroup")
+				return ctrl.Result{}, err
+			}
 		}
 		appGroup.Status.ObservedGeneration = appGroup.Generation
 	}
-	return ctrl.Result{}, nil
+	appGroup.Status.ObservedReferencesHash = referencesHash
+
+	// Roll out the newly published chart versions that match the version ranges of the applications
+	interval := appGroup.GetAutoUpdateInterval()
+	if interval == 0 {
+		return ctrl.Result{}, nil
+	}
+	if appGroup.GetReadyCondition() == meta.SucceededReason {
+		updates, err := reconcileHelper.ChartUpdates(ctx)
+		if err != nil {
+			// The group keeps running the current charts until the repositories can be reached again
+			logr.Error(err, "failed to check for chart updates")
+			return ctrl.Result{RequeueAfter: interval}, nil
+		}
+		if len(updates) > 0 {
+			statusHelper.MarkChartUpdating(appGroup, updates)
+			if err := reconcileHelper.CreateOrUpdate(ctx); errors.Is(err, meta.ErrChartSourceNotReady) {
+				return ctrl.Result{RequeueAfter: sourceNotReadyRequeueDelay}, nil
+			} else if err != nil {
+				logr.Error(err, "failed to reconcile updating the appgroup charts")
+				return ctrl.Result{}, err
+			}
+		}
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
 }
 
 func (r *ApplicationGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Index the ApplicationGroups by the secrets and configmaps they reference
+	// so that a change to one of them enqueues the ApplicationGroups that use it
+	ctx := context.Background()
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &v1alpha1.ApplicationGroup{}, secretRefIndexKey, func(obj client.Object) []string {
+		return helpers.SecretReferences(obj.(*v1alpha1.ApplicationGroup), workflow.GetNamespace())
+	}); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &v1alpha1.ApplicationGroup{}, configMapRefIndexKey, func(obj client.Object) []string {
+		return helpers.ConfigMapReferences(obj.(*v1alpha1.ApplicationGroup), workflow.GetNamespace())
+	}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.ApplicationGroup{}).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		For(&v1alpha1.ApplicationGroup{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		// Only the metadata of the secrets and configmaps is cached, their data is read from the API server
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.referencingAppGroups(secretRefIndexKey)), builder.OnlyMetadata, builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.referencingAppGroups(configMapRefIndexKey)), builder.OnlyMetadata, builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
+
+// referencingAppGroups returns the requests of the ApplicationGroups that reference the object through the index
+func (r *ApplicationGroupReconciler) referencingAppGroups(indexKey string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		appGroups := &v1alpha1.ApplicationGroupList{}
+		key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}.String()
+		if err := r.List(context.Background(), appGroups, client.MatchingFields{indexKey: key}); err != nil {
+			r.Log.Error(err, "failed to list the ApplicationGroups referencing the object", "index", indexKey, "object", key)
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(appGroups.Items))
+		for _, appGroup := range appGroups.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: appGroup.Name}})
+		}
+		return requests
+	}
+}
@@ -283,8 +283,7 @@ var _ = Describe("ApplicationGroup Controller", func() {
 			if err := k8sClient.Get(ctx, key, appGroup); err != nil {
 				return false
 			}
-			_, exist := appGroup.Annotations[v1alpha1.LastSuccessfulAnnotation]
-			return appGroup.GetReadyCondition() == meta.SucceededReason && exist
+			return appGroup.GetReadyCondition() == meta.SucceededReason && len(appGroup.Status.Revisions) > 0
 		}, defaultTimeout, time.Second).Should(BeTrue())
 
 		By("Upgrading the ambassador chart to a newer version while intentionally timing out the last DAG step")
@@ -326,6 +325,49 @@ var _ = Describe("ApplicationGroup Controller", func() {
 		}, defaultTimeout, time.Second).Should(BeTrue())
 	})
 
+	It("Should leave the failed helm releases in place with the pause remediation strategy", func() {
+		appGroup.Spec.Remediation = &v1alpha1.Remediation{Strategy: v1alpha1.RemediationPause}
+		appGroup.Spec.Applications[1].Spec.Chart.Version = ambassadorOldChartVersion
+
+		By("Applying the bookinfo object to the cluster")
+		err := k8sClient.Create(ctx, appGroup)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("Waiting for the bookinfo object to reach a succeeded reason")
+		Eventually(func() bool {
+			if err := k8sClient.Get(ctx, key, appGroup); err != nil {
+				return false
+			}
+			return appGroup.GetReadyCondition() == meta.SucceededReason
+		}, defaultTimeout, time.Second).Should(BeTrue())
+
+		By("Upgrading the ambassador chart to a newer version while intentionally timing out the last DAG step")
+		patch := client.MergeFrom(appGroup.DeepCopy())
+		appGroup.Spec.Applications[1].Spec.Chart.Version = ambassadorChartVersion
+		appGroup.Spec.Applications[0].Spec.Release.Timeout = &metav1.Duration{Duration: time.Second}
+		err = k8sClient.Patch(ctx, appGroup, patch)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("Waiting for the remediation to be paused")
+		Eventually(func() bool {
+			if err := k8sClient.Get(ctx, key, appGroup); err != nil {
+				return false
+			}
+			remediation := meta.GetResourceCondition(appGroup, meta.RemediationCondition)
+			return appGroup.Generation > 1 && remediation != nil && remediation.Reason == meta.RemediationPausedReason
+		}, defaultTimeout, time.Second).Should(BeTrue())
+
+		By("Ensuring that no rollback workflow was submitted and the newer chart version stays released")
+		rollbackWorkflow := &v1alpha13.Workflow{}
+		err = k8sClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-rollback", name), Namespace: defaultNamespace}, rollbackWorkflow)
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		hr := &fluxhelmv2beta1.HelmRelease{}
+		err = k8sClient.Get(ctx, types.NamespacedName{Name: ambassador, Namespace: name}, hr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hr.Spec.Chart.Spec.Version).To(Equal(ambassadorChartVersion))
+		Expect(appGroup.Status.RemediationAttempts).To(BeZero())
+	})
+
 	It("Should create the bookinfo and then delete it while in progress", func() {
 		By("Applying the bookinfo object to the cluster")
 		err := k8sClient.Create(ctx, appGroup)
@@ -449,8 +491,7 @@ var _ = Describe("ApplicationGroup Controller", func() {
 			if err := k8sClient.Get(ctx, key, applicationGroup); err != nil {
 				return false
 			}
-			_, exist := applicationGroup.Annotations[v1alpha1.LastSuccessfulAnnotation]
-			return applicationGroup.GetReadyCondition() == meta.SucceededReason && exist
+			return applicationGroup.GetReadyCondition() == meta.SucceededReason && len(applicationGroup.Status.Revisions) > 0
 
 		}, defaultTimeout, time.Second).Should(BeTrue())
 
@@ -1,6 +1,7 @@
 package controllers_test
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
@@ -10,9 +11,11 @@ import (
 	v1alpha1 "github.com/Azure/Orkestra/api/v1alpha1"
 	"github.com/Azure/Orkestra/controllers"
 	"github.com/Azure/Orkestra/pkg/registry"
+	"github.com/Azure/Orkestra/pkg/revision"
 	"github.com/Azure/Orkestra/pkg/workflow"
 	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	fluxsourcev1beta1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/onsi/ginkgo/config"
 	"github.com/onsi/gomega/gexec"
 
@@ -81,6 +84,8 @@ var _ = BeforeSuite(func() {
 	Expect(err).ToNot(HaveOccurred())
 	err = fluxhelmv2beta1.AddToScheme(scheme.Scheme)
 	Expect(err).ToNot(HaveOccurred())
+	err = fluxsourcev1beta1.AddToScheme(scheme.Scheme)
+	Expect(err).ToNot(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
 
@@ -94,24 +99,28 @@ var _ = BeforeSuite(func() {
 	rc, err := registry.NewClient(ctrl.Log, registry.TargetDir(tempChartStoreTargetDir))
 	Expect(err).ToNot(HaveOccurred())
 
-	// Register the staging helm repository/registry
-	err = rc.AddRepo(&registry.Config{
+	// Set up the staging store
+	stagingStore := registry.NewChartMuseumStore(&registry.Config{
 		Name: "staging",
 		URL:  portForwardStagingRepoURL,
 	})
-	Expect(err).ToNot(HaveOccurred())
+	Expect(stagingStore.Ready(context.Background())).To(Succeed())
 
 	baseLogger := ctrl.Log.WithName("controllers").WithName("ApplicationGroup")
 
 	workflowClientBuilder := workflow.NewBuilder(k8sManager.GetClient(), baseLogger).WithStagingRepo(inClusterstagingRepoURL).WithParallelism(10).InNamespace("orkestra")
+	revisionHistory := revision.NewHistory(k8sManager.GetClient(), "orkestra")
 
 	err = (&controllers.ApplicationGroupReconciler{
 		Client:                  k8sManager.GetClient(),
+		APIReader:               k8sManager.GetAPIReader(),
 		Log:                     baseLogger,
 		Scheme:                  k8sManager.GetScheme(),
 		RegistryClient:          rc,
+		StagingStore:            stagingStore,
 		StagingRepoName:         "staging",
 		WorkflowClientBuilder:   workflowClientBuilder,
+		RevisionHistory:         revisionHistory,
 		TargetDir:               tempChartStoreTargetDir,
 		Recorder:                k8sManager.GetEventRecorderFor("appgroup-controller"),
 		DisableRemediation:      false,
@@ -124,6 +133,7 @@ var _ = BeforeSuite(func() {
 		Log:                   baseLogger,
 		Scheme:                k8sManager.GetScheme(),
 		WorkflowClientBuilder: workflowClientBuilder,
+		RevisionHistory:       revisionHistory,
 		Recorder:              k8sManager.GetEventRecorderFor("appgroup-controller"),
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
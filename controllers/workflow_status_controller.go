@@ -17,6 +17,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/Azure/Orkestra/pkg/helpers"
+	"github.com/Azure/Orkestra/pkg/metrics"
+	"github.com/Azure/Orkestra/pkg/revision"
 
 	"github.com/Azure/Orkestra/api/v1alpha1"
 	workflowpkg "github.com/Azure/Orkestra/pkg/workflow"
@@ -34,8 +36,16 @@ type WorkflowStatusReconciler struct {
 	Scheme                *runtime.Scheme
 	WorkflowClientBuilder *workflowpkg.Builder
 
+	// RevisionHistory stores the successfully rolled out revisions of the ApplicationGroups
+	RevisionHistory *revision.History
+
 	// Recorder generates kubernetes events
 	Recorder record.EventRecorder
+
+	// DisableRemediation for debugging purposes
+	// The failed Workflow and HelmReleases are left in place
+	// regardless of the remediation strategy of the ApplicationGroup
+	DisableRemediation bool
 }
 
 // +kubebuilder:rbac:groups=argoproj.io,resources=workflows,verbs=get;list;watch;create;update;patch;delete
@@ -96,17 +106,20 @@ func (r *WorkflowStatusReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	patch := client.MergeFrom(parent.DeepCopy())
 	statusHelper := &helpers.StatusHelper{
-		Client:    r.Client,
-		Logger:    logr,
-		PatchFrom: patch,
-		Recorder:  r.Recorder,
+		Client:          r.Client,
+		Logger:          logr,
+		PatchFrom:       patch,
+		Recorder:        r.Recorder,
+		RevisionHistory: r.RevisionHistory,
 	}
 	reconcileHelper := helpers.ReconcileHelper{
 		Client:                r.Client,
 		Logger:                logr,
 		Instance:              parent,
 		WorkflowClientBuilder: r.WorkflowClientBuilder,
+		RevisionHistory:       r.RevisionHistory,
 		StatusHelper:          statusHelper,
+		DisableRemediation:    r.DisableRemediation,
 	}
 
 	workflowAppGeneration, err := getWorkflowAppGeneration(workflow)
@@ -143,31 +156,65 @@ func (r *WorkflowStatusReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		logr.Error(err, "failed to update the chart status of the app group")
 		return ctrl.Result{}, err
 	}
+	previousCondition := parent.GetWorkflowCondition(workflowType)
 	if err := statusHelper.UpdateFromWorkflowStatus(parent, workflow, workflowType); err != nil {
 		logr.Error(err, "failed to update the workflow status of the app group")
 		return ctrl.Result{}, err
 	}
+	if condition := parent.GetWorkflowCondition(workflowType); condition != previousCondition {
+		recordWorkflowMetrics(parent, workflow, workflowType, condition)
+	}
+	// Remediate the failed workflows of the application group unless it is being deleted
+	var remediationErr error
+	if parent.DeletionTimestamp.IsZero() {
+		switch workflowpkg.ToConditionReason(workflow.Status.Phase) {
+		case meta.FailedReason:
+			remediationErr = reconcileHelper.Remediate(ctx, workflowType)
+		case meta.SucceededReason:
+			if workflowType != v1alpha1.Forward {
+				reconcileHelper.RemediationSucceeded()
+			}
+		}
+	}
 	if err := statusHelper.PatchStatus(ctx, parent); err != nil {
 		logr.Error(err, "failed to patch the status of the parent based on the workflow")
 		return ctrl.Result{}, err
 	}
-	if workflowType == v1alpha1.Forward &&
-		workflowpkg.ToConditionReason(workflow.Status.Phase) == meta.FailedReason {
-		if lastSuccessfulSpec := parent.GetLastSuccessful(); lastSuccessfulSpec != nil {
-			if err := reconcileHelper.Rollback(ctx); err != nil {
-				logr.Error(err, "failed to generate the rollback workflow")
-				return ctrl.Result{}, err
-			}
-		} else {
-			if err := reconcileHelper.Reverse(ctx); err != nil {
-				logr.Error(err, "failed to generate the reverse workflow")
-				return ctrl.Result{}, err
-			}
-		}
+	if remediationErr != nil {
+		logr.Error(remediationErr, "failed to remediate the failed workflow")
+		return ctrl.Result{}, remediationErr
 	}
 	return ctrl.Result{}, nil
 }
 
+// recordWorkflowMetrics records the outcome and the durations of the workflow
+// and its applications once the workflow has completed
+func recordWorkflowMetrics(parent *v1alpha1.ApplicationGroup, workflow *v1alpha13.Workflow, workflowType v1alpha1.WorkflowType, condition string) {
+	var outcome string
+	switch condition {
+	case meta.SucceededReason:
+		outcome = metrics.SucceededOutcome
+	case meta.FailedReason:
+		outcome = metrics.FailedOutcome
+	default:
+		return
+	}
+	metrics.RecordWorkflow(workflowType, outcome, workflow.Status.FinishedAt.Sub(workflow.Status.StartedAt.Time))
+	if workflowType != v1alpha1.Forward {
+		return
+	}
+	for name, node := range workflowpkg.ApplicationNodes(parent, workflow) {
+		if !node.Fulfilled() {
+			continue
+		}
+		appOutcome := metrics.SucceededOutcome
+		if node.FailedOrError() {
+			appOutcome = metrics.FailedOutcome
+		}
+		metrics.RecordApplicationDeploy(parent.Name, name, appOutcome, node.FinishedAt.Sub(node.StartedAt.Time))
+	}
+}
+
 func getWorkflowAppGeneration(workflow *v1alpha13.Workflow) (int64, error) {
 	workflowAppGroupGenerationStr, ok := workflow.GetLabels()[v1alpha1.WorkflowAppGroupGenerationLabel]
 	if !ok {
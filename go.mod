@@ -3,8 +3,11 @@ module github.com/Azure/Orkestra
 go 1.16
 
 require (
+	github.com/Masterminds/semver/v3 v3.1.1
 	github.com/argoproj/argo-workflows/v3 v3.1.8
 	github.com/chartmuseum/helm-push v0.9.0
+	github.com/containerd/containerd v1.4.4
+	github.com/deislabs/oras v0.11.1
 	github.com/fluxcd/helm-controller/api v0.11.2
 	github.com/fluxcd/pkg/apis/meta v0.10.0
 	github.com/fluxcd/source-controller/api v0.10.0
@@ -15,8 +18,14 @@ require (
 	github.com/jinzhu/copier v0.3.0
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.16.0
+	github.com/opencontainers/go-digest v1.0.0
+	github.com/opencontainers/image-spec v1.0.1
+	github.com/prometheus/client_golang v1.11.0
+	github.com/spf13/cobra v1.1.3
 	go.opencensus.io v0.22.5 // indirect
+	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
 	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43 // indirect
+	golang.org/x/sync v0.0.0-20201207232520-09787c993a3a
 	gopkg.in/DATA-DOG/go-sqlmock.v1 v1.3.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0
 	helm.sh/helm/v3 v3.6.2
@@ -6,28 +6,41 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/Orkestra/pkg/utils"
+	"github.com/Azure/Orkestra/pkg/webhook"
 	"github.com/Azure/Orkestra/pkg/workflow"
 
+	"github.com/Azure/Orkestra/pkg/helpers"
 	"github.com/Azure/Orkestra/pkg/registry"
+	"github.com/Azure/Orkestra/pkg/revision"
+	"github.com/Azure/Orkestra/pkg/templates"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	orkestrav1alpha1 "github.com/Azure/Orkestra/api/v1alpha1"
 	"github.com/Azure/Orkestra/controllers"
 	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	fluxsourcev1beta1 "github.com/fluxcd/source-controller/api/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
 
 const (
 	stagingRepoURLEnv = "STAGING_REPO_URL"
+	// defaultStagingStorePath is the directory of the local staging store, next to the default chart store path of the helm chart
+	defaultStagingStorePath = "/etc/orkestra/charts/staging"
 )
 
 var (
@@ -46,6 +59,9 @@ func init() {
 
 	// Add HelmRelease scheme to operator
 	_ = fluxhelmv2beta1.AddToScheme(scheme)
+
+	// Add GitRepository and Bucket scheme to operator
+	_ = fluxsourcev1beta1.AddToScheme(scheme)
 }
 
 func main() {
@@ -54,13 +70,22 @@ func main() {
 		enableLeaderElection    bool
 		configPath              string
 		stagingRepoURL          string
+		stagingRepoSecret       string
+		stagingRepoName         string
+		stagingStoreKind        string
+		stagingStoreAddr        string
+		stagingStorePath        string
+		stagingGCInterval       time.Duration
 		tempChartStoreTargetDir string
 		disableRemediation      bool
 		cleanupDownloadedCharts bool
+		maxConcurrentCharts     int
+		maxConcurrentReconciles int
 		debug                   bool
 		workflowParallelism     int64
 		logLevel                int
 		enableZapLogDevMode     bool
+		enableWebhooks          bool
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8081", "The address the metric endpoint binds to.")
@@ -69,12 +94,21 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&configPath, "config", "", "The path to the controller config file")
 	flag.StringVar(&stagingRepoURL, "staging-repo-url", "", "The URL for the helm registry used for staging artifacts (ENV - STAGING_REPO_URL). NOTE: Flag overrides env value")
+	flag.StringVar(&stagingRepoSecret, "staging-repo-secret", "", "The name of the secret in the orkestra namespace with the credentials for the staging registry (username, password, token, authHeader, tls.crt, tls.key, ca.crt)")
+	flag.StringVar(&stagingRepoName, "staging-repo-name", templates.DefaultStagingRepoName, "The name of the HelmRepository in the orkestra namespace that sources the staged charts")
+	flag.StringVar(&stagingStoreKind, "staging-store", registry.ChartMuseumStagingStore, "The backend of the staging repository, one of chartmuseum, oci or local. The local store serves the staged charts from the controller on --staging-store-addr and --staging-repo-url must be the in-cluster URL of that address")
+	flag.StringVar(&stagingStoreAddr, "staging-store-addr", ":8082", "The address the local staging store binds to")
+	flag.StringVar(&stagingStorePath, "staging-store-path", defaultStagingStorePath, "The directory the local staging store keeps the staged charts in. NOTE: it must be on a persistent volume, since the charts are only staged again when the ApplicationGroup changes")
+	flag.DurationVar(&stagingGCInterval, "staging-gc-interval", time.Hour, "The interval at which the staged charts that are no longer referenced by any ApplicationGroup are deleted from the staging store, 0 only collects them when an ApplicationGroup is deleted. NOTE: the staging store must be dedicated to orkestra")
 	flag.StringVar(&tempChartStoreTargetDir, "chart-store-path", "", "The temporary storage path for the downloaded and staged chart artifacts")
 	flag.BoolVar(&disableRemediation, "disable-remediation", false, "Disable the remediation (delete/rollback) of the workflow on failure (useful if you wish to debug failures in the workflow/executor container")
 	flag.BoolVar(&cleanupDownloadedCharts, "cleanup-downloaded-charts", false, "Enable/disable the cleanup of the charts downloaded to the chart-store-path")
+	flag.IntVar(&maxConcurrentCharts, "max-concurrent-charts", helpers.DefaultMaxConcurrentCharts, "The max number of applications of an ApplicationGroup whose charts are pulled and staged concurrently")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1, "The max number of ApplicationGroups that are reconciled concurrently")
 	flag.BoolVar(&debug, "debug", false, "Enable debug run of the appgroup controller")
 	flag.Int64Var(&workflowParallelism, "workflow-parallelism", 10, "Specifies the max number of workflow pods that can be executed in parallel")
 	flag.IntVar(&logLevel, "log-level", 0, "Log Level")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Enable the validating admission webhook for the ApplicationGroup (requires the webhook serving certificates)")
 	flag.Parse()
 
 	if logLevel < 0 {
@@ -83,7 +117,17 @@ func main() {
 	ctrl.SetLogger(zap.New(zap.UseDevMode(enableZapLogDevMode)))
 
 	// Start the probe at the very beginning
-	probe, err := utils.ProbeHandler(stagingRepoURL, "health")
+	// The staging store is not ready until it has been set up
+	var probeStore atomic.Value
+	probe, err := utils.ProbeHandler(func() error {
+		store, ok := probeStore.Load().(registry.StagingStore)
+		if !ok {
+			return fmt.Errorf("staging store is not set up")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		return store.Ready(ctx)
+	})
 	if err != nil {
 		setupLog.Error(err, "unable to start readiness/liveness probes", "controller", "ApplicationGroup")
 		os.Exit(1)
@@ -126,50 +170,78 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Register the staging helm repository/registry
+	// Set up the staging store and wait for it to be ready
 	// We perform retry on this so that we don't go into a crash loop backoff
-	retryChan := make(chan bool)
+	retryChan := make(chan registry.StagingStore)
 	retryCtx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	var stagingAuthSecretRef *corev1.ObjectReference
+	if stagingRepoSecret != "" {
+		stagingAuthSecretRef = &corev1.ObjectReference{Name: stagingRepoSecret, Namespace: workflow.GetNamespace()}
+	}
 	go func() {
 		for {
-			err = rc.AddRepo(&registry.Config{
-				Name: "staging",
-				URL:  stagingHelmURL,
-			})
+			var store registry.StagingStore
+			store, err = newStagingStore(stagingStoreKind, stagingHelmURL, stagingStorePath, stagingAuthSecretRef, mgr.GetAPIReader())
+			if err == nil {
+				err = store.Ready(retryCtx)
+			}
 			if err != nil {
-				setupLog.Error(err, "failed to add staging helm repo, retrying...")
+				setupLog.Error(err, "failed to set up the staging store, retrying...")
 				time.Sleep(time.Second * 5)
 			} else {
-				retryChan <- true
+				retryChan <- store
 				break
 			}
 		}
 	}()
+	var stagingStore registry.StagingStore
 	select {
-	case <-retryChan:
+	case stagingStore = <-retryChan:
 		cancel()
 		close(retryChan)
-		setupLog.Info("successfully set-up the local chartmuseum helm repository")
+		setupLog.Info("successfully set up the staging store", "store", stagingStoreKind, "url", stagingStore.URL())
 	case <-retryCtx.Done():
 		cancel()
 		close(retryChan)
-		setupLog.Error(err, "pod timed out while trying to setup the helm chart museum...")
+		setupLog.Error(err, "pod timed out while trying to set up the staging store...")
 		os.Exit(1)
 	}
+	probeStore.Store(stagingStore)
+	if localStore, ok := stagingStore.(*registry.LocalStore); ok {
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			return localStore.ListenAndServe(ctx, stagingStoreAddr)
+		})); err != nil {
+			setupLog.Error(err, "unable to add the local staging store server")
+			os.Exit(1)
+		}
+	}
 
 	baseLogger := ctrl.Log.WithName("controllers").WithName("ApplicationGroup")
+	revisionHistory := revision.NewHistory(mgr.GetClient(), workflow.GetNamespace())
+
+	stagingGC := registry.NewStagingGC(mgr.GetClient(), stagingStore, revisionHistory, stagingGCInterval, ctrl.Log.WithName("staging-gc"))
+	if err := mgr.Add(stagingGC); err != nil {
+		setupLog.Error(err, "unable to add the staging garbage collector")
+		os.Exit(1)
+	}
 
 	if err = (&controllers.ApplicationGroupReconciler{
 		Client:                  mgr.GetClient(),
+		APIReader:               mgr.GetAPIReader(),
 		Log:                     baseLogger,
 		Scheme:                  mgr.GetScheme(),
 		RegistryClient:          rc,
+		StagingStore:            stagingStore,
 		StagingRepoName:         "staging",
-		WorkflowClientBuilder:   workflow.NewBuilder(mgr.GetClient(), baseLogger).WithStagingRepo(workflowHelmURL).WithParallelism(workflowParallelism).InNamespace(workflow.GetNamespace()),
+		WorkflowClientBuilder:   workflow.NewBuilder(mgr.GetClient(), baseLogger).WithStagingRepo(workflowHelmURL).WithStagingRepoName(stagingRepoName).WithParallelism(workflowParallelism).InNamespace(workflow.GetNamespace()),
+		RevisionHistory:         revisionHistory,
+		StagingGC:               stagingGC,
 		TargetDir:               tempChartStoreTargetDir,
 		Recorder:                mgr.GetEventRecorderFor("appgroup-controller"),
 		DisableRemediation:      disableRemediation,
 		CleanupDownloadedCharts: cleanupDownloadedCharts,
+		MaxConcurrentCharts:     maxConcurrentCharts,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ApplicationGroup")
 		os.Exit(1)
@@ -179,13 +251,23 @@ func main() {
 		Client:                mgr.GetClient(),
 		Log:                   baseLogger,
 		Scheme:                mgr.GetScheme(),
-		WorkflowClientBuilder: workflow.NewBuilder(mgr.GetClient(), baseLogger).WithStagingRepo(workflowHelmURL).WithParallelism(workflowParallelism).InNamespace(workflow.GetNamespace()),
+		WorkflowClientBuilder: workflow.NewBuilder(mgr.GetClient(), baseLogger).WithStagingRepo(workflowHelmURL).WithStagingRepoName(stagingRepoName).WithParallelism(workflowParallelism).InNamespace(workflow.GetNamespace()),
+		RevisionHistory:       revisionHistory,
 		Recorder:              mgr.GetEventRecorderFor("appgroup-controller"),
+		DisableRemediation:    disableRemediation,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "WorkflowStatus")
 		os.Exit(1)
 	}
 
+	if enableWebhooks {
+		mgr.GetWebhookServer().Register(webhook.ValidateApplicationGroupPath, &ctrlwebhook.Admission{
+			Handler: &webhook.ApplicationGroupValidator{
+				Log: ctrl.Log.WithName("webhooks").WithName("ApplicationGroup"),
+			},
+		})
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -195,6 +277,15 @@ func main() {
 	}
 }
 
+// newStagingStore returns the staging store of the given kind with the credentials of the auth secret
+func newStagingStore(kind, url, dir string, authSecretRef *corev1.ObjectReference, c client.Reader) (registry.StagingStore, error) {
+	cfg, err := registry.GetStagingRepoConfig("staging", url, authSecretRef, c)
+	if err != nil {
+		return nil, err
+	}
+	return registry.NewStagingStore(kind, cfg, dir)
+}
+
 // getValues returns the stagingRepoUrl unless the appGroup controller
 // is run in a debug mode, then it returns the port forwarded url
 func getValues(stagingHelmURL, tempChartStoreTargetDir string, debug bool) (string, string, string) {
@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const ApprovalExecutorName = "approval-executor"
+
+// ApprovalForward is a manual approval gate. The workflow node is suspended until
+// the gate is approved through the approve annotation of the ApplicationGroup
+type ApprovalForward struct{}
+
+func (exec ApprovalForward) Reverse() Executor {
+	return ApprovalReverse{}
+}
+
+func (exec ApprovalForward) GetName() string {
+	return ApprovalExecutorName
+}
+
+func (exec ApprovalForward) GetTemplate() v1alpha13.Template {
+	return approvalBaseTemplate(exec.GetName())
+}
+
+func (exec ApprovalForward) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return approvalBaseTask(exec.GetName(), name, dependencies), nil
+}
+
+// ApprovalReverse skips the approval gate, since nothing is rolled out
+// that needs to be approved when the applications are uninstalled
+type ApprovalReverse struct{}
+
+func (exec ApprovalReverse) Reverse() Executor {
+	return ApprovalForward{}
+}
+
+func (exec ApprovalReverse) GetName() string {
+	return ApprovalExecutorName
+}
+
+func (exec ApprovalReverse) GetTemplate() v1alpha13.Template {
+	return approvalBaseTemplate(exec.GetName())
+}
+
+func (exec ApprovalReverse) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return skippedTask(approvalBaseTask(exec.GetName(), name, dependencies), nil)
+}
+
+func approvalBaseTemplate(executorName string) v1alpha13.Template {
+	return v1alpha13.Template{
+		Name:    executorName,
+		Suspend: &v1alpha13.SuspendTemplate{},
+	}
+}
+
+func approvalBaseTask(executorName, name string, dependencies []string) v1alpha13.DAGTask {
+	return v1alpha13.DAGTask{
+		Name:         utils.ConvertToDNS1123(name),
+		Template:     executorName,
+		Dependencies: dependencies,
+	}
+}
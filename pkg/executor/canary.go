@@ -0,0 +1,495 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// CanaryProviderFlagger watches the Flagger Canary of the application
+	CanaryProviderFlagger = "flagger"
+	// CanaryProviderArgoRollouts watches the Argo Rollouts Rollout of the application
+	CanaryProviderArgoRollouts = "argo-rollouts"
+
+	// DefaultCanaryTimeout is the time the progressive rollout has to be promoted or aborted in
+	DefaultCanaryTimeout = time.Hour
+	// DefaultCanaryTargetKind is the kind of the workload targeted by the Flagger Canary
+	DefaultCanaryTargetKind = "Deployment"
+)
+
+const (
+	canaryProvider   = "provider"
+	canaryName       = "name"
+	canaryNamespace  = "namespace"
+	canaryTimeout    = "canaryTimeout"
+	canaryTargetKind = "targetKind"
+	canaryTargetName = "targetName"
+	canaryRevision   = "revision"
+)
+
+// canaryProviders holds the resources watched for each provider, the revision of the resource
+// that tells whether the provider has picked up the new release, and the conditions
+// for the promotion and the abortion of the progressive rollout
+var canaryProviders = map[string]struct {
+	apiVersion, kind                   string
+	revisionPath, observedCondition    string
+	successCondition, failureCondition string
+}{
+	CanaryProviderFlagger: {
+		apiVersion: "flagger.app/v1beta1",
+		kind:       "Canary",
+		// Flagger records the hash of the target spec when it starts the analysis of a new revision
+		revisionPath:      "{.status.lastAppliedSpec}",
+		observedCondition: "status.lastAppliedSpec != %s",
+		// The first revision is initialized by Flagger without an analysis
+		successCondition: "status.phase in (Initialized,Succeeded)",
+		failureCondition: "status.phase == Failed",
+	},
+	CanaryProviderArgoRollouts: {
+		apiVersion:        "argoproj.io/v1alpha1",
+		kind:              "Rollout",
+		revisionPath:      "{.metadata.generation}",
+		observedCondition: "status.observedGeneration == %s",
+		successCondition:  "status.phase == Healthy",
+		failureCondition:  "status.phase == Degraded",
+	},
+}
+
+// canaryTargetKinds are the kinds of the workloads targeted by the Flagger Canary.
+// Their revision annotations only change with the pod template, like the spec hash of Flagger.
+var canaryTargetKinds = []string{"Deployment", "DaemonSet"}
+
+// CanaryProviders returns the supported progressive rollout providers
+func CanaryProviders() []string {
+	return []string{CanaryProviderFlagger, CanaryProviderArgoRollouts}
+}
+
+// CanaryForward deploys the HelmRelease and then watches the Flagger Canary or the Argo Rollouts Rollout
+// of the application until the progressive rollout is promoted or aborted. An aborted rollout fails the
+// workflow node so that the ApplicationGroup is remediated.
+type CanaryForward struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
+
+func (exec CanaryForward) Reverse() Executor {
+	return CanaryReverse{exec.RetryStrategy}
+}
+
+func (exec CanaryForward) GetName() string {
+	return templateName("canary-forward-executor", exec.RetryStrategy)
+}
+
+// GetExecutors returns the executors whose templates are run by the canary steps
+func (exec CanaryForward) GetExecutors() []Executor {
+	executors := []Executor{HelmReleaseForward{exec.RetryStrategy}, canaryTargetRevision{}}
+	for _, provider := range CanaryProviders() {
+		executors = append(executors,
+			canaryRollout{Provider: provider, RetryStrategy: exec.RetryStrategy},
+			canaryResourceRevision{provider},
+			canaryObserved{provider},
+			canaryStatus{provider},
+		)
+	}
+	return executors
+}
+
+// GetTemplate runs the steps of the provider of the task
+func (exec CanaryForward) GetTemplate() v1alpha13.Template {
+	var steps []v1alpha13.WorkflowStep
+	for _, provider := range CanaryProviders() {
+		rollout := canaryRollout{Provider: provider, RetryStrategy: exec.RetryStrategy}
+		steps = append(steps, v1alpha13.WorkflowStep{
+			Name:      provider,
+			Template:  rollout.GetName(),
+			Arguments: v1alpha13.Arguments{Parameters: canaryInputArguments(rollout.GetTemplate().Inputs)},
+			When:      fmt.Sprintf("'{{inputs.parameters.%s}}' == '%s'", canaryProvider, provider),
+		})
+	}
+	return v1alpha13.Template{
+		Name: exec.GetName(),
+		Inputs: v1alpha13.Inputs{
+			Parameters: []v1alpha13.Parameter{
+				{
+					Name: HelmReleaseArg,
+				},
+				{
+					Name:    TimeoutArg,
+					Default: utils.ToAnyStringPtr(DefaultTimeout),
+				},
+				{
+					Name: canaryProvider,
+				},
+				{
+					Name: canaryName,
+				},
+				{
+					Name: canaryNamespace,
+				},
+				{
+					Name:    canaryTimeout,
+					Default: utils.ToAnyStringPtr(strconv.Itoa(int(DefaultCanaryTimeout.Seconds()))),
+				},
+				{
+					Name:    canaryTargetKind,
+					Default: utils.ToAnyStringPtr(DefaultCanaryTargetKind),
+				},
+				{
+					Name: canaryTargetName,
+				},
+			},
+		},
+		Steps: []v1alpha13.ParallelSteps{{Steps: steps}},
+	}
+}
+
+func (exec CanaryForward) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	params, err := canaryParameters(taskParams)
+	if err != nil {
+		return v1alpha13.DAGTask{}, err
+	}
+	// The canary is looked up in the target namespace of the release by default
+	namespace := params.Ref.Namespace
+	if namespace == "" {
+		hr, err := utils.B64ToHr(hrStr)
+		if err != nil {
+			return v1alpha13.DAGTask{}, fmt.Errorf("failed to decode the helmrelease of the canary executor task: %w", err)
+		}
+		namespace = hr.Spec.TargetNamespace
+	}
+	// The Flagger Canary targets the workload of the same name by default
+	targetName := params.Ref.Name
+	if params.TargetRef != nil && params.TargetRef.Name != "" {
+		targetName = params.TargetRef.Name
+	}
+	task := helmReleaseBaseTask(exec.GetName(), name, dependencies, timeout, hrStr)
+	task.Arguments.Parameters = append(task.Arguments.Parameters,
+		v1alpha13.Parameter{Name: canaryProvider, Value: utils.ToAnyStringPtr(params.Provider)},
+		v1alpha13.Parameter{Name: canaryName, Value: utils.ToAnyStringPtr(params.Ref.Name)},
+		v1alpha13.Parameter{Name: canaryNamespace, Value: utils.ToAnyStringPtr(namespace)},
+		v1alpha13.Parameter{Name: canaryTargetName, Value: utils.ToAnyStringPtr(targetName)},
+	)
+	if params.TargetRef != nil && params.TargetRef.Kind != "" {
+		task.Arguments.Parameters = append(task.Arguments.Parameters, v1alpha13.Parameter{Name: canaryTargetKind, Value: utils.ToAnyStringPtr(params.TargetRef.Kind)})
+	}
+	if params.Timeout != nil {
+		task.Arguments.Parameters = append(task.Arguments.Parameters, v1alpha13.Parameter{Name: canaryTimeout, Value: utils.ToAnyStringPtr(strconv.Itoa(int(params.Timeout.Seconds())))})
+	}
+	return task, nil
+}
+
+// CanaryReverse uninstalls the HelmRelease, the progressive rollout isn't watched while uninstalling
+type CanaryReverse struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
+
+func (exec CanaryReverse) Reverse() Executor {
+	return CanaryForward{exec.RetryStrategy}
+}
+
+func (exec CanaryReverse) GetName() string {
+	return templateName("canary-reverse-executor", exec.RetryStrategy)
+}
+
+func (exec CanaryReverse) GetTemplate() v1alpha13.Template {
+	return helmReleaseBaseTemplate(exec.GetName(), Delete, exec.RetryStrategy)
+}
+
+func (exec CanaryReverse) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return helmReleaseBaseTask(exec.GetName(), name, dependencies, timeout, hrStr), nil
+}
+
+// canaryRollout deploys the HelmRelease and watches the progressive rollout of the provider.
+// The status of the provider resource is only trusted once the provider has observed the new
+// revision, otherwise the promotion or the abortion of the previous rollout would be reported.
+type canaryRollout struct {
+	Provider      string
+	RetryStrategy *v1alpha1.RetryStrategy
+}
+
+func (exec canaryRollout) Reverse() Executor {
+	return exec
+}
+
+func (exec canaryRollout) GetName() string {
+	return templateName(fmt.Sprintf("canary-%s", exec.Provider), exec.RetryStrategy)
+}
+
+func (exec canaryRollout) GetTemplate() v1alpha13.Template {
+	inputs := v1alpha13.Inputs{
+		Parameters: []v1alpha13.Parameter{{Name: HelmReleaseArg}, {Name: TimeoutArg}, {Name: canaryName}, {Name: canaryNamespace}, {Name: canaryTimeout}},
+	}
+	deploy := v1alpha13.WorkflowStep{
+		Name:      "deploy",
+		Template:  HelmReleaseForward{exec.RetryStrategy}.GetName(),
+		Arguments: v1alpha13.Arguments{Parameters: canaryArguments(HelmReleaseArg, TimeoutArg)},
+	}
+	revision := func(name string, executor Executor) v1alpha13.WorkflowStep {
+		return v1alpha13.WorkflowStep{
+			Name:      name,
+			Template:  executor.GetName(),
+			Arguments: v1alpha13.Arguments{Parameters: canaryInputArguments(executor.GetTemplate().Inputs)},
+		}
+	}
+	observed := v1alpha13.WorkflowStep{
+		Name:     "observed",
+		Template: canaryObserved{exec.Provider}.GetName(),
+		Arguments: v1alpha13.Arguments{Parameters: append(canaryArguments(canaryName, canaryNamespace, canaryTimeout),
+			v1alpha13.Parameter{Name: canaryRevision, Value: utils.ToAnyStringPtr("{{steps.revision.outputs.parameters.revision}}")},
+		)},
+	}
+	status := v1alpha13.WorkflowStep{
+		Name:      "status",
+		Template:  canaryStatus{exec.Provider}.GetName(),
+		Arguments: v1alpha13.Arguments{Parameters: canaryArguments(canaryName, canaryNamespace, canaryTimeout)},
+	}
+
+	var steps [][]v1alpha13.WorkflowStep
+	switch exec.Provider {
+	case CanaryProviderFlagger:
+		// Flagger only starts a new analysis when the pod template of its target changes,
+		// so the new spec hash is only waited for if the release changed the target revision
+		inputs.Parameters = append(inputs.Parameters, v1alpha13.Parameter{Name: canaryTargetKind}, v1alpha13.Parameter{Name: canaryTargetName})
+		observed.When = "'{{steps.target-revision.outputs.parameters.revision}}' != '{{steps.deployed-target-revision.outputs.parameters.revision}}'"
+		steps = [][]v1alpha13.WorkflowStep{
+			{revision("revision", canaryResourceRevision{exec.Provider}), revision("target-revision", canaryTargetRevision{})},
+			{deploy},
+			{revision("deployed-target-revision", canaryTargetRevision{})},
+			{observed},
+			{status},
+		}
+	default:
+		// The generation of the resource is observed by the provider once it picked up the new spec
+		steps = [][]v1alpha13.WorkflowStep{
+			{deploy},
+			{revision("revision", canaryResourceRevision{exec.Provider})},
+			{observed},
+			{status},
+		}
+	}
+
+	template := v1alpha13.Template{
+		Name:   exec.GetName(),
+		Inputs: inputs,
+	}
+	for _, parallelSteps := range steps {
+		template.Steps = append(template.Steps, v1alpha13.ParallelSteps{Steps: parallelSteps})
+	}
+	return template
+}
+
+func (exec canaryRollout) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return v1alpha13.DAGTask{}, fmt.Errorf("the canary rollout is only run by the canary executor")
+}
+
+// canaryResourceRevision reads the revision of the provider resource, which is empty if the resource doesn't exist yet
+type canaryResourceRevision struct {
+	Provider string
+}
+
+func (exec canaryResourceRevision) Reverse() Executor {
+	return exec
+}
+
+func (exec canaryResourceRevision) GetName() string {
+	return fmt.Sprintf("canary-%s-revision", exec.Provider)
+}
+
+func (exec canaryResourceRevision) GetTemplate() v1alpha13.Template {
+	provider := canaryProviders[exec.Provider]
+	return canaryRevisionTemplate(exec.GetName(), canaryManifest(provider.apiVersion, provider.kind, canaryName), provider.revisionPath,
+		[]v1alpha13.Parameter{{Name: canaryName}, {Name: canaryNamespace}})
+}
+
+func (exec canaryResourceRevision) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return v1alpha13.DAGTask{}, fmt.Errorf("the canary revision is only read by the canary executor")
+}
+
+// canaryTargetRevision reads the revision of the pod template of the workload targeted by the Flagger Canary,
+// which is empty if the workload doesn't exist yet. Only the annotation of the target kind is set.
+type canaryTargetRevision struct{}
+
+func (exec canaryTargetRevision) Reverse() Executor {
+	return exec
+}
+
+func (exec canaryTargetRevision) GetName() string {
+	return "canary-target-revision"
+}
+
+func (exec canaryTargetRevision) GetTemplate() v1alpha13.Template {
+	return canaryRevisionTemplate(exec.GetName(),
+		canaryManifest("apps/v1", fmt.Sprintf("\"{{inputs.parameters.%s}}\"", canaryTargetKind), canaryTargetName),
+		`{.metadata.annotations.deployment\.kubernetes\.io/revision}{.metadata.annotations.deprecated\.daemonset\.template\.generation}`,
+		[]v1alpha13.Parameter{{Name: canaryTargetKind}, {Name: canaryTargetName}, {Name: canaryNamespace}})
+}
+
+func (exec canaryTargetRevision) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return v1alpha13.DAGTask{}, fmt.Errorf("the canary target revision is only read by the canary executor")
+}
+
+// canaryObserved waits for the provider to pick up the new revision of the application
+type canaryObserved struct {
+	Provider string
+}
+
+func (exec canaryObserved) Reverse() Executor {
+	return exec
+}
+
+func (exec canaryObserved) GetName() string {
+	return fmt.Sprintf("canary-%s-observed", exec.Provider)
+}
+
+func (exec canaryObserved) GetTemplate() v1alpha13.Template {
+	provider := canaryProviders[exec.Provider]
+	template := canaryWaitTemplate(exec.GetName(), exec.Provider, fmt.Sprintf(provider.observedCondition, fmt.Sprintf("{{inputs.parameters.%s}}", canaryRevision)), "")
+	template.Inputs.Parameters = append(template.Inputs.Parameters, v1alpha13.Parameter{Name: canaryRevision})
+	return template
+}
+
+func (exec canaryObserved) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return v1alpha13.DAGTask{}, fmt.Errorf("the canary revision is only observed by the canary executor")
+}
+
+// canaryStatus waits for the progressive rollout of the provider to be promoted or aborted
+type canaryStatus struct {
+	Provider string
+}
+
+func (exec canaryStatus) Reverse() Executor {
+	return exec
+}
+
+func (exec canaryStatus) GetName() string {
+	return fmt.Sprintf("canary-%s-status", exec.Provider)
+}
+
+func (exec canaryStatus) GetTemplate() v1alpha13.Template {
+	provider := canaryProviders[exec.Provider]
+	return canaryWaitTemplate(exec.GetName(), exec.Provider, provider.successCondition, provider.failureCondition)
+}
+
+func (exec canaryStatus) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return v1alpha13.DAGTask{}, fmt.Errorf("the canary status is only watched by the canary executor")
+}
+
+// canaryManifest returns the manifest of the resource named by the input parameter in the canary namespace
+func canaryManifest(apiVersion, kind, nameParameter string) string {
+	return fmt.Sprintf("apiVersion: %s\nkind: %s\nmetadata:\n  name: \"{{inputs.parameters.%s}}\"\n  namespace: \"{{inputs.parameters.%s}}\"\n",
+		apiVersion, kind, nameParameter, canaryNamespace)
+}
+
+// canaryRevisionTemplate gets the resource of the manifest and outputs the revision read from the JSONPath
+func canaryRevisionTemplate(name, manifest, revisionPath string, inputs []v1alpha13.Parameter) v1alpha13.Template {
+	return v1alpha13.Template{
+		Name:               name,
+		ServiceAccountName: workflowServiceAccountName(),
+		Inputs:             v1alpha13.Inputs{Parameters: inputs},
+		Outputs: v1alpha13.Outputs{
+			Parameters: []v1alpha13.Parameter{{
+				Name:      canaryRevision,
+				ValueFrom: &v1alpha13.ValueFrom{JSONPath: revisionPath, Default: utils.ToAnyStringPtr("")},
+			}},
+		},
+		Resource: &v1alpha13.ResourceTemplate{
+			Action:   "get",
+			Manifest: manifest,
+			Flags:    []string{"--ignore-not-found"},
+		},
+	}
+}
+
+// canaryWaitTemplate gets the provider resource until it meets the success or the failure condition
+func canaryWaitTemplate(name, providerName, successCondition, failureCondition string) v1alpha13.Template {
+	provider := canaryProviders[providerName]
+	activeDeadlineSeconds := intstr.FromString(fmt.Sprintf("{{inputs.parameters.%s}}", canaryTimeout))
+	return v1alpha13.Template{
+		Name:               name,
+		ServiceAccountName: workflowServiceAccountName(),
+		Inputs: v1alpha13.Inputs{
+			Parameters: []v1alpha13.Parameter{{Name: canaryName}, {Name: canaryNamespace}, {Name: canaryTimeout}},
+		},
+		ActiveDeadlineSeconds: &activeDeadlineSeconds,
+		Resource: &v1alpha13.ResourceTemplate{
+			Action:           "get",
+			Manifest:         canaryManifest(provider.apiVersion, provider.kind, canaryName),
+			SuccessCondition: successCondition,
+			FailureCondition: failureCondition,
+		},
+	}
+}
+
+// canaryArguments passes the input parameters of the canary template on to a step
+func canaryArguments(names ...string) []v1alpha13.Parameter {
+	var parameters []v1alpha13.Parameter
+	for _, name := range names {
+		parameters = append(parameters, v1alpha13.Parameter{Name: name, Value: utils.ToAnyStringPtr(fmt.Sprintf("{{inputs.parameters.%s}}", name))})
+	}
+	return parameters
+}
+
+// canaryInputArguments passes the input parameters of the canary template that are inputs of the step template
+func canaryInputArguments(inputs v1alpha13.Inputs) []v1alpha13.Parameter {
+	var names []string
+	for _, p := range inputs.Parameters {
+		names = append(names, p.Name)
+	}
+	return canaryArguments(names...)
+}
+
+func canaryParameters(taskParams *apiextensionsv1.JSON) (*CanaryParameters, error) {
+	params := &CanaryParameters{}
+	if taskParams == nil {
+		return nil, fmt.Errorf("task parameters are required for the canary executor task")
+	}
+	if err := json.Unmarshal(taskParams.Raw, params); err != nil {
+		return nil, err
+	}
+	if _, ok := canaryProviders[params.Provider]; !ok {
+		return nil, fmt.Errorf("provider must be one of %v for the canary executor, got %q", CanaryProviders(), params.Provider)
+	}
+	if params.Ref.Name == "" {
+		return nil, fmt.Errorf("ref.name is required for the canary executor")
+	}
+	if params.TargetRef == nil || params.TargetRef.Kind == "" {
+		return params, nil
+	}
+	for _, kind := range canaryTargetKinds {
+		if params.TargetRef.Kind == kind {
+			return params, nil
+		}
+	}
+	return nil, fmt.Errorf("targetRef.kind must be one of %v for the canary executor, got %q", canaryTargetKinds, params.TargetRef.Kind)
+}
+
+// ValidateCanaryParameters checks the parameters of the canary executor
+func ValidateCanaryParameters(taskParams *apiextensionsv1.JSON) error {
+	_, err := canaryParameters(taskParams)
+	return err
+}
+
+type CanaryParameters struct {
+	// Provider of the progressive rollout, either flagger or argo-rollouts
+	Provider string `json:"provider"`
+
+	// Ref is the Flagger Canary or the Argo Rollouts Rollout of the application.
+	// The namespace defaults to the target namespace of the release.
+	Ref corev1.ObjectReference `json:"ref"`
+
+	// TargetRef is the Deployment or the DaemonSet targeted by the Flagger Canary in the namespace of the canary.
+	// The kind defaults to Deployment and the name to the name of the canary.
+	TargetRef *corev1.ObjectReference `json:"targetRef,omitempty"`
+
+	// Timeout is the time the progressive rollout has to be promoted or aborted in. Defaults to 1h.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
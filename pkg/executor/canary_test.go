@@ -0,0 +1,251 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestCanaryForward_GetTask(t *testing.T) {
+	hr := &fluxhelmv2beta1.HelmRelease{Spec: fluxhelmv2beta1.HelmReleaseSpec{TargetNamespace: "bookinfo"}}
+	hrStr := utils.HrToB64(hr)
+
+	tests := []struct {
+		name    string
+		params  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "Flagger Canary In The Release Namespace",
+			params: `{"provider":"flagger","ref":{"name":"productpage"}}`,
+			want: map[string]string{
+				HelmReleaseArg:   hrStr,
+				TimeoutArg:       "5m",
+				canaryProvider:   CanaryProviderFlagger,
+				canaryName:       "productpage",
+				canaryNamespace:  "bookinfo",
+				canaryTargetName: "productpage",
+			},
+		},
+		{
+			name:   "Flagger Canary Of A DaemonSet",
+			params: `{"provider":"flagger","ref":{"name":"productpage"},"targetRef":{"kind":"DaemonSet","name":"productpage-v1"}}`,
+			want: map[string]string{
+				HelmReleaseArg:   hrStr,
+				TimeoutArg:       "5m",
+				canaryProvider:   CanaryProviderFlagger,
+				canaryName:       "productpage",
+				canaryNamespace:  "bookinfo",
+				canaryTargetName: "productpage-v1",
+				canaryTargetKind: "DaemonSet",
+			},
+		},
+		{
+			name:   "Argo Rollout With Timeout",
+			params: `{"provider":"argo-rollouts","ref":{"name":"productpage","namespace":"rollouts"},"timeout":"2h"}`,
+			want: map[string]string{
+				HelmReleaseArg:   hrStr,
+				TimeoutArg:       "5m",
+				canaryProvider:   CanaryProviderArgoRollouts,
+				canaryName:       "productpage",
+				canaryNamespace:  "rollouts",
+				canaryTargetName: "productpage",
+				canaryTimeout:    "7200",
+			},
+		},
+		{
+			name:    "Unknown Provider",
+			params:  `{"provider":"istio","ref":{"name":"productpage"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Target Kind",
+			params:  `{"provider":"flagger","ref":{"name":"productpage"},"targetRef":{"kind":"StatefulSet"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Missing Ref",
+			params:  `{"provider":"flagger"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := CanaryForward{}.GetTask("productpage", []string{"approval"}, "5m", hrStr, &apiextensionsv1.JSON{Raw: []byte(tt.params)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := make(map[string]string)
+			for _, p := range task.Arguments.Parameters {
+				got[p.Name] = p.Value.String()
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("GetTask() parameters diff = %v", cmp.Diff(got, tt.want))
+			}
+			if task.Template != (CanaryForward{}).GetName() || !cmp.Equal(task.Dependencies, []string{"approval"}) {
+				t.Errorf("GetTask() = %+v, want the canary template depending on the approval", task)
+			}
+		})
+	}
+
+	if _, err := (CanaryForward{}).GetTask("productpage", nil, "5m", hrStr, nil); err == nil {
+		t.Errorf("GetTask() without parameters must fail")
+	}
+}
+
+func TestCanaryForward_GetTemplate(t *testing.T) {
+	exec := CanaryForward{}
+	templates := map[string]v1alpha13.Template{exec.GetName(): exec.GetTemplate()}
+	for _, e := range exec.GetExecutors() {
+		templates[e.GetName()] = e.GetTemplate()
+	}
+	// Every step runs one of the templates added to the workflow along with the canary template
+	// and passes the inputs of the step template, which are inputs of the calling template
+	for _, template := range templates {
+		inputs := make(map[string]bool)
+		for _, p := range template.Inputs.Parameters {
+			inputs[p.Name] = true
+		}
+		for _, parallelSteps := range template.Steps {
+			for _, step := range parallelSteps.Steps {
+				stepTemplate, ok := templates[step.Template]
+				if !ok {
+					t.Errorf("template %s step %s runs template %s, which is not one of the executor templates", template.Name, step.Name, step.Template)
+					continue
+				}
+				var want, got []string
+				for _, p := range stepTemplate.Inputs.Parameters {
+					want = append(want, p.Name)
+				}
+				for _, p := range step.Arguments.Parameters {
+					got = append(got, p.Name)
+					if value := p.Value.String(); strings.HasPrefix(value, "{{inputs.parameters.") && !inputs[strings.TrimSuffix(strings.TrimPrefix(value, "{{inputs.parameters."), "}}")] {
+						t.Errorf("template %s step %s passes %s, which is not an input of the template", template.Name, step.Name, value)
+					}
+				}
+				if !cmp.Equal(got, want) {
+					t.Errorf("template %s step %s arguments = %v, want the inputs of template %s %v", template.Name, step.Name, got, step.Template, want)
+				}
+			}
+		}
+	}
+	for _, provider := range CanaryProviders() {
+		resource := canaryStatus{provider}.GetTemplate().Resource
+		if resource == nil || resource.Action != "get" || resource.SuccessCondition == "" || resource.FailureCondition == "" {
+			t.Errorf("canary %s status template resource = %+v, want a get action with success and failure conditions", provider, resource)
+		}
+	}
+}
+
+func TestCanaryObserved_GetTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		revision string
+		status   labels.Set
+		want     bool
+	}{
+		{
+			name:     "Flagger Analysis Of The Previous Revision",
+			provider: CanaryProviderFlagger,
+			revision: "5d6f7b8c9",
+			status:   labels.Set{"status.phase": "Succeeded", "status.lastAppliedSpec": "5d6f7b8c9"},
+			want:     false,
+		},
+		{
+			name:     "Flagger Analysis Of The New Revision",
+			provider: CanaryProviderFlagger,
+			revision: "5d6f7b8c9",
+			status:   labels.Set{"status.phase": "Progressing", "status.lastAppliedSpec": "7c8d9e6f5"},
+			want:     true,
+		},
+		{
+			name:     "Flagger Canary Created By The Release",
+			provider: CanaryProviderFlagger,
+			status:   labels.Set{"status.phase": "Initializing", "status.lastAppliedSpec": "7c8d9e6f5"},
+			want:     true,
+		},
+		{
+			name:     "Argo Rollout Of The Previous Generation",
+			provider: CanaryProviderArgoRollouts,
+			revision: "4",
+			status:   labels.Set{"status.phase": "Healthy", "status.observedGeneration": "3"},
+			want:     false,
+		},
+		{
+			name:     "Argo Rollout Of The New Generation",
+			provider: CanaryProviderArgoRollouts,
+			revision: "4",
+			status:   labels.Set{"status.phase": "Progressing", "status.observedGeneration": "4"},
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := canaryObserved{tt.provider}.GetTemplate().Resource
+			// The condition is parsed by the Argo executor once the revision is substituted
+			condition := strings.ReplaceAll(resource.SuccessCondition, fmt.Sprintf("{{inputs.parameters.%s}}", canaryRevision), tt.revision)
+			selector, err := labels.Parse(condition)
+			if err != nil {
+				t.Fatalf("invalid success condition %q: %v", condition, err)
+			}
+			if got := selector.Matches(tt.status); got != tt.want {
+				t.Errorf("success condition %q matches %v = %v, want %v", condition, tt.status, got, tt.want)
+			}
+			if resource.FailureCondition != "" {
+				t.Errorf("failure condition = %q, the status of the previous rollout must not fail the node", resource.FailureCondition)
+			}
+		})
+	}
+}
+
+func TestCanaryForward_GetTemplate_When(t *testing.T) {
+	// Only the status step of the provider of the task is run, the provider is quoted
+	// since Argo compares the substituted parameters of the when expression as strings
+	got := map[string]string{}
+	for _, parallelSteps := range (CanaryForward{}).GetTemplate().Steps {
+		for _, step := range parallelSteps.Steps {
+			if step.When != "" {
+				got[step.Name] = step.When
+			}
+		}
+	}
+	want := map[string]string{}
+	for _, provider := range CanaryProviders() {
+		want[provider] = fmt.Sprintf("'{{inputs.parameters.provider}}' == '%s'", provider)
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("GetTemplate() step when diff = %v", cmp.Diff(got, want))
+	}
+}
+
+func TestCanaryRollout_GetTemplate_When(t *testing.T) {
+	var observed *v1alpha13.WorkflowStep
+	for _, parallelSteps := range (canaryRollout{Provider: CanaryProviderFlagger}).GetTemplate().Steps {
+		for i := range parallelSteps.Steps {
+			if parallelSteps.Steps[i].Name == "observed" {
+				observed = &parallelSteps.Steps[i]
+			}
+		}
+	}
+	if observed == nil {
+		t.Fatalf("GetTemplate() has no observed step")
+	}
+	// The new spec hash is only waited for if the release changed the pod template of the target,
+	// the revisions are quoted so that the step is also run when the target had no revision before
+	want := "'{{steps.target-revision.outputs.parameters.revision}}' != '{{steps.deployed-target-revision.outputs.parameters.revision}}'"
+	if observed.When != want {
+		t.Errorf("GetTemplate() observed step when = %v, want %v", observed.When, want)
+	}
+}
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// containerBaseTemplate returns the template of the executors that run their own image against the
+// helmrelease of the application. The executor parameters are passed to the image as --<name>=<value> flags.
+func containerBaseTemplate(executorName, image string, retryStrategy *v1alpha1.RetryStrategy, parameters ...v1alpha13.Parameter) v1alpha13.Template {
+	executorArgs := []string{"--spec", "{{inputs.parameters.helmrelease}}", "--timeout", "{{inputs.parameters.timeout}}"}
+	for _, p := range parameters {
+		executorArgs = append(executorArgs, fmt.Sprintf("--%s={{inputs.parameters.%s}}", p.Name, p.Name))
+	}
+	return v1alpha13.Template{
+		Name:               executorName,
+		ServiceAccountName: workflowServiceAccountName(),
+		Inputs: v1alpha13.Inputs{
+			Parameters: append([]v1alpha13.Parameter{
+				{
+					Name: HelmReleaseArg,
+				},
+				{
+					Name:    TimeoutArg,
+					Default: utils.ToAnyStringPtr(DefaultTimeout),
+				},
+			}, parameters...),
+		},
+		Executor: &v1alpha13.ExecutorConfig{
+			ServiceAccountName: workflowServiceAccountName(),
+		},
+		RetryStrategy: toArgoRetryStrategy(retryStrategy),
+		Container: &corev1.Container{
+			Name:  executorName,
+			Image: image,
+			Args:  executorArgs,
+		},
+	}
+}
+
+// containerBaseTask returns the task of the executors that run their own image with the values of the executor parameters
+func containerBaseTask(executorName, name string, dependencies []string, timeout, hrStr string, parameters ...v1alpha13.Parameter) v1alpha13.DAGTask {
+	task := helmReleaseBaseTask(executorName, name, dependencies, timeout, hrStr)
+	task.Arguments.Parameters = append(task.Arguments.Parameters, parameters...)
+	return task
+}
+
+// skippedTask skips the task in the reverse workflow of the executors that have
+// nothing to do once the applications are uninstalled. The skipped task still
+// satisfies the dependencies of the other executors.
+func skippedTask(task v1alpha13.DAGTask, err error) (v1alpha13.DAGTask, error) {
+	task.When = "false"
+	return task, err
+}
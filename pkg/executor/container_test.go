@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestContainerExecutors_GetTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		exec    Executor
+		params  *apiextensionsv1.JSON
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "HealthCheck Without Parameters",
+			exec: HealthCheckForward{},
+			want: map[string]string{
+				HelmReleaseArg:       "hr",
+				TimeoutArg:           "5m",
+				healthCheckEndpoints: "",
+			},
+		},
+		{
+			name:   "HealthCheck Endpoints And Timeout",
+			exec:   HealthCheckForward{},
+			params: &apiextensionsv1.JSON{Raw: []byte(`{"endpoints":["http://productpage.bookinfo:9080/health","https://bookinfo.example.com"],"timeout":"10m"}`)},
+			want: map[string]string{
+				HelmReleaseArg:       "hr",
+				TimeoutArg:           "10m0s",
+				healthCheckEndpoints: "http://productpage.bookinfo:9080/health,https://bookinfo.example.com",
+			},
+		},
+		{
+			name:    "HealthCheck Endpoint Without Scheme",
+			exec:    HealthCheckForward{},
+			params:  &apiextensionsv1.JSON{Raw: []byte(`{"endpoints":["productpage.bookinfo:9080/health"]}`)},
+			wantErr: true,
+		},
+		{
+			name: "HelmTest Without Parameters",
+			exec: HelmTestForward{},
+			want: map[string]string{
+				HelmReleaseArg: "hr",
+				TimeoutArg:     "5m",
+				helmTestFilter: "",
+				helmTestLogs:   "false",
+			},
+		},
+		{
+			name:   "HelmTest Filtered Tests With Logs",
+			exec:   HelmTestForward{},
+			params: &apiextensionsv1.JSON{Raw: []byte(`{"timeout":"90s","logs":true,"filter":["name=bookinfo-test-connection","!name=bookinfo-test-slow"]}`)},
+			want: map[string]string{
+				HelmReleaseArg: "hr",
+				TimeoutArg:     "1m30s",
+				helmTestFilter: "name=bookinfo-test-connection,!name=bookinfo-test-slow",
+				helmTestLogs:   "true",
+			},
+		},
+		{
+			name:    "HelmTest Filter Without Attribute",
+			exec:    HelmTestForward{},
+			params:  &apiextensionsv1.JSON{Raw: []byte(`{"filter":["bookinfo-test-connection"]}`)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := tt.exec.GetTask("executor", []string{"helmrelease"}, "5m", "hr", tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := make(map[string]string)
+			for _, p := range task.Arguments.Parameters {
+				got[p.Name] = p.Value.String()
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("GetTask() parameters diff = %v", cmp.Diff(got, tt.want))
+			}
+			// Every parameter of the task is an input of the template, passed on to the executor image
+			template := tt.exec.GetTemplate()
+			args := make(map[string]bool)
+			for _, arg := range template.Container.Args {
+				args[arg] = true
+			}
+			var inputs []string
+			for _, p := range template.Inputs.Parameters {
+				inputs = append(inputs, p.Name)
+				if p.Name != HelmReleaseArg && p.Name != TimeoutArg && !args[fmt.Sprintf("--%s={{inputs.parameters.%s}}", p.Name, p.Name)] {
+					t.Errorf("GetTemplate() args = %v, want the %s flag", template.Container.Args, p.Name)
+				}
+			}
+			var params []string
+			for _, p := range task.Arguments.Parameters {
+				params = append(params, p.Name)
+			}
+			if !cmp.Equal(params, inputs) {
+				t.Errorf("GetTask() parameters = %v, want the template inputs %v", params, inputs)
+			}
+			if reverse, _ := tt.exec.Reverse().GetTask("executor", nil, "5m", "hr", tt.params); reverse.When != "false" {
+				t.Errorf("GetTask() of the reverse executor must be skipped, got when %q", reverse.When)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/Azure/Orkestra/api/v1alpha1"
 	"github.com/Azure/Orkestra/pkg/utils"
 	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -12,19 +13,20 @@ import (
 )
 
 type CustomForward struct {
-	Image *corev1.Container
+	Image         *corev1.Container
+	RetryStrategy *v1alpha1.RetryStrategy
 }
 
 func (exec CustomForward) Reverse() Executor {
-	return CustomReverse{exec.Image}
+	return CustomReverse{exec.Image, exec.RetryStrategy}
 }
 
 func (exec CustomForward) GetName() string {
-	return "custom-forward-executor"
+	return templateName("custom-forward-executor", exec.RetryStrategy)
 }
 
 func (exec CustomForward) GetTemplate() v1alpha13.Template {
-	return customBaseTemplate(exec.GetName(), Install, exec.Image)
+	return customBaseTemplate(exec.GetName(), Install, exec.Image, exec.RetryStrategy)
 }
 
 func (exec CustomForward) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
@@ -32,26 +34,27 @@ func (exec CustomForward) GetTask(name string, dependencies []string, timeout, h
 }
 
 type CustomReverse struct {
-	Image *corev1.Container
+	Image         *corev1.Container
+	RetryStrategy *v1alpha1.RetryStrategy
 }
 
 func (exec CustomReverse) Reverse() Executor {
-	return CustomForward{exec.Image}
+	return CustomForward{exec.Image, exec.RetryStrategy}
 }
 
 func (exec CustomReverse) GetName() string {
-	return "custom-reverse-executor"
+	return templateName("custom-reverse-executor", exec.RetryStrategy)
 }
 
 func (exec CustomReverse) GetTemplate() v1alpha13.Template {
-	return customBaseTemplate(exec.GetName(), Delete, exec.Image)
+	return customBaseTemplate(exec.GetName(), Delete, exec.Image, exec.RetryStrategy)
 }
 
 func (exec CustomReverse) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
 	return customBaseTask(exec.GetName(), name, dependencies, timeout, hrStr, taskParams)
 }
 
-func customBaseTemplate(executorName string, action Action, image *corev1.Container) v1alpha13.Template {
+func customBaseTemplate(executorName string, action Action, image *corev1.Container, retryStrategy *v1alpha1.RetryStrategy) v1alpha13.Template {
 	executorArgs := []string{"--spec", "{{inputs.parameters.helmrelease}}", "--action", string(action), "--data", "{{inputs.parameters.data}}", "--timeout", "{{inputs.parameters.timeout}}", "--interval", "1s"}
 	return v1alpha13.Template{
 		Name:               executorName,
@@ -73,6 +76,7 @@ func customBaseTemplate(executorName string, action Action, image *corev1.Contai
 		Executor: &v1alpha13.ExecutorConfig{
 			ServiceAccountName: workflowServiceAccountName(),
 		},
+		RetryStrategy: toArgoRetryStrategy(retryStrategy),
 		Container: &corev1.Container{
 			Name:  image.Name,
 			Image: image.Image,
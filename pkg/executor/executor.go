@@ -43,15 +43,41 @@ type Executor interface {
 	GetTask(name string, dependencies []string, timeout, hrStr string, parameters *apiextensionsv1.JSON) (v1alpha13.DAGTask, error)
 }
 
-func ForwardFactory(executorType v1alpha1.ExecutorType, image *corev1.Container) Executor {
+// CompositeExecutor is implemented by the executors whose template runs the templates
+// of other executors, which have to be added to the workflow as well
+type CompositeExecutor interface {
+	Executor
+	GetExecutors() []Executor
+}
+
+func ForwardFactory(executorType v1alpha1.ExecutorType, image *corev1.Container, retryStrategy *v1alpha1.RetryStrategy) Executor {
 	switch executorType {
 	case v1alpha1.KeptnExecutor:
-		return KeptnForward{}
+		return KeptnForward{
+			RetryStrategy: retryStrategy,
+		}
+	case v1alpha1.CanaryExecutor:
+		return CanaryForward{
+			RetryStrategy: retryStrategy,
+		}
+	case v1alpha1.ApprovalExecutor:
+		return ApprovalForward{}
+	case v1alpha1.HealthCheckExecutor:
+		return HealthCheckForward{
+			RetryStrategy: retryStrategy,
+		}
+	case v1alpha1.HelmTestExecutor:
+		return HelmTestForward{
+			RetryStrategy: retryStrategy,
+		}
 	case v1alpha1.CustomExecutor:
 		return CustomForward{
-			Image: image,
+			Image:         image,
+			RetryStrategy: retryStrategy,
 		}
 	default:
-		return HelmReleaseForward{}
+		return HelmReleaseForward{
+			RetryStrategy: retryStrategy,
+		}
 	}
 }
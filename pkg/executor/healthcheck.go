@@ -0,0 +1,117 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	HealthCheckImage = "azureorkestra/healthcheck-executor"
+	HealthCheckTag   = "v0.1.0"
+)
+
+const healthCheckEndpoints = "endpoints"
+
+// HealthCheckForward waits for the workloads of the release to be rolled out, for its jobs
+// to complete and for the configured HTTP endpoints to return a 2xx status code
+type HealthCheckForward struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
+
+func (exec HealthCheckForward) Reverse() Executor {
+	return HealthCheckReverse{exec.RetryStrategy}
+}
+
+func (exec HealthCheckForward) GetName() string {
+	return templateName("healthcheck-executor", exec.RetryStrategy)
+}
+
+func (exec HealthCheckForward) GetTemplate() v1alpha13.Template {
+	return healthCheckBaseTemplate(exec.GetName(), exec.RetryStrategy)
+}
+
+func (exec HealthCheckForward) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return healthCheckBaseTask(exec.GetName(), name, dependencies, timeout, hrStr, taskParams)
+}
+
+// HealthCheckReverse skips the health check, since there is nothing left
+// to check once the applications are uninstalled
+type HealthCheckReverse struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
+
+func (exec HealthCheckReverse) Reverse() Executor {
+	return HealthCheckForward{exec.RetryStrategy}
+}
+
+func (exec HealthCheckReverse) GetName() string {
+	return templateName("healthcheck-executor", exec.RetryStrategy)
+}
+
+func (exec HealthCheckReverse) GetTemplate() v1alpha13.Template {
+	return healthCheckBaseTemplate(exec.GetName(), exec.RetryStrategy)
+}
+
+func (exec HealthCheckReverse) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return skippedTask(healthCheckBaseTask(exec.GetName(), name, dependencies, timeout, hrStr, taskParams))
+}
+
+func healthCheckBaseTemplate(executorName string, retryStrategy *v1alpha1.RetryStrategy) v1alpha13.Template {
+	return containerBaseTemplate(executorName, fmt.Sprintf("%s:%s", HealthCheckImage, HealthCheckTag), retryStrategy,
+		v1alpha13.Parameter{Name: healthCheckEndpoints, Default: utils.ToAnyStringPtr("")},
+	)
+}
+
+func healthCheckBaseTask(executorName, name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	params, err := healthCheckParameters(taskParams)
+	if err != nil {
+		return v1alpha13.DAGTask{}, err
+	}
+	if params.Timeout != nil {
+		timeout = params.Timeout.Duration.String()
+	}
+	return containerBaseTask(executorName, name, dependencies, timeout, hrStr,
+		v1alpha13.Parameter{Name: healthCheckEndpoints, Value: utils.ToAnyStringPtr(strings.Join(params.Endpoints, ","))},
+	), nil
+}
+
+func healthCheckParameters(taskParams *apiextensionsv1.JSON) (*HealthCheckParameters, error) {
+	params := &HealthCheckParameters{}
+	if taskParams == nil {
+		return params, nil
+	}
+	if err := json.Unmarshal(taskParams.Raw, params); err != nil {
+		return nil, err
+	}
+	for _, endpoint := range params.Endpoints {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("endpoint %q must be an http or https URL for the healthcheck executor", endpoint)
+		}
+		if strings.Contains(endpoint, ",") {
+			return nil, fmt.Errorf("endpoint %q must not contain a comma for the healthcheck executor", endpoint)
+		}
+	}
+	return params, nil
+}
+
+// ValidateHealthCheckParameters checks the parameters of the healthcheck executor
+func ValidateHealthCheckParameters(taskParams *apiextensionsv1.JSON) error {
+	_, err := healthCheckParameters(taskParams)
+	return err
+}
+
+type HealthCheckParameters struct {
+	// Endpoints are the HTTP endpoints that must return a 2xx status code
+	Endpoints []string `json:"endpoints,omitempty"`
+
+	// Timeout is the time the release has to become healthy in. Defaults to the timeout of the release.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
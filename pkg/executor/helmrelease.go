@@ -2,6 +2,8 @@ package executor
 
 import (
 	"fmt"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
 	"github.com/Azure/Orkestra/pkg/utils"
 	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -13,43 +15,47 @@ const (
 	HelmReleaseTag   = "v0.4.2"
 )
 
-type HelmReleaseForward struct{}
+type HelmReleaseForward struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
 
 func (exec HelmReleaseForward) Reverse() Executor {
-	return HelmReleaseReverse{}
+	return HelmReleaseReverse{exec.RetryStrategy}
 }
 
 func (exec HelmReleaseForward) GetName() string {
-	return "helmrelease-forward-executor"
+	return templateName("helmrelease-forward-executor", exec.RetryStrategy)
 }
 
 func (exec HelmReleaseForward) GetTemplate() v1alpha13.Template {
-	return helmReleaseBaseTemplate(exec.GetName(), Install)
+	return helmReleaseBaseTemplate(exec.GetName(), Install, exec.RetryStrategy)
 }
 
 func (exec HelmReleaseForward) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
 	return helmReleaseBaseTask(exec.GetName(), name, dependencies, timeout, hrStr), nil
 }
 
-type HelmReleaseReverse struct{}
+type HelmReleaseReverse struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
 
 func (exec HelmReleaseReverse) Reverse() Executor {
-	return HelmReleaseForward{}
+	return HelmReleaseForward{exec.RetryStrategy}
 }
 
 func (exec HelmReleaseReverse) GetName() string {
-	return "helmrelease-reverse-executor"
+	return templateName("helmrelease-reverse-executor", exec.RetryStrategy)
 }
 
 func (exec HelmReleaseReverse) GetTemplate() v1alpha13.Template {
-	return helmReleaseBaseTemplate(exec.GetName(), Delete)
+	return helmReleaseBaseTemplate(exec.GetName(), Delete, exec.RetryStrategy)
 }
 
 func (exec HelmReleaseReverse) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
 	return helmReleaseBaseTask(exec.GetName(), name, dependencies, timeout, hrStr), nil
 }
 
-func helmReleaseBaseTemplate(executorName string, action Action) v1alpha13.Template {
+func helmReleaseBaseTemplate(executorName string, action Action, retryStrategy *v1alpha1.RetryStrategy) v1alpha13.Template {
 	executorArgs := []string{"--spec", "{{inputs.parameters.helmrelease}}", "--action", string(action), "--timeout", "{{inputs.parameters.timeout}}", "--interval", "1s"}
 	return v1alpha13.Template{
 		Name:               executorName,
@@ -68,6 +74,7 @@ func helmReleaseBaseTemplate(executorName string, action Action) v1alpha13.Templ
 		Executor: &v1alpha13.ExecutorConfig{
 			ServiceAccountName: workflowServiceAccountName(),
 		},
+		RetryStrategy: toArgoRetryStrategy(retryStrategy),
 		Container: &corev1.Container{
 			Name:  ExecutorName,
 			Image: fmt.Sprintf("%s:%s", HelmReleaseImage, HelmReleaseTag),
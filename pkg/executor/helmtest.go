@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/helmtest"
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	HelmTestImage = "azureorkestra/helmtest-executor"
+	HelmTestTag   = "v0.1.0"
+)
+
+const (
+	helmTestFilter = "filter"
+	helmTestLogs   = "logs"
+)
+
+// HelmTestForward runs the helm test hooks of the release and fails if one of the tests fails
+type HelmTestForward struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
+
+func (exec HelmTestForward) Reverse() Executor {
+	return HelmTestReverse{exec.RetryStrategy}
+}
+
+func (exec HelmTestForward) GetName() string {
+	return templateName("helmtest-executor", exec.RetryStrategy)
+}
+
+func (exec HelmTestForward) GetTemplate() v1alpha13.Template {
+	return helmTestBaseTemplate(exec.GetName(), exec.RetryStrategy)
+}
+
+func (exec HelmTestForward) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return helmTestBaseTask(exec.GetName(), name, dependencies, timeout, hrStr, taskParams)
+}
+
+// HelmTestReverse skips the release tests, since there is nothing left
+// to test once the applications are uninstalled
+type HelmTestReverse struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
+
+func (exec HelmTestReverse) Reverse() Executor {
+	return HelmTestForward{exec.RetryStrategy}
+}
+
+func (exec HelmTestReverse) GetName() string {
+	return templateName("helmtest-executor", exec.RetryStrategy)
+}
+
+func (exec HelmTestReverse) GetTemplate() v1alpha13.Template {
+	return helmTestBaseTemplate(exec.GetName(), exec.RetryStrategy)
+}
+
+func (exec HelmTestReverse) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	return skippedTask(helmTestBaseTask(exec.GetName(), name, dependencies, timeout, hrStr, taskParams))
+}
+
+func helmTestBaseTemplate(executorName string, retryStrategy *v1alpha1.RetryStrategy) v1alpha13.Template {
+	return containerBaseTemplate(executorName, fmt.Sprintf("%s:%s", HelmTestImage, HelmTestTag), retryStrategy,
+		v1alpha13.Parameter{Name: helmTestFilter, Default: utils.ToAnyStringPtr("")},
+		v1alpha13.Parameter{Name: helmTestLogs, Default: utils.ToAnyStringPtr("false")},
+	)
+}
+
+func helmTestBaseTask(executorName, name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
+	params, err := helmTestParameters(taskParams)
+	if err != nil {
+		return v1alpha13.DAGTask{}, err
+	}
+	if params.Timeout != nil {
+		timeout = params.Timeout.Duration.String()
+	}
+	return containerBaseTask(executorName, name, dependencies, timeout, hrStr,
+		v1alpha13.Parameter{Name: helmTestFilter, Value: utils.ToAnyStringPtr(strings.Join(params.Filter, ","))},
+		v1alpha13.Parameter{Name: helmTestLogs, Value: utils.ToAnyStringPtr(strconv.FormatBool(params.Logs))},
+	), nil
+}
+
+func helmTestParameters(taskParams *apiextensionsv1.JSON) (*HelmTestParameters, error) {
+	params := &HelmTestParameters{}
+	if taskParams == nil {
+		return params, nil
+	}
+	if err := json.Unmarshal(taskParams.Raw, params); err != nil {
+		return nil, err
+	}
+	if _, err := helmtest.ParseFilters(params.Filter); err != nil {
+		return nil, fmt.Errorf("%v for the helmtest executor", err)
+	}
+	for _, f := range params.Filter {
+		if strings.Contains(f, ",") {
+			return nil, fmt.Errorf("filter %q must not contain a comma for the helmtest executor", f)
+		}
+	}
+	return params, nil
+}
+
+// ValidateHelmTestParameters checks the parameters of the helmtest executor
+func ValidateHelmTestParameters(taskParams *apiextensionsv1.JSON) error {
+	_, err := helmTestParameters(taskParams)
+	return err
+}
+
+type HelmTestParameters struct {
+	// Timeout is the time each test hook has to complete in. Defaults to the timeout of the release.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Logs adds the end of the logs of the test pods to the events of the ApplicationGroup
+	Logs bool `json:"logs,omitempty"`
+
+	// Filter selects the tests to run with name=<test> and excludes tests with !name=<test>
+	Filter []string `json:"filter,omitempty"`
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/Azure/Orkestra/api/v1alpha1"
 	"github.com/Azure/Orkestra/pkg/utils"
 	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -20,43 +21,47 @@ const (
 	configMapNamespace = "configMapNamespace"
 )
 
-type KeptnForward struct{}
+type KeptnForward struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
 
 func (exec KeptnForward) Reverse() Executor {
-	return KeptnReverse{}
+	return KeptnReverse{exec.RetryStrategy}
 }
 
 func (exec KeptnForward) GetName() string {
-	return "keptn-forward-executor"
+	return templateName("keptn-forward-executor", exec.RetryStrategy)
 }
 
 func (exec KeptnForward) GetTemplate() v1alpha13.Template {
-	return keptnBaseTemplate(exec.GetName(), Install)
+	return keptnBaseTemplate(exec.GetName(), Install, exec.RetryStrategy)
 }
 
 func (exec KeptnForward) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
 	return keptnBaseTask(exec.GetName(), name, dependencies, timeout, hrStr, taskParams)
 }
 
-type KeptnReverse struct{}
+type KeptnReverse struct {
+	RetryStrategy *v1alpha1.RetryStrategy
+}
 
 func (exec KeptnReverse) Reverse() Executor {
-	return KeptnForward{}
+	return KeptnForward{exec.RetryStrategy}
 }
 
 func (exec KeptnReverse) GetName() string {
-	return "keptn-reverse-executor"
+	return templateName("keptn-reverse-executor", exec.RetryStrategy)
 }
 
 func (exec KeptnReverse) GetTemplate() v1alpha13.Template {
-	return keptnBaseTemplate(exec.GetName(), Delete)
+	return keptnBaseTemplate(exec.GetName(), Delete, exec.RetryStrategy)
 }
 
 func (exec KeptnReverse) GetTask(name string, dependencies []string, timeout, hrStr string, taskParams *apiextensionsv1.JSON) (v1alpha13.DAGTask, error) {
 	return keptnBaseTask(exec.GetName(), name, dependencies, timeout, hrStr, taskParams)
 }
 
-func keptnBaseTemplate(executorName string, action Action) v1alpha13.Template {
+func keptnBaseTemplate(executorName string, action Action, retryStrategy *v1alpha1.RetryStrategy) v1alpha13.Template {
 	executorArgs := []string{"--spec", "{{inputs.parameters.helmrelease}}", "--action", string(action), "--configmap-name", "{{inputs.parameters.configMapName}}", "--configmap-namespace", "{{inputs.parameters.configMapNamespace}}", "--timeout", "{{inputs.parameters.timeout}}", "--interval", "1s"}
 	return v1alpha13.Template{
 		Name:               executorName,
@@ -81,6 +86,7 @@ func keptnBaseTemplate(executorName string, action Action) v1alpha13.Template {
 		Executor: &v1alpha13.ExecutorConfig{
 			ServiceAccountName: workflowServiceAccountName(),
 		},
+		RetryStrategy: toArgoRetryStrategy(retryStrategy),
 		Container: &corev1.Container{
 			Name:  executorName,
 			Image: fmt.Sprintf("%s:%s", KeptnImage, KeptnTag),
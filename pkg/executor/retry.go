@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const retryHashLen = 10
+
+// templateName returns the name of the executor template for the retry strategy.
+// Argo configures retries on the template, so every distinct retry strategy gets
+// its own template, suffixed with the hash of the retry strategy
+func templateName(name string, retryStrategy *v1alpha1.RetryStrategy) string {
+	if retryStrategy == nil {
+		return name
+	}
+	b, _ := json.Marshal(retryStrategy)
+	return fmt.Sprintf("%s-%s", name, utils.TruncateString(utils.GetHash(string(b)), retryHashLen))
+}
+
+// toArgoRetryStrategy converts the executor retry strategy to the Argo template retry strategy
+func toArgoRetryStrategy(retryStrategy *v1alpha1.RetryStrategy) *v1alpha13.RetryStrategy {
+	if retryStrategy == nil {
+		return nil
+	}
+	limit := intstr.FromInt(int(retryStrategy.Limit))
+	argoRetryStrategy := &v1alpha13.RetryStrategy{
+		Limit:       &limit,
+		RetryPolicy: v1alpha13.RetryPolicyOnFailure,
+	}
+	if retryStrategy.RetryOn != "" {
+		argoRetryStrategy.RetryPolicy = v1alpha13.RetryPolicy(retryStrategy.RetryOn)
+	}
+	if backoff := retryStrategy.Backoff; backoff != nil {
+		argoRetryStrategy.Backoff = &v1alpha13.Backoff{
+			Duration: backoff.Duration.Duration.String(),
+		}
+		if backoff.Factor != nil {
+			factor := intstr.FromInt(int(*backoff.Factor))
+			argoRetryStrategy.Backoff.Factor = &factor
+		}
+		if backoff.MaxDuration != nil {
+			argoRetryStrategy.Backoff.MaxDuration = backoff.MaxDuration.Duration.String()
+		}
+	}
+	return argoRetryStrategy
+}
@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_toArgoRetryStrategy(t *testing.T) {
+	factor := int32(2)
+	tests := []struct {
+		name          string
+		retryStrategy *v1alpha1.RetryStrategy
+		want          *v1alpha13.RetryStrategy
+	}{
+		{
+			name: "No Retry Strategy",
+		},
+		{
+			name:          "Defaults To Retry On Failure",
+			retryStrategy: &v1alpha1.RetryStrategy{Limit: 3},
+			want: &v1alpha13.RetryStrategy{
+				Limit:       intOrStringPtr(3),
+				RetryPolicy: v1alpha13.RetryPolicyOnFailure,
+			},
+		},
+		{
+			name: "With Backoff",
+			retryStrategy: &v1alpha1.RetryStrategy{
+				Limit:   2,
+				RetryOn: v1alpha1.RetryAlways,
+				Backoff: &v1alpha1.Backoff{
+					Duration:    metav1.Duration{Duration: 10 * time.Second},
+					Factor:      &factor,
+					MaxDuration: &metav1.Duration{Duration: 2 * time.Minute},
+				},
+			},
+			want: &v1alpha13.RetryStrategy{
+				Limit:       intOrStringPtr(2),
+				RetryPolicy: v1alpha13.RetryPolicyAlways,
+				Backoff: &v1alpha13.Backoff{
+					Duration:    "10s",
+					Factor:      intOrStringPtr(2),
+					MaxDuration: "2m0s",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toArgoRetryStrategy(tt.retryStrategy); !cmp.Equal(got, tt.want) {
+				t.Errorf("toArgoRetryStrategy() diff = %v", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestExecutorTemplateName(t *testing.T) {
+	retryOnce := &v1alpha1.RetryStrategy{Limit: 1}
+	retryTwice := &v1alpha1.RetryStrategy{Limit: 2}
+
+	if got := (HelmReleaseForward{}).GetName(); got != "helmrelease-forward-executor" {
+		t.Errorf("GetName() = %v, want the executor name without a retry strategy", got)
+	}
+	once := HelmReleaseForward{RetryStrategy: retryOnce}
+	if once.GetName() == (HelmReleaseForward{RetryStrategy: retryTwice}).GetName() {
+		t.Errorf("GetName() = %v for different retry strategies, want distinct template names", once.GetName())
+	}
+	if once.GetName() != once.GetTemplate().Name {
+		t.Errorf("GetTemplate() name = %v, want %v", once.GetTemplate().Name, once.GetName())
+	}
+	if once.Reverse().GetTemplate().RetryStrategy == nil {
+		t.Errorf("Reverse() dropped the retry strategy")
+	}
+}
+
+func intOrStringPtr(i int) *intstr.IntOrString {
+	v := intstr.FromInt(i)
+	return &v
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/Azure/Orkestra/api/v1alpha1"
 	executorpkg "github.com/Azure/Orkestra/pkg/executor"
@@ -112,19 +113,24 @@ type ExecutorNode struct {
 	Params       *apiextensionsv1.JSON
 }
 
-func NewExecutorNode(executor *v1alpha1.Executor) *ExecutorNode {
+// NewExecutorNode creates the executor node for the workflow executor. The retry strategy
+// of the executor takes precedence over the retry strategy of the application
+func NewExecutorNode(executor *v1alpha1.Executor, retryStrategy *v1alpha1.RetryStrategy) *ExecutorNode {
+	if executor.RetryStrategy != nil {
+		retryStrategy = executor.RetryStrategy
+	}
 	return &ExecutorNode{
 		Name:         executor.Name,
 		Dependencies: append([]string{}, executor.Dependencies...),
-		Executor:     executorpkg.ForwardFactory(executor.Type, executor.Image),
+		Executor:     executorpkg.ForwardFactory(executor.Type, executor.Image, retryStrategy),
 		Params:       executor.Params,
 	}
 }
 
-func NewDefaultExecutorNode() *ExecutorNode {
+func NewDefaultExecutorNode(retryStrategy *v1alpha1.RetryStrategy) *ExecutorNode {
 	return &ExecutorNode{
 		Name:         string(v1alpha1.HelmReleaseExecutor),
-		Executor:     executorpkg.ForwardFactory(v1alpha1.HelmReleaseExecutor, nil),
+		Executor:     executorpkg.ForwardFactory(v1alpha1.HelmReleaseExecutor, nil, retryStrategy),
 		Dependencies: []string{},
 	}
 }
@@ -148,17 +154,25 @@ func NewForwardGraph(appGroup *v1alpha1.ApplicationGroup) *Graph {
 		Nodes:        make(map[string]*AppNode),
 	}
 
-	for i, application := range appGroup.Spec.Applications {
+	for _, application := range appGroup.Spec.Applications {
 		applicationNode := NewAppNode(&application)
 		applicationTaskNode := NewTaskNode(&application)
-		g.assignExecutorsToTask(applicationTaskNode, application.Spec.Workflow)
+		g.assignExecutorsToTask(applicationTaskNode, application.Spec)
 		appValues := application.GetValues()
 
+		// The charts from Git repositories and buckets are staged with the version of their Chart.yaml,
+		// and the charts with a version range with the version that the range was resolved to
+		appStatus := appGroup.GetApplicationStatus(application.Name)
+		isResolved := application.Spec.Chart.GetSourceKind() != "" || application.Spec.Chart.IsVersionRange()
+		if isResolved && appStatus != nil && appStatus.Version != "" {
+			applicationTaskNode.ChartVersion = appStatus.Version
+		}
+
 		// We need to know that the subcharts were staged in order to build this graph
-		if len(appGroup.Status.Applications) > i {
+		if appStatus != nil {
 			// Iterate through the subchart nodes
 			for _, subChart := range application.Spec.Subcharts {
-				subChartStatus, ok := appGroup.Status.Applications[i].Subcharts[subChart.Name]
+				subChartStatus, ok := appStatus.Subcharts[subChart.Name]
 				if !ok {
 					continue
 				}
@@ -183,7 +197,7 @@ func NewForwardGraph(appGroup *v1alpha1.ApplicationGroup) *Graph {
 					subChartNode.Dependencies = append(subChartNode.Dependencies, getTaskName(application.Name, dep))
 				}
 
-				g.assignExecutorsToTask(subChartNode, application.Spec.Workflow)
+				g.assignExecutorsToTask(subChartNode, application.Spec)
 				applicationNode.Tasks[subChartNode.Name] = subChartNode
 
 				// Disable the sub-chart dependencies in the values of the parent chart
@@ -207,6 +221,73 @@ func NewReverseGraph(appGroup *v1alpha1.ApplicationGroup) *Graph {
 	return NewForwardGraph(appGroup).Reverse()
 }
 
+// NewRollbackGraph creates a DAG that rolls the applications back to the spec of the
+// passed previous ApplicationGroup and reverses the applications that were not part of it
+func NewRollbackGraph(current, previous *v1alpha1.ApplicationGroup) *Graph {
+	currGraph := NewForwardGraph(current)
+	lastGraph := NewForwardGraph(previous)
+	// Rolling back remediates a failed rollout, so it doesn't wait for the approval gates
+	lastGraph.skipApprovals()
+	diffGraph := Diff(currGraph, lastGraph)
+	return Combine(lastGraph, diffGraph.Reverse())
+}
+
+// NewPartialRollbackGraph creates the rollback DAG of NewRollbackGraph limited to the failed
+// applications and the applications that depend on them, leaving the healthy applications untouched
+func NewPartialRollbackGraph(current, previous *v1alpha1.ApplicationGroup, failedApps []string) *Graph {
+	apps := NewForwardGraph(current).Dependents(failedApps)
+	return NewRollbackGraph(current, previous).Subgraph(apps)
+}
+
+// Dependents returns the named app nodes together with the app nodes
+// that directly or transitively depend on them
+func (g *Graph) Dependents(names []string) map[string]bool {
+	dependents := make(map[string]bool)
+	queue := append([]string{}, names...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if dependents[name] {
+			continue
+		}
+		dependents[name] = true
+		for _, appName := range SortedAppNames(g.Nodes) {
+			for _, dep := range g.Nodes[appName].Dependencies {
+				if dep == name {
+					queue = append(queue, appName)
+				}
+			}
+		}
+	}
+	return dependents
+}
+
+// Subgraph returns a copy of the graph with only the named app nodes. The dependencies
+// on the removed app nodes and the executors that are no longer used are dropped
+func (g *Graph) Subgraph(names map[string]bool) *Graph {
+	subgraph := g.DeepCopy()
+	subgraph.AllExecutors = make(map[string]executorpkg.Executor)
+	for name, node := range subgraph.Nodes {
+		if !names[name] {
+			delete(subgraph.Nodes, name)
+			continue
+		}
+		dependencies := []string{}
+		for _, dep := range node.Dependencies {
+			if names[dep] {
+				dependencies = append(dependencies, dep)
+			}
+		}
+		node.Dependencies = dependencies
+		for _, task := range node.Tasks {
+			for _, executor := range task.Executors {
+				subgraph.addExecutorIfNotExist(executor.Executor)
+			}
+		}
+	}
+	return subgraph
+}
+
 // Reverse method reverses the app node dependencies and the task node dependencies
 // of the received graph
 func (g *Graph) Reverse() *Graph {
@@ -245,6 +326,20 @@ func (g *Graph) Reverse() *Graph {
 	return reverseGraph
 }
 
+// skipApprovals replaces the approval gates of the graph with their reverse, which is skipped
+func (g *Graph) skipApprovals() {
+	for _, application := range g.Nodes {
+		for _, task := range application.Tasks {
+			for _, executor := range task.Executors {
+				if _, ok := executor.Executor.(executorpkg.ApprovalForward); ok {
+					executor.Executor = executor.Executor.Reverse()
+					g.AllExecutors[executor.Executor.GetName()] = executor.Executor
+				}
+			}
+		}
+	}
+}
+
 // Diff returns the difference between two graphs
 // It is the equivalent of performing A - B
 func Diff(a, b *Graph) *Graph {
@@ -301,20 +396,27 @@ func (g *Graph) addExecutorIfNotExist(executor executorpkg.Executor) {
 	if _, ok := g.AllExecutors[executor.GetName()]; !ok {
 		g.AllExecutors[executor.GetName()] = executor
 	}
+	if composite, ok := executor.(executorpkg.CompositeExecutor); ok {
+		for _, e := range composite.GetExecutors() {
+			g.addExecutorIfNotExist(e)
+		}
+	}
 }
 
 func getTaskName(appName, taskName string) string {
 	return fmt.Sprintf("%s-%s", appName, taskName)
 }
 
-func (g *Graph) assignExecutorsToTask(taskNode *TaskNode, workflow []v1alpha1.Executor) {
-	if len(workflow) == 0 {
-		taskNode.Executors[string(v1alpha1.HelmReleaseExecutor)] = NewDefaultExecutorNode()
-		g.addExecutorIfNotExist(executorpkg.ForwardFactory(v1alpha1.HelmReleaseExecutor, nil))
+func (g *Graph) assignExecutorsToTask(taskNode *TaskNode, spec v1alpha1.ApplicationSpec) {
+	if len(spec.Workflow) == 0 {
+		executorNode := NewDefaultExecutorNode(spec.RetryStrategy)
+		taskNode.Executors[executorNode.Name] = executorNode
+		g.addExecutorIfNotExist(executorNode.Executor)
 	} else {
-		for _, item := range workflow {
-			taskNode.Executors[item.Name] = NewExecutorNode(&item)
-			g.addExecutorIfNotExist(executorpkg.ForwardFactory(item.Type, item.Image))
+		for _, item := range spec.Workflow {
+			executorNode := NewExecutorNode(&item, spec.RetryStrategy)
+			taskNode.Executors[item.Name] = executorNode
+			g.addExecutorIfNotExist(executorNode.Executor)
 		}
 	}
 }
@@ -345,3 +447,33 @@ func SubChartValues(subChartName string, values map[string]interface{}) (*apiext
 	}
 	return v1alpha1.GetJSON(data)
 }
+
+// SortedAppNames returns the names of the app nodes in sorted order
+func SortedAppNames(nodes map[string]*AppNode) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SortedTaskNames returns the names of the task nodes in sorted order
+func SortedTaskNames(nodes map[string]*TaskNode) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SortedExecutorNames returns the names of the executor nodes in sorted order
+func SortedExecutorNames(nodes map[string]*ExecutorNode) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
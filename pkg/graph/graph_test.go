@@ -224,6 +224,7 @@ func Test_NewForwardGraph(t *testing.T) {
 					Status: v1alpha1.ApplicationGroupStatus{
 						Applications: []v1alpha1.ApplicationStatus{
 							{
+								Name: "application1",
 								Subcharts: map[string]v1alpha1.ChartStatus{
 									"subchart1": {
 										Version: "0.1.0",
@@ -237,6 +238,7 @@ func Test_NewForwardGraph(t *testing.T) {
 								},
 							},
 							{
+								Name: "application2",
 								Subcharts: map[string]v1alpha1.ChartStatus{
 									"subchart1": {
 										Version: "0.1.0",
@@ -619,6 +621,7 @@ func Test_NewReverseGraph(t *testing.T) {
 					Status: v1alpha1.ApplicationGroupStatus{
 						Applications: []v1alpha1.ApplicationStatus{
 							{
+								Name: "application1",
 								Subcharts: map[string]v1alpha1.ChartStatus{
 									"subchart1": {
 										Version: "0.1.0",
@@ -632,6 +635,7 @@ func Test_NewReverseGraph(t *testing.T) {
 								},
 							},
 							{
+								Name: "application2",
 								Subcharts: map[string]v1alpha1.ChartStatus{
 									"subchart1": {
 										Version: "0.1.0",
@@ -1332,3 +1336,166 @@ func Test_subChartValues(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewPartialRollbackGraph(t *testing.T) {
+	application := func(name, version string, dependencies ...string) v1alpha1.Application {
+		return v1alpha1.Application{
+			DAG: v1alpha1.DAG{Name: name, Dependencies: dependencies},
+			Spec: v1alpha1.ApplicationSpec{
+				Chart:   &v1alpha1.ChartRef{Name: name, Version: version},
+				Release: &v1alpha1.Release{},
+			},
+		}
+	}
+	previous := &v1alpha1.ApplicationGroup{
+		ObjectMeta: v1.ObjectMeta{Name: "bookinfo"},
+		Spec: v1alpha1.ApplicationGroupSpec{
+			Applications: []v1alpha1.Application{
+				application("a", "0.1.0"),
+				application("b", "0.1.0", "a"),
+				application("c", "0.1.0", "b"),
+				application("d", "0.1.0"),
+			},
+		},
+	}
+	current := previous.DeepCopy()
+	current.Spec.Applications = []v1alpha1.Application{
+		application("a", "0.2.0"),
+		application("b", "0.2.0", "a"),
+		application("c", "0.2.0", "b"),
+		application("d", "0.2.0"),
+		application("e", "0.1.0", "b"),
+	}
+
+	got := NewPartialRollbackGraph(current, previous, []string{"b"})
+
+	type node struct {
+		Dependencies []string
+		ChartVersion string
+		Executor     string
+	}
+	gotNodes := make(map[string]node)
+	for name, appNode := range got.Nodes {
+		task := appNode.Tasks[getTaskName(name, name)]
+		gotNodes[name] = node{
+			Dependencies: appNode.Dependencies,
+			ChartVersion: task.ChartVersion,
+			Executor:     task.Executors[string(v1alpha1.HelmReleaseExecutor)].Executor.GetName(),
+		}
+	}
+	wantNodes := map[string]node{
+		"b": {Dependencies: []string{}, ChartVersion: "0.1.0", Executor: executor.HelmReleaseForward{}.GetName()},
+		"c": {Dependencies: []string{"b"}, ChartVersion: "0.1.0", Executor: executor.HelmReleaseForward{}.GetName()},
+		"e": {Dependencies: []string{}, ChartVersion: "0.1.0", Executor: executor.HelmReleaseReverse{}.GetName()},
+	}
+	if !cmp.Equal(gotNodes, wantNodes) {
+		t.Errorf("NewPartialRollbackGraph() nodes diff = %v", cmp.Diff(gotNodes, wantNodes))
+	}
+	wantExecutors := map[string]executor.Executor{
+		executor.HelmReleaseForward{}.GetName(): executor.HelmReleaseForward{},
+		executor.HelmReleaseReverse{}.GetName(): executor.HelmReleaseReverse{},
+	}
+	if !cmp.Equal(got.AllExecutors, wantExecutors) {
+		t.Errorf("NewPartialRollbackGraph() executors diff = %v", cmp.Diff(got.AllExecutors, wantExecutors))
+	}
+}
+
+func Test_NewForwardGraph_ResolvedChartVersion(t *testing.T) {
+	appGroup := &v1alpha1.ApplicationGroup{
+		ObjectMeta: v1.ObjectMeta{Name: "application"},
+		Spec: v1alpha1.ApplicationGroupSpec{
+			Applications: []v1alpha1.Application{
+				{
+					DAG: v1alpha1.DAG{Name: "application1"},
+					Spec: v1alpha1.ApplicationSpec{
+						Chart: &v1alpha1.ChartRef{
+							Name:    "application1",
+							Version: "*",
+							Git:     &v1alpha1.GitChartSource{Path: "charts/application1"},
+						},
+						Release: &v1alpha1.Release{},
+					},
+				},
+				{
+					DAG: v1alpha1.DAG{Name: "application2"},
+					Spec: v1alpha1.ApplicationSpec{
+						Chart:   &v1alpha1.ChartRef{Name: "application2", Version: "0.1.0"},
+						Release: &v1alpha1.Release{},
+					},
+				},
+				{
+					DAG: v1alpha1.DAG{Name: "application3"},
+					Spec: v1alpha1.ApplicationSpec{
+						Chart:   &v1alpha1.ChartRef{Name: "application3", Version: "~1.2"},
+						Release: &v1alpha1.Release{},
+					},
+				},
+			},
+		},
+		Status: v1alpha1.ApplicationGroupStatus{
+			Applications: []v1alpha1.ApplicationStatus{
+				{Name: "application1", ChartStatus: v1alpha1.ChartStatus{Version: "1.2.0"}},
+				{Name: "application2", ChartStatus: v1alpha1.ChartStatus{Version: "0.2.0"}},
+				{Name: "application3", ChartStatus: v1alpha1.ChartStatus{Version: "1.2.3"}},
+			},
+		},
+	}
+	wantVersions := map[string]string{"application1": "1.2.0", "application2": "0.1.0", "application3": "1.2.3"}
+	g := NewForwardGraph(appGroup)
+	// The chart from the Git repository and the chart with a version range are released with the
+	// staged version of the status, while the chart with an exact version keeps the version of the spec
+	for app, want := range wantVersions {
+		if got := g.Nodes[app].Tasks[getTaskName(app, app)].ChartVersion; got != want {
+			t.Errorf("NewForwardGraph() %s chart version = %v, want %v", app, got, want)
+		}
+	}
+
+	// The status of the applications is looked up by name, since the applications may be reordered in the spec
+	appGroup.Spec.Applications[0], appGroup.Spec.Applications[2] = appGroup.Spec.Applications[2], appGroup.Spec.Applications[0]
+	g = NewForwardGraph(appGroup)
+	for app, want := range wantVersions {
+		if got := g.Nodes[app].Tasks[getTaskName(app, app)].ChartVersion; got != want {
+			t.Errorf("NewForwardGraph() of the reordered applications %s chart version = %v, want %v", app, got, want)
+		}
+	}
+}
+
+func Test_NewRollbackGraph_Approval(t *testing.T) {
+	appGroup := func(version string) *v1alpha1.ApplicationGroup {
+		return &v1alpha1.ApplicationGroup{
+			ObjectMeta: v1.ObjectMeta{Name: "bookinfo"},
+			Spec: v1alpha1.ApplicationGroupSpec{
+				Applications: []v1alpha1.Application{
+					{
+						DAG: v1alpha1.DAG{Name: "a"},
+						Spec: v1alpha1.ApplicationSpec{
+							Chart:   &v1alpha1.ChartRef{Name: "a", Version: version},
+							Release: &v1alpha1.Release{},
+							Workflow: []v1alpha1.Executor{
+								{DAG: v1alpha1.DAG{Name: "approval"}, Type: v1alpha1.ApprovalExecutor},
+								{DAG: v1alpha1.DAG{Name: "helmrelease", Dependencies: []string{"approval"}}, Type: v1alpha1.HelmReleaseExecutor},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	previous, current := appGroup("0.1.0"), appGroup("0.2.0")
+
+	// The forward workflow waits for the approval, while the rollback skips it
+	for name, tt := range map[string]struct {
+		g    *Graph
+		want executor.Executor
+	}{
+		"Forward":  {g: NewForwardGraph(current), want: executor.ApprovalForward{}},
+		"Rollback": {g: NewRollbackGraph(current, previous), want: executor.ApprovalReverse{}},
+	} {
+		if got := tt.g.Nodes["a"].Tasks[getTaskName("a", "a")].Executors["approval"].Executor; got != tt.want {
+			t.Errorf("%s graph approval executor = %T, want %T", name, got, tt.want)
+		}
+		if got := tt.g.AllExecutors[executor.ApprovalExecutorName]; got != tt.want {
+			t.Errorf("%s graph approval template executor = %T, want %T", name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// ErrInvalidGraph is matched by all of the graph validation errors
+var ErrInvalidGraph = errors.New("invalid graph")
+
+// Level identifies the level of the graph that a validation error was found at
+type Level string
+
+const (
+	AppLevel      Level = "application"
+	TaskLevel     Level = "task"
+	ExecutorLevel Level = "executor"
+)
+
+// DanglingDependencyError is returned when a node depends on a node
+// that does not exist at the same level of the graph
+type DanglingDependencyError struct {
+	Level Level
+	// Scope is the name of the enclosing app node for the task level and
+	// the enclosing task node for the executor level
+	Scope      string
+	Node       string
+	Dependency string
+}
+
+func (e *DanglingDependencyError) Error() string {
+	return fmt.Sprintf("%s node %q%s depends on unknown %s node %q", e.Level, e.Node, scopeString(e.Scope), e.Level, e.Dependency)
+}
+
+func (e *DanglingDependencyError) Unwrap() error {
+	return ErrInvalidGraph
+}
+
+// CycleError is returned when the dependencies at one level of the graph form a cycle
+type CycleError struct {
+	Level Level
+	// Scope is the name of the enclosing app node for the task level and
+	// the enclosing task node for the executor level
+	Scope string
+	// Path starts and ends with the same node
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%s dependency cycle detected%s: %s", e.Level, scopeString(e.Scope), strings.Join(e.Path, " -> "))
+}
+
+func (e *CycleError) Unwrap() error {
+	return ErrInvalidGraph
+}
+
+func scopeString(scope string) string {
+	if scope == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in %q", scope)
+}
+
+// Validate checks the app node, task node and executor node dependencies of the graph
+// and returns an aggregate of the DanglingDependencyError and CycleError found, or nil
+// if the graph is a valid set of DAGs
+func (g *Graph) Validate() error {
+	var errs []error
+	appDeps := make(map[string][]string)
+	for _, appName := range SortedAppNames(g.Nodes) {
+		appNode := g.Nodes[appName]
+		appDeps[appName] = appNode.Dependencies
+		errs = append(errs, danglingDependencies(AppLevel, "", appName, appNode.Dependencies, func(dep string) bool {
+			_, ok := g.Nodes[dep]
+			return ok
+		})...)
+
+		taskDeps := make(map[string][]string)
+		for _, taskName := range SortedTaskNames(appNode.Tasks) {
+			task := appNode.Tasks[taskName]
+			taskDeps[taskName] = task.Dependencies
+			errs = append(errs, danglingDependencies(TaskLevel, appName, taskName, task.Dependencies, func(dep string) bool {
+				_, ok := appNode.Tasks[dep]
+				return ok
+			})...)
+
+			executorDeps := make(map[string][]string)
+			for _, executorName := range SortedExecutorNames(task.Executors) {
+				executorNode := task.Executors[executorName]
+				executorDeps[executorName] = executorNode.Dependencies
+				errs = append(errs, danglingDependencies(ExecutorLevel, taskName, executorName, executorNode.Dependencies, func(dep string) bool {
+					_, ok := task.Executors[dep]
+					return ok
+				})...)
+			}
+			if cycle := findCycle(executorDeps); cycle != nil {
+				errs = append(errs, &CycleError{Level: ExecutorLevel, Scope: taskName, Path: cycle})
+			}
+		}
+		if cycle := findCycle(taskDeps); cycle != nil {
+			errs = append(errs, &CycleError{Level: TaskLevel, Scope: appName, Path: cycle})
+		}
+	}
+	if cycle := findCycle(appDeps); cycle != nil {
+		errs = append(errs, &CycleError{Level: AppLevel, Path: cycle})
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func danglingDependencies(level Level, scope, node string, dependencies []string, exists func(string) bool) []error {
+	var errs []error
+	for _, dep := range dependencies {
+		if !exists(dep) {
+			errs = append(errs, &DanglingDependencyError{Level: level, Scope: scope, Node: node, Dependency: dep})
+		}
+	}
+	return errs
+}
+
+// findCycle performs a depth first search over the dependency mapping and
+// returns the first cycle found as a path of node names, or nil if there are none.
+// Dependencies on unknown nodes are ignored since they are reported as dangling.
+func findCycle(deps map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		state[node] = visiting
+		path = append(path, node)
+		for _, dep := range deps[node] {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				for i, name := range path {
+					if name == dep {
+						return append(append([]string{}, path[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+		return nil
+	}
+
+	nodes := make([]string, 0, len(deps))
+	for node := range deps {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+func Test_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph *Graph
+		want  []error
+	}{
+		{
+			name: "Valid Graph",
+			graph: &Graph{
+				Nodes: map[string]*AppNode{
+					"application1": {
+						Name: "application1",
+						Tasks: map[string]*TaskNode{
+							"application1-subchart1": {
+								Name: "application1-subchart1",
+							},
+							"application1-application1": {
+								Name:         "application1-application1",
+								Dependencies: []string{"application1-subchart1"},
+								Executors: map[string]*ExecutorNode{
+									"helmrelease": {Name: "helmrelease"},
+									"keptn":       {Name: "keptn", Dependencies: []string{"helmrelease"}},
+								},
+							},
+						},
+					},
+					"application2": {
+						Name:         "application2",
+						Dependencies: []string{"application1"},
+					},
+				},
+			},
+			want: nil,
+		},
+		{
+			name: "Dangling Dependencies At All Levels",
+			graph: &Graph{
+				Nodes: map[string]*AppNode{
+					"application1": {
+						Name:         "application1",
+						Dependencies: []string{"application3"},
+						Tasks: map[string]*TaskNode{
+							"application1-application1": {
+								Name:         "application1-application1",
+								Dependencies: []string{"application1-subchart1"},
+								Executors: map[string]*ExecutorNode{
+									"keptn": {Name: "keptn", Dependencies: []string{"helmrelease"}},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: []error{
+				&DanglingDependencyError{Level: AppLevel, Node: "application1", Dependency: "application3"},
+				&DanglingDependencyError{Level: TaskLevel, Scope: "application1", Node: "application1-application1", Dependency: "application1-subchart1"},
+				&DanglingDependencyError{Level: ExecutorLevel, Scope: "application1-application1", Node: "keptn", Dependency: "helmrelease"},
+			},
+		},
+		{
+			name: "Cycles At All Levels",
+			graph: &Graph{
+				Nodes: map[string]*AppNode{
+					"application1": {
+						Name:         "application1",
+						Dependencies: []string{"application3"},
+						Tasks: map[string]*TaskNode{
+							"application1-subchart1": {
+								Name:         "application1-subchart1",
+								Dependencies: []string{"application1-subchart2"},
+							},
+							"application1-subchart2": {
+								Name:         "application1-subchart2",
+								Dependencies: []string{"application1-subchart1"},
+							},
+						},
+					},
+					"application2": {
+						Name:         "application2",
+						Dependencies: []string{"application1"},
+						Tasks: map[string]*TaskNode{
+							"application2-application2": {
+								Name: "application2-application2",
+								Executors: map[string]*ExecutorNode{
+									"helmrelease": {Name: "helmrelease", Dependencies: []string{"helmrelease"}},
+								},
+							},
+						},
+					},
+					"application3": {
+						Name:         "application3",
+						Dependencies: []string{"application2"},
+					},
+				},
+			},
+			want: []error{
+				&CycleError{Level: TaskLevel, Scope: "application1", Path: []string{"application1-subchart1", "application1-subchart2", "application1-subchart1"}},
+				&CycleError{Level: ExecutorLevel, Scope: "application2-application2", Path: []string{"helmrelease", "helmrelease"}},
+				&CycleError{Level: AppLevel, Path: []string{"application1", "application3", "application2", "application1"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.graph.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidGraph) {
+				t.Fatalf("Validate() = %v, want an error matching ErrInvalidGraph", err)
+			}
+			agg, ok := err.(utilerrors.Aggregate)
+			if !ok {
+				t.Fatalf("Validate() = %T, want an aggregate error", err)
+			}
+			if !cmp.Equal(agg.Errors(), tt.want) {
+				t.Errorf("Validate() diff = %v", cmp.Diff(agg.Errors(), tt.want))
+			}
+		})
+	}
+}
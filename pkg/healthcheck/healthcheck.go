@@ -0,0 +1,244 @@
+// Package healthcheck checks that the workloads of a helm release are rolled out,
+// that its jobs completed and that its HTTP endpoints are healthy.
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ReleaseNameAnnotation and ReleaseNamespaceAnnotation are set by helm on the resources of a release
+	ReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	ReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+
+	DefaultInterval = 5 * time.Second
+)
+
+// Checker checks the health of the resources of a helm release
+type Checker struct {
+	Client client.Client
+	Logger logr.Logger
+
+	// HTTPClient sends the requests to the endpoints, defaults to the http.DefaultClient
+	HTTPClient *http.Client
+
+	// ReleaseName and ReleaseNamespace identify the helm release
+	ReleaseName      string
+	ReleaseNamespace string
+
+	// Endpoints are the HTTP endpoints that must return a 2xx status code
+	Endpoints []string
+}
+
+// JobFailedError is returned when a job of the release failed, since waiting for it doesn't help
+type JobFailedError struct {
+	Name    string
+	Message string
+}
+
+func (e *JobFailedError) Error() string {
+	return fmt.Sprintf("job %s failed: %s", e.Name, e.Message)
+}
+
+// Wait checks the health of the release every interval until it is healthy, one of its jobs failed or the timeout expires
+func (c *Checker) Wait(ctx context.Context, timeout, interval time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var unhealthy []string
+	err := wait.PollImmediateUntil(interval, func() (bool, error) {
+		var err error
+		unhealthy, err = c.Check(ctx)
+		if _, ok := err.(*JobFailedError); ok {
+			return false, err
+		} else if err != nil {
+			// The API server or the endpoints may be temporarily unavailable
+			c.Logger.Info("failed to check the health of the release", "error", err.Error())
+			unhealthy = []string{err.Error()}
+			return false, nil
+		}
+		for _, reason := range unhealthy {
+			c.Logger.Info("release is not healthy yet", "reason", reason)
+		}
+		return len(unhealthy) == 0, nil
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("release %s/%s is not healthy after %s: %v", c.ReleaseNamespace, c.ReleaseName, timeout, unhealthy)
+	}
+	return err
+}
+
+// Check returns the reasons why the release is not healthy yet. It returns a JobFailedError if one of its jobs failed.
+func (c *Checker) Check(ctx context.Context) ([]string, error) {
+	var unhealthy []string
+
+	deployments := &appsv1.DeploymentList{}
+	if err := c.Client.List(ctx, deployments, client.InNamespace(c.ReleaseNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list the deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		if d := &deployments.Items[i]; c.inRelease(d) {
+			if reason := deploymentStatus(d); reason != "" {
+				unhealthy = append(unhealthy, fmt.Sprintf("deployment %s %s", d.Name, reason))
+			}
+		}
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := c.Client.List(ctx, statefulSets, client.InNamespace(c.ReleaseNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list the statefulsets: %w", err)
+	}
+	for i := range statefulSets.Items {
+		if s := &statefulSets.Items[i]; c.inRelease(s) {
+			if reason := statefulSetStatus(s); reason != "" {
+				unhealthy = append(unhealthy, fmt.Sprintf("statefulset %s %s", s.Name, reason))
+			}
+		}
+	}
+
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := c.Client.List(ctx, daemonSets, client.InNamespace(c.ReleaseNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list the daemonsets: %w", err)
+	}
+	for i := range daemonSets.Items {
+		if d := &daemonSets.Items[i]; c.inRelease(d) {
+			if reason := daemonSetStatus(d); reason != "" {
+				unhealthy = append(unhealthy, fmt.Sprintf("daemonset %s %s", d.Name, reason))
+			}
+		}
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := c.Client.List(ctx, jobs, client.InNamespace(c.ReleaseNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list the jobs: %w", err)
+	}
+	for i := range jobs.Items {
+		if j := &jobs.Items[i]; c.inRelease(j) {
+			if complete, message := jobStatus(j); message != "" {
+				return nil, &JobFailedError{Name: j.Name, Message: message}
+			} else if !complete {
+				unhealthy = append(unhealthy, fmt.Sprintf("job %s has not completed", j.Name))
+			}
+		}
+	}
+
+	for _, endpoint := range c.Endpoints {
+		if reason := c.endpointStatus(ctx, endpoint); reason != "" {
+			unhealthy = append(unhealthy, fmt.Sprintf("endpoint %s %s", endpoint, reason))
+		}
+	}
+	return unhealthy, nil
+}
+
+func (c *Checker) inRelease(obj client.Object) bool {
+	annotations := obj.GetAnnotations()
+	return annotations[ReleaseNameAnnotation] == c.ReleaseName && annotations[ReleaseNamespaceAnnotation] == c.ReleaseNamespace
+}
+
+func (c *Checker) endpointStatus(ctx context.Context, endpoint string) string {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Sprintf("is invalid: %v", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Sprintf("is unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Sprintf("returned %s", resp.Status)
+	}
+	return ""
+}
+
+// deploymentStatus returns why the deployment isn't rolled out, following kubectl rollout status
+func deploymentStatus(d *appsv1.Deployment) string {
+	if d.Status.ObservedGeneration < d.Generation {
+		return "has not observed its latest generation"
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	switch {
+	case d.Status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("has %d of %d replicas updated", d.Status.UpdatedReplicas, replicas)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		return fmt.Sprintf("has %d old replicas pending termination", d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		return fmt.Sprintf("has %d of %d updated replicas available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	}
+	return ""
+}
+
+// statefulSetStatus returns why the statefulset isn't rolled out, following kubectl rollout status
+func statefulSetStatus(s *appsv1.StatefulSet) string {
+	if s.Status.ObservedGeneration < s.Generation {
+		return "has not observed its latest generation"
+	}
+	replicas := int32(1)
+	if s.Spec.Replicas != nil {
+		replicas = *s.Spec.Replicas
+	}
+	if s.Status.ReadyReplicas < replicas {
+		return fmt.Sprintf("has %d of %d replicas ready", s.Status.ReadyReplicas, replicas)
+	}
+	if s.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return ""
+	}
+	if rollingUpdate := s.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		// Only the replicas from the partition onwards are updated
+		if updated := replicas - *rollingUpdate.Partition; s.Status.UpdatedReplicas < updated {
+			return fmt.Sprintf("has %d of %d partitioned replicas updated", s.Status.UpdatedReplicas, updated)
+		}
+		return ""
+	}
+	if s.Status.UpdateRevision != s.Status.CurrentRevision {
+		return fmt.Sprintf("has %d of %d replicas updated to revision %s", s.Status.UpdatedReplicas, replicas, s.Status.UpdateRevision)
+	}
+	return ""
+}
+
+// daemonSetStatus returns why the daemonset isn't rolled out, following kubectl rollout status
+func daemonSetStatus(d *appsv1.DaemonSet) string {
+	if d.Status.ObservedGeneration < d.Generation {
+		return "has not observed its latest generation"
+	}
+	if d.Spec.UpdateStrategy.Type == appsv1.RollingUpdateDaemonSetStrategyType && d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("has %d of %d pods updated", d.Status.UpdatedNumberScheduled, d.Status.DesiredNumberScheduled)
+	}
+	if d.Status.NumberAvailable < d.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("has %d of %d pods available", d.Status.NumberAvailable, d.Status.DesiredNumberScheduled)
+	}
+	return ""
+}
+
+// jobStatus returns whether the job completed, or the message of its failure
+func jobStatus(j *batchv1.Job) (bool, string) {
+	for _, condition := range j.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, ""
+		case batchv1.JobFailed:
+			return false, condition.Message
+		}
+	}
+	return false, ""
+}
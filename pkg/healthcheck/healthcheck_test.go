@@ -0,0 +1,156 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func releaseMeta(name, release string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   "bookinfo",
+		Generation:  2,
+		Annotations: map[string]string{ReleaseNameAnnotation: release, ReleaseNamespaceAnnotation: "bookinfo"},
+	}
+}
+
+func deployment(name, release string, updated, available int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: releaseMeta(name, release),
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: updated, AvailableReplicas: available},
+	}
+}
+
+func statefulSet(name, release, updateRevision string) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: releaseMeta(name, release),
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       int32Ptr(1),
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 1, UpdatedReplicas: 1, CurrentRevision: "rev-1", UpdateRevision: updateRevision},
+	}
+}
+
+func daemonSet(name, release string, available int32) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: releaseMeta(name, release),
+		Spec:       appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}},
+		Status:     appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: available},
+	}
+}
+
+func job(name, release string, condition batchv1.JobConditionType) *batchv1.Job {
+	j := &batchv1.Job{ObjectMeta: releaseMeta(name, release)}
+	if condition != "" {
+		j.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	}
+	return j
+}
+
+func TestChecker_Check(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		objects       []client.Object
+		endpoints     []string
+		wantUnhealthy int
+		wantJobFailed bool
+	}{
+		{
+			name: "Healthy Release",
+			objects: []client.Object{
+				deployment("productpage", "bookinfo", 2, 2),
+				statefulSet("ratings", "bookinfo", "rev-1"),
+				daemonSet("logging", "bookinfo", 3),
+				job("migrate", "bookinfo", batchv1.JobComplete),
+			},
+			endpoints: []string{healthy.URL},
+		},
+		{
+			name: "Workloads Rolling Out",
+			objects: []client.Object{
+				deployment("productpage", "bookinfo", 1, 1),
+				statefulSet("ratings", "bookinfo", "rev-2"),
+				daemonSet("logging", "bookinfo", 2),
+				job("migrate", "bookinfo", ""),
+			},
+			wantUnhealthy: 4,
+		},
+		{
+			name: "Resources Of Other Releases Are Ignored",
+			objects: []client.Object{
+				deployment("productpage", "bookinfo", 2, 2),
+				deployment("reviews", "reviews", 0, 0),
+				job("migrate", "reviews", batchv1.JobFailed),
+			},
+		},
+		{
+			name:          "Unavailable Endpoint",
+			objects:       []client.Object{deployment("productpage", "bookinfo", 2, 2)},
+			endpoints:     []string{healthy.URL, unavailable.URL},
+			wantUnhealthy: 1,
+		},
+		{
+			name:          "Failed Job",
+			objects:       []client.Object{job("migrate", "bookinfo", batchv1.JobFailed)},
+			wantJobFailed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &Checker{
+				Client:           fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objects...).Build(),
+				Logger:           logr.Discard(),
+				ReleaseName:      "bookinfo",
+				ReleaseNamespace: "bookinfo",
+				Endpoints:        tt.endpoints,
+			}
+			unhealthy, err := checker.Check(context.Background())
+			if _, ok := err.(*JobFailedError); ok != tt.wantJobFailed {
+				t.Fatalf("Check() error = %v, wantJobFailed %v", err, tt.wantJobFailed)
+			}
+			if !tt.wantJobFailed && err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if len(unhealthy) != tt.wantUnhealthy {
+				t.Errorf("Check() unhealthy = %v, want %d reasons", unhealthy, tt.wantUnhealthy)
+			}
+			// A release that doesn't become healthy fails once the timeout expires
+			if tt.wantUnhealthy > 0 {
+				if err := checker.Wait(context.Background(), 50*time.Millisecond, 10*time.Millisecond); err == nil {
+					t.Errorf("Wait() of an unhealthy release must fail")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package helmtest runs the test hooks of a helm release and records their
+// results and logs as events of the ApplicationGroup that owns the release.
+package helmtest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// EventSource is the component reported in the events of the test results
+	EventSource = "helmtest-executor"
+
+	// TestSucceededReason and TestFailedReason are the reasons of the events of the test results
+	TestSucceededReason = "HelmTestSucceeded"
+	TestFailedReason    = "HelmTestFailed"
+
+	// LogTailLines is the number of lines kept from the end of the logs of a test pod
+	LogTailLines = 20
+
+	// maxEventMessageLength is the length the event messages are truncated to, keeping the end of the logs
+	maxEventMessageLength = 1024
+)
+
+// Result is the outcome of a test hook of the release
+type Result struct {
+	Name  string
+	Kind  string
+	Phase release.HookPhase
+	Logs  string
+}
+
+// Failed returns whether the test hook didn't succeed
+func (r Result) Failed() bool {
+	return r.Phase != release.HookPhaseSucceeded
+}
+
+// ParseFilters parses the test filters in the name=<test> and !name=<test> syntax of the helm test command
+func ParseFilters(filters []string) (map[string][]string, error) {
+	parsed := make(map[string][]string)
+	for _, f := range filters {
+		key, value := "", ""
+		if strings.HasPrefix(f, "name=") {
+			key, value = "name", strings.TrimPrefix(f, "name=")
+		} else if strings.HasPrefix(f, "!name=") {
+			key, value = "!name", strings.TrimPrefix(f, "!name=")
+		}
+		if value == "" {
+			return nil, fmt.Errorf("filter %q must be either name=<test> or !name=<test>", f)
+		}
+		parsed[key] = append(parsed[key], value)
+	}
+	return parsed, nil
+}
+
+// Run runs the test hooks of the release that match the filters and returns the results of the tests that were run
+func Run(cfg *action.Configuration, releaseName, namespace string, timeout time.Duration, filters []string) ([]Result, error) {
+	parsed, err := ParseFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	testing := action.NewReleaseTesting(cfg)
+	testing.Namespace = namespace
+	testing.Timeout = timeout
+	testing.Filters = parsed
+
+	// The hooks skipped by the filters keep the results of their previous run
+	started := time.Now()
+	rel, err := testing.Run(releaseName)
+	if rel == nil {
+		return nil, fmt.Errorf("failed to get the release %s: %w", releaseName, err)
+	}
+	return Results(rel, started), err
+}
+
+// Results returns the results of the test hooks of the release that were run since the given time
+func Results(rel *release.Release, since time.Time) []Result {
+	var results []Result
+	for _, hook := range rel.Hooks {
+		if !isTestHook(hook) || hook.LastRun.StartedAt.Time.Before(since) {
+			continue
+		}
+		results = append(results, Result{Name: hook.Name, Kind: hook.Kind, Phase: hook.LastRun.Phase})
+	}
+	return results
+}
+
+func isTestHook(hook *release.Hook) bool {
+	for _, event := range hook.Events {
+		if event == release.HookTest {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectLogs adds the end of the logs of the test pods to the results
+func CollectLogs(ctx context.Context, clientset kubernetes.Interface, namespace string, results []Result) {
+	tailLines := int64(LogTailLines)
+	for i := range results {
+		if results[i].Kind != "Pod" {
+			continue
+		}
+		stream, err := clientset.CoreV1().Pods(namespace).GetLogs(results[i].Name, &corev1.PodLogOptions{TailLines: &tailLines}).Stream(ctx)
+		if err != nil {
+			// The pod may have been removed by its hook delete policy
+			results[i].Logs = fmt.Sprintf("logs unavailable: %v", err)
+			continue
+		}
+		logs, err := ioutil.ReadAll(stream)
+		stream.Close()
+		if err != nil {
+			results[i].Logs = fmt.Sprintf("logs unavailable: %v", err)
+			continue
+		}
+		results[i].Logs = strings.TrimSpace(string(logs))
+	}
+}
+
+// RecordEvents records the results of the tests as events of the ApplicationGroup
+func RecordEvents(ctx context.Context, c client.Client, appGroupName, releaseName string, results []Result) error {
+	appGroup := &v1alpha1.ApplicationGroup{}
+	if err := c.Get(ctx, types.NamespacedName{Name: appGroupName}, appGroup); err != nil {
+		return fmt.Errorf("failed to get the applicationgroup %s: %w", appGroupName, err)
+	}
+	for _, result := range results {
+		if err := c.Create(ctx, newEvent(appGroup, releaseName, result)); err != nil {
+			return fmt.Errorf("failed to record the result of test %s: %w", result.Name, err)
+		}
+	}
+	return nil
+}
+
+func newEvent(appGroup *v1alpha1.ApplicationGroup, releaseName string, result Result) *corev1.Event {
+	eventType, reason := corev1.EventTypeNormal, TestSucceededReason
+	if result.Failed() {
+		eventType, reason = corev1.EventTypeWarning, TestFailedReason
+	}
+	message := fmt.Sprintf("test %s of release %s: %s", result.Name, releaseName, result.Phase)
+	if logs := result.Logs; logs != "" {
+		// Keep the end of the logs, where the failures usually are
+		if max := maxEventMessageLength - len(message) - len("\n..."); len(logs) > max {
+			logs = "..." + logs[len(logs)-max:]
+		}
+		message = fmt.Sprintf("%s\n%s", message, logs)
+	}
+
+	now := metav1.Now()
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%v.%x", appGroup.Name, now.UnixNano()),
+			// The events of cluster scoped objects are recorded in the default namespace
+			Namespace: metav1.NamespaceDefault,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:            "ApplicationGroup",
+			APIVersion:      v1alpha1.GroupVersion.String(),
+			Name:            appGroup.Name,
+			UID:             appGroup.UID,
+			ResourceVersion: appGroup.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: EventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+}
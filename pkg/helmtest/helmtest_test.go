@@ -0,0 +1,156 @@
+package helmtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name:    "Included And Excluded Tests",
+			filters: []string{"name=test-connection", "!name=test-slow", "name=test-api"},
+			want:    map[string][]string{"name": {"test-connection", "test-api"}, "!name": {"test-slow"}},
+		},
+		{
+			name:    "No Filters",
+			filters: nil,
+			want:    map[string][]string{},
+		},
+		{
+			name:    "Unknown Attribute",
+			filters: []string{"kind=Pod"},
+			wantErr: true,
+		},
+		{
+			name:    "Empty Test Name",
+			filters: []string{"name="},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilters(tt.filters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("ParseFilters() diff = %v", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestResults(t *testing.T) {
+	started := time.Now()
+	hook := func(name string, event release.HookEvent, phase release.HookPhase, startedAt time.Time) *release.Hook {
+		return &release.Hook{
+			Name:    name,
+			Kind:    "Pod",
+			Events:  []release.HookEvent{event},
+			LastRun: release.HookExecution{StartedAt: helmtime.Time{Time: startedAt}, Phase: phase},
+		}
+	}
+	rel := &release.Release{
+		Hooks: []*release.Hook{
+			hook("bookinfo-test-connection", release.HookTest, release.HookPhaseSucceeded, started.Add(time.Second)),
+			hook("bookinfo-test-api", release.HookTest, release.HookPhaseFailed, started.Add(2*time.Second)),
+			// Skipped by the filters, so it still has the result of its previous run
+			hook("bookinfo-test-slow", release.HookTest, release.HookPhaseFailed, started.Add(-time.Hour)),
+			hook("bookinfo-migrate", release.HookPreInstall, release.HookPhaseSucceeded, started.Add(time.Second)),
+		},
+	}
+	want := []Result{
+		{Name: "bookinfo-test-connection", Kind: "Pod", Phase: release.HookPhaseSucceeded},
+		{Name: "bookinfo-test-api", Kind: "Pod", Phase: release.HookPhaseFailed},
+	}
+	got := Results(rel, started)
+	if !cmp.Equal(got, want) {
+		t.Errorf("Results() diff = %v", cmp.Diff(got, want))
+	}
+	if got[0].Failed() || !got[1].Failed() {
+		t.Errorf("Failed() = %v, %v, want false, true", got[0].Failed(), got[1].Failed())
+	}
+}
+
+func TestCollectLogs(t *testing.T) {
+	clientset := kubefake.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bookinfo-test-connection", Namespace: "bookinfo"}})
+	results := []Result{
+		{Name: "bookinfo-test-connection", Kind: "Pod", Phase: release.HookPhaseFailed},
+		{Name: "bookinfo-test-job", Kind: "Job", Phase: release.HookPhaseSucceeded},
+	}
+	CollectLogs(context.Background(), clientset, "bookinfo", results)
+	if results[0].Logs == "" {
+		t.Errorf("CollectLogs() didn't collect the logs of the test pod")
+	}
+	if results[1].Logs != "" {
+		t.Errorf("CollectLogs() collected logs %q for a test job", results[1].Logs)
+	}
+}
+
+func TestRecordEvents(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	appGroup := &v1alpha1.ApplicationGroup{ObjectMeta: metav1.ObjectMeta{Name: "bookinfo", UID: "bookinfo-uid"}}
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(appGroup).Build()
+
+	logs := strings.Repeat("connection refused\n", 100) + "FAIL"
+	results := []Result{
+		{Name: "bookinfo-test-connection", Kind: "Pod", Phase: release.HookPhaseSucceeded},
+		{Name: "bookinfo-test-api", Kind: "Pod", Phase: release.HookPhaseFailed, Logs: logs},
+	}
+	if err := RecordEvents(ctx, k, "bookinfo", "bookinfo", results); err != nil {
+		t.Fatalf("RecordEvents() error = %v", err)
+	}
+
+	events := &corev1.EventList{}
+	if err := k.List(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+	reasons := make(map[string]corev1.Event)
+	for _, event := range events.Items {
+		if event.InvolvedObject.Name != "bookinfo" || event.InvolvedObject.UID != "bookinfo-uid" {
+			t.Errorf("RecordEvents() event involves %+v, want the applicationgroup", event.InvolvedObject)
+		}
+		reasons[event.Reason] = event
+	}
+	if event, ok := reasons[TestSucceededReason]; !ok || event.Type != corev1.EventTypeNormal {
+		t.Errorf("RecordEvents() events = %v, want a normal %s event", events.Items, TestSucceededReason)
+	}
+	event, ok := reasons[TestFailedReason]
+	if !ok || event.Type != corev1.EventTypeWarning {
+		t.Fatalf("RecordEvents() events = %v, want a warning %s event", events.Items, TestFailedReason)
+	}
+	// The end of the logs is kept within the event message length
+	if len(event.Message) > maxEventMessageLength || !strings.HasPrefix(event.Message, "test bookinfo-test-api of release bookinfo: Failed") || !strings.HasSuffix(event.Message, "FAIL") {
+		t.Errorf("RecordEvents() failed test message = %q", event.Message)
+	}
+
+	if err := RecordEvents(ctx, k, "unknown", "bookinfo", results); err == nil {
+		t.Errorf("RecordEvents() of an unknown applicationgroup must fail")
+	}
+}
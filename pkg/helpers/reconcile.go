@@ -5,15 +5,21 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/graph"
 	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/Azure/Orkestra/pkg/metrics"
 	"github.com/Azure/Orkestra/pkg/registry"
+	"github.com/Azure/Orkestra/pkg/revision"
 	"github.com/Azure/Orkestra/pkg/utils"
 	"github.com/Azure/Orkestra/pkg/workflow"
 	"github.com/go-logr/logr"
 	"github.com/jinzhu/copier"
+	"golang.org/x/sync/errgroup"
 	"helm.sh/helm/v3/pkg/chart"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -36,7 +42,16 @@ type ReconcileHelper struct {
 	Instance              *v1alpha1.ApplicationGroup
 	WorkflowClientBuilder *workflow.Builder
 	RegistryClient        *registry.Client
+	StagingStore          registry.StagingStore
 	StatusHelper          *StatusHelper
+	RevisionHistory       *revision.History
+
+	// APIReader reads the referenced Secrets and ConfigMaps from the API server, since the cache only
+	// holds their metadata. The client is used if it is not set
+	APIReader client.Reader
+
+	// DisableRemediation leaves the failed releases in place regardless of the remediation strategy
+	DisableRemediation bool
 
 	RegistryOptions RegistryClientOptions
 }
@@ -46,19 +61,55 @@ type RegistryClientOptions struct {
 	TargetDir               string
 	CleanupDownloadedCharts bool
 	stagingDirecotry        string
+
+	// MaxConcurrentCharts is the number of applications of the group whose charts are pulled and staged concurrently
+	MaxConcurrentCharts int
+}
+
+// DefaultMaxConcurrentCharts is the default number of applications whose charts are pulled and staged concurrently
+const DefaultMaxConcurrentCharts = 4
+
+// GetMaxConcurrentCharts returns the number of applications whose charts are pulled and staged concurrently,
+// which defaults to DefaultMaxConcurrentCharts
+func (o RegistryClientOptions) GetMaxConcurrentCharts() int {
+	if o.MaxConcurrentCharts > 0 {
+		return o.MaxConcurrentCharts
+	}
+	return DefaultMaxConcurrentCharts
+}
+
+// apiReader returns the reader of the referenced Secrets and ConfigMaps
+func (helper *ReconcileHelper) apiReader() client.Reader {
+	if helper.APIReader != nil {
+		return helper.APIReader
+	}
+	return helper.Client
 }
 
 func (helper *ReconcileHelper) CreateOrUpdate(ctx context.Context) error {
 	helper.Logger = helper.Logger.WithValues(v1alpha1.AppGroupNameKey, helper.Instance.Name)
 	helper.V(3).Info("Reconciling ApplicationGroup object")
 
-	if err := helper.reconcileApplications(); err != nil {
+	// The hash is taken before the undeclared subcharts are added to the spec,
+	// so that it matches the hash of the ApplicationGroup when it is resumed
+	specHash := helper.Instance.SpecHash()
+	if err := helper.reconcileApplications(ctx); errors.Is(err, meta.ErrChartSourceNotReady) {
+		// The application group keeps progressing until the source-controller has fetched the charts
+		return err
+	} else if errors.Is(err, meta.ErrChartVerificationFailed) {
+		helper.StatusHelper.MarkChartVerificationFailed(helper.Instance, err)
+		return fmt.Errorf("failed to verify the application charts with: %w", err)
+	} else if err != nil {
 		helper.StatusHelper.MarkChartPullFailed(helper.Instance, err)
 		return fmt.Errorf("failed to reconcile the applications with: %w", err)
 	}
 	// Generate the Workflow object to submit to Argo
-	forwardClient := helper.WorkflowClientBuilder.Build(v1alpha1.Forward, helper.Instance)
-	if err := workflow.Run(ctx, forwardClient); err != nil {
+	forwardClient := workflow.WithSpecHash(helper.WorkflowClientBuilder.Build(v1alpha1.Forward, helper.Instance), specHash)
+	if err := workflow.Run(ctx, forwardClient); errors.Is(err, graph.ErrInvalidGraph) {
+		// The forward workflow client has already marked the application group with the DAG validation failure
+		helper.StatusHelper.RecordDAGValidationFailed(helper.Instance, err)
+		return fmt.Errorf("failed to run forward workflow with: %w", err)
+	} else if err != nil {
 		helper.StatusHelper.MarkWorkflowTemplateGenerationFailed(helper.Instance, err)
 		return fmt.Errorf("failed to run forward workflow with: %w", err)
 	}
@@ -77,6 +128,99 @@ func (helper *ReconcileHelper) Rollback(ctx context.Context) error {
 	return nil
 }
 
+// RollbackToRevision suspends the forward workflow and rolls the application group back to the
+// revision requested by the RollbackToAnnotation. The request is cleared once it has been handled,
+// whether or not the rollback workflow could be submitted.
+func (helper *ReconcileHelper) RollbackToRevision(ctx context.Context) error {
+	err := helper.rollbackToRevision(ctx)
+	if err != nil {
+		helper.StatusHelper.MarkRollbackFailed(helper.Instance, err)
+	}
+
+	patch := client.MergeFrom(helper.Instance.DeepCopy())
+	delete(helper.Instance.Annotations, v1alpha1.RollbackToAnnotation)
+	if patchErr := helper.Patch(ctx, helper.Instance, patch); patchErr != nil {
+		helper.Error(patchErr, "failed to remove the rollback request from the application group")
+		return patchErr
+	}
+	return err
+}
+
+func (helper *ReconcileHelper) rollbackToRevision(ctx context.Context) error {
+	// Validate the requested revision before suspending the forward workflow
+	if _, err := helper.RevisionHistory.RollbackSpec(ctx, helper.Instance); err != nil {
+		return err
+	}
+	generation, _, _ := helper.Instance.GetRollbackTo()
+	helper.Info("Rolling back to the requested revision", "generation", generation)
+
+	forwardClient := helper.WorkflowClientBuilder.Build(v1alpha1.Forward, helper.Instance)
+	if err := workflow.Suspend(ctx, forwardClient); err != nil {
+		return fmt.Errorf("failed to suspend forward workflow: %w", err)
+	}
+	if err := helper.Rollback(ctx); err != nil {
+		return err
+	}
+	helper.StatusHelper.MarkRollbackProgressing(helper.Instance, generation)
+	return nil
+}
+
+// Approve approves the pending approval gates of the forward workflow requested by the ApproveAnnotation.
+// The request is cleared once it has been handled, the gates that were not pending are reported in an event.
+func (helper *ReconcileHelper) Approve(ctx context.Context) error {
+	approvals := helper.Instance.GetApprovals()
+	forwardClient := helper.WorkflowClientBuilder.Build(v1alpha1.Forward, helper.Instance)
+	notPending, err := workflow.Approve(ctx, forwardClient, approvals)
+	if err != nil {
+		return err
+	}
+	helper.StatusHelper.MarkApproved(helper.Instance, approvals, notPending)
+
+	patch := client.MergeFrom(helper.Instance.DeepCopy())
+	delete(helper.Instance.Annotations, v1alpha1.ApproveAnnotation)
+	if err := helper.Patch(ctx, helper.Instance, patch); err != nil {
+		helper.Error(err, "failed to remove the approval request from the application group")
+		return err
+	}
+	return nil
+}
+
+// Suspend suspends the in-flight forward and rollback workflows of the ApplicationGroup
+func (helper *ReconcileHelper) Suspend(ctx context.Context) error {
+	for _, wfType := range []v1alpha1.WorkflowType{v1alpha1.Forward, v1alpha1.Rollback} {
+		if err := workflow.Suspend(ctx, helper.WorkflowClientBuilder.Build(wfType, helper.Instance)); err != nil {
+			return fmt.Errorf("failed to suspend %s workflow: %w", wfType, err)
+		}
+	}
+	return nil
+}
+
+// Resume resumes the workflows of the last observed generation instead of regenerating them when the spec
+// of the ApplicationGroup only changed by clearing the suspend flag. It returns false if the forward workflow
+// doesn't exist, failed or rolls out another spec, in which case the ApplicationGroup has to be rolled out again.
+func (helper *ReconcileHelper) Resume(ctx context.Context) (bool, error) {
+	forwardClient := helper.WorkflowClientBuilder.Build(v1alpha1.Forward, helper.Instance)
+	forwardWorkflow, err := workflow.GetWorkflow(ctx, forwardClient)
+	if client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("failed to get the forward workflow: %w", err)
+	} else if err != nil || forwardWorkflow.GetAnnotations()[v1alpha1.SpecHashAnnotation] != helper.Instance.SpecHash() ||
+		workflow.ToConditionReason(forwardWorkflow.Status.Phase) == meta.FailedReason {
+		return false, nil
+	}
+
+	generation := helper.Instance.Status.ObservedGeneration
+	resumed, err := workflow.Adopt(ctx, forwardClient, generation)
+	if err != nil || !resumed {
+		return false, err
+	}
+	helper.Info("Resuming the workflows", "generation", generation)
+	// The rollback workflow is only resumed if it was suspended along with the forward workflow
+	if _, err := workflow.Adopt(ctx, helper.WorkflowClientBuilder.Build(v1alpha1.Rollback, helper.Instance), generation); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (helper *ReconcileHelper) Reverse(ctx context.Context) error {
 	reverseClient := helper.WorkflowClientBuilder.Build(v1alpha1.Reverse, helper.Instance)
 	forwardClient := helper.WorkflowClientBuilder.Build(v1alpha1.Forward, helper.Instance)
@@ -98,187 +242,364 @@ func (helper *ReconcileHelper) Reverse(ctx context.Context) error {
 	return nil
 }
 
-func (helper *ReconcileHelper) reconcileApplications() error {
-	// Init the application status every time we re-reconcile the applications
-	initAppStatus(helper.Instance)
-
-	// Pull and conditionally stage application & dependency charts
-	for i, application := range helper.Instance.Spec.Applications {
-		ll := helper.WithValues("application", application.Name)
-		ll.V(3).Info("performing chart actions")
+// Remediate applies the remediation strategy of the application group once the workflow of the given type
+// failed. The remediation of the forward workflow is applied once per generation, while failed remediation
+// workflows are resubmitted until the remediation attempts run out.
+func (helper *ReconcileHelper) Remediate(ctx context.Context, wfType v1alpha1.WorkflowType) error {
+	instance := helper.Instance
+	remediation := meta.GetResourceCondition(instance, meta.RemediationCondition)
+	if wfType == v1alpha1.Forward {
+		if remediation != nil {
+			// The failed generation has already been remediated
+			return nil
+		}
+	} else {
+		// Only the workflows submitted by a pending remediation are resubmitted,
+		// other rollback workflows such as the on-demand rollbacks are left alone
+		if instance.Status.RemediationAttempts == 0 || remediation == nil || remediation.Status != metav1.ConditionUnknown {
+			return nil
+		}
+		if instance.Status.RemediationAttempts >= instance.GetRemediationMaxAttempts() {
+			helper.StatusHelper.MarkRemediationFailed(instance)
+			return nil
+		}
+	}
 
-		repoCfg, err := registry.GetHelmRepoConfig(&application, helper.Client)
+	if helper.DisableRemediation {
+		helper.StatusHelper.MarkRemediationDisabled(instance, "remediation is disabled by the controller")
+		return nil
+	}
+	reason, strategy := meta.ReversingReason, v1alpha1.RemediationReverse
+	switch instance.GetRemediationStrategy() {
+	case v1alpha1.RemediationNone:
+		helper.StatusHelper.MarkRemediationDisabled(instance, "remediation strategy is none")
+		return nil
+	case v1alpha1.RemediationPause:
+		helper.StatusHelper.MarkRemediationPaused(instance)
+		return nil
+	case v1alpha1.RemediationReverse:
+		if err := helper.Reverse(ctx); err != nil {
+			return fmt.Errorf("failed to generate the reverse workflow: %w", err)
+		}
+	default:
+		hasRevision, err := helper.RevisionHistory.HasRevision(ctx, instance)
 		if err != nil {
-			return fmt.Errorf("failed to get repo configuration for repo at URL %s: %w", application.Spec.Chart.URL, err)
+			return fmt.Errorf("failed to look up the revision history: %w", err)
 		}
-
-		if err := helper.RegistryClient.AddRepo(repoCfg); err != nil {
-			return fmt.Errorf("failed to add helm repo at URL %s: %w", application.Spec.Chart.URL, err)
+		if hasRevision {
+			if err := helper.Rollback(ctx); err != nil {
+				return fmt.Errorf("failed to generate the rollback workflow: %w", err)
+			}
+			reason, strategy = meta.RollingBackReason, v1alpha1.RemediationRollback
+		} else if err := helper.Reverse(ctx); err != nil {
+			return fmt.Errorf("failed to generate the reverse workflow: %w", err)
 		}
+	}
+	instance.Status.RemediationAttempts++
+	helper.StatusHelper.MarkRemediating(instance, reason)
+	metrics.RecordRemediation(string(strategy))
+	return nil
+}
 
-		name := application.Spec.Chart.Name
-		version := application.Spec.Chart.Version
-		repoKey := application.Name
+// RemediationSucceeded marks the pending remediation of the application group as succeeded
+func (helper *ReconcileHelper) RemediationSucceeded() {
+	remediation := meta.GetResourceCondition(helper.Instance, meta.RemediationCondition)
+	if helper.Instance.Status.RemediationAttempts > 0 && remediation != nil && remediation.Status == metav1.ConditionUnknown {
+		helper.StatusHelper.MarkRemediationSucceeded(helper.Instance)
+	}
+}
 
-		fpath, appCh, err := helper.RegistryClient.PullChart(ll, repoKey, name, version)
-		defer func() {
-			if helper.RegistryOptions.CleanupDownloadedCharts {
-				os.Remove(fpath)
+func (helper *ReconcileHelper) reconcileApplications(ctx context.Context) error {
+	// Init the application status every time we re-reconcile the applications
+	initAppStatus(helper.Instance)
+
+	// Pull and conditionally stage application & dependency charts. Every application only updates its own
+	// entries of the spec and the status, so that the applications can be reconciled concurrently
+	pulledCharts := make([]string, len(helper.Instance.Spec.Applications))
+	defer func() {
+		if helper.RegistryOptions.CleanupDownloadedCharts {
+			for _, fpath := range pulledCharts {
+				if fpath != "" {
+					os.Remove(fpath)
+				}
 			}
-		}()
-		if err != nil || appCh == nil {
-			return fmt.Errorf("failed to pull application chart %s/%s:%s: %w", repoKey, name, version, err)
 		}
+	}()
+
+	g, ctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, helper.RegistryOptions.GetMaxConcurrentCharts())
+	for i := range helper.Instance.Spec.Applications {
+		i := i
+		g.Go(func() error {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			var err error
+			pulledCharts[i], err = helper.reconcileApplication(ctx, i)
+			return err
+		})
+	}
+	return g.Wait()
+}
 
-		var mustStageSubcharts bool
+// reconcileApplication pulls the chart of the application at index i, stages its subcharts and the
+// modified application chart, and returns the path of the pulled chart
+func (helper *ReconcileHelper) reconcileApplication(ctx context.Context, i int) (string, error) {
+	application := helper.Instance.Spec.Applications[i]
+	ll := helper.WithValues("application", application.Name)
+	ll.V(3).Info("performing chart actions")
 
-		if application.Spec.Subcharts != nil && len(application.Spec.Subcharts) > 0 && appCh.Dependencies() != nil {
-			mustStageSubcharts = true
-		}
+	fpath, appCh, err := helper.pullApplicationChart(ctx, ll, &application)
+	if err != nil {
+		return fpath, err
+	}
+	// Record the version that the chart is staged with, which is the version
+	// of the Chart.yaml for the charts from Git repositories and buckets
+	helper.Instance.Status.Applications[i].ChartStatus.Version = appCh.Metadata.Version
+
+	// The charts of every application are packaged in their own directory since the applications
+	// of the group may use the same chart and are staged concurrently
+	stagingDir := helper.getStagingDirectory(application.Name)
+	if err := os.MkdirAll(stagingDir, os.ModePerm); err != nil {
+		return fpath, fmt.Errorf("failed to create the staging directory %s: %w", stagingDir, err)
+	}
 
-		if mustStageSubcharts {
-			// take account of all embedded subcharts found in the application chart
-			embeddedSubcharts := make(map[string]bool)
-			for _, d := range appCh.Dependencies() {
-				embeddedSubcharts[d.Name()] = true
-			}
+	var mustStageSubcharts bool
 
-			// Remove all explicit subchart entries from tracking map
-			for _, d := range helper.Instance.Spec.Applications[i].Spec.Subcharts {
-				delete(embeddedSubcharts, d.Name)
-			}
+	if application.Spec.Subcharts != nil && len(application.Spec.Subcharts) > 0 && appCh.Dependencies() != nil {
+		mustStageSubcharts = true
+	}
 
-			// Use the remaining set of dependencies that are not explicitly declared and
-			// add them to the groups application spec's subcharts list
-			for name := range embeddedSubcharts {
-				helper.Instance.Spec.Applications[i].Spec.Subcharts = append(helper.Instance.Spec.Applications[i].Spec.Subcharts, v1alpha1.DAG{Name: name})
-			}
+	if mustStageSubcharts {
+		// take account of all embedded subcharts found in the application chart
+		embeddedSubcharts := make(map[string]bool)
+		for _, d := range appCh.Dependencies() {
+			embeddedSubcharts[d.Name()] = true
+		}
 
-			stagingRepoName := helper.RegistryOptions.StagingRepoName
-			// Package and push all application subcharts staging registry
-			for _, sc := range appCh.Dependencies() {
-				chartStatus := v1alpha1.ChartStatus{
-					Version: sc.Metadata.Version,
-					Staged:  false,
-					Error:   "",
-				}
+		// Remove all explicit subchart entries from tracking map
+		for _, d := range helper.Instance.Spec.Applications[i].Spec.Subcharts {
+			delete(embeddedSubcharts, d.Name)
+		}
 
-				// copy the subchart
-				scc := &chart.Chart{}
-				_ = copier.Copy(scc, sc)
+		// Use the remaining set of dependencies that are not explicitly declared and
+		// add them to the groups application spec's subcharts list
+		for name := range embeddedSubcharts {
+			helper.Instance.Spec.Applications[i].Spec.Subcharts = append(helper.Instance.Spec.Applications[i].Spec.Subcharts, v1alpha1.DAG{Name: name})
+		}
 
-				if err := scc.Validate(); err != nil {
-					err = fmt.Errorf("failed to validate application subchart for staging registry: %w", err)
-					chartStatus.Error = err.Error()
-					helper.Instance.Status.Applications[i].Subcharts[sc.Name()] = chartStatus
-					return err
-				}
+		// Package and push all application subcharts staging registry
+		for _, sc := range appCh.Dependencies() {
+			chartStatus := v1alpha1.ChartStatus{
+				Version: sc.Metadata.Version,
+				Staged:  false,
+				Error:   "",
+			}
 
-				// Copy over all non yaml files from parent chart templates to subchart templates
-				for _, f := range appCh.Templates {
-					if !utils.IsFileYaml(f.Name) {
-						t := &chart.File{}
-						_ = copier.Copy(t, f)
-						t.Name = utils.AddAppChartNameToFile(t.Name, appCh.Name())
-						scc.Templates = append(scc.Templates, t)
-					}
-				}
+			// copy the subchart
+			scc := &chart.Chart{}
+			_ = copier.Copy(scc, sc)
 
-				scc.Metadata.Name = utils.GetSubchartName(appCh.Metadata.Name, scc.Metadata.Name)
-				path, err := registry.SaveChartPackage(scc, helper.getStagingDirectory())
-				if err != nil {
-					err = fmt.Errorf("failed to save subchart package as tgz at location %s: %w", path, err)
-					chartStatus.Error = err.Error()
-					helper.Instance.Status.Applications[i].Subcharts[sc.Name()] = chartStatus
-					return err
-				}
+			if err := scc.Validate(); err != nil {
+				err = fmt.Errorf("failed to validate application subchart for staging registry: %w", err)
+				chartStatus.Error = err.Error()
+				helper.Instance.Status.Applications[i].Subcharts[sc.Name()] = chartStatus
+				return fpath, err
+			}
 
-				err = helper.RegistryClient.PushChart(ll, stagingRepoName, path, scc)
-				if err != nil {
-					err = fmt.Errorf("failed to push application subchart to staging registry: %w", err)
-					chartStatus.Error = err.Error()
-					helper.Instance.Status.Applications[i].Subcharts[sc.Name()] = chartStatus
-					return err
+			// Copy over all non yaml files from parent chart templates to subchart templates
+			for _, f := range appCh.Templates {
+				if !utils.IsFileYaml(f.Name) {
+					t := &chart.File{}
+					_ = copier.Copy(t, f)
+					t.Name = utils.AddAppChartNameToFile(t.Name, appCh.Name())
+					scc.Templates = append(scc.Templates, t)
 				}
+			}
 
-				chartStatus.Staged = true
+			scc.Metadata.Name = utils.GetSubchartName(appCh.Metadata.Name, scc.Metadata.Name)
+			path, err := registry.SaveChartPackage(scc, stagingDir)
+			if err != nil {
+				err = fmt.Errorf("failed to save subchart package as tgz at location %s: %w", path, err)
+				chartStatus.Error = err.Error()
+				helper.Instance.Status.Applications[i].Subcharts[sc.Name()] = chartStatus
+				return fpath, err
+			}
 
+			err = registry.PushChart(ctx, ll, helper.StagingStore, path, scc)
+			if err != nil {
+				err = fmt.Errorf("failed to push application subchart to staging registry: %w", err)
+				chartStatus.Error = err.Error()
 				helper.Instance.Status.Applications[i].Subcharts[sc.Name()] = chartStatus
+				return fpath, err
 			}
+
+			chartStatus.Staged = true
+
+			helper.Instance.Status.Applications[i].Subcharts[sc.Name()] = chartStatus
 		}
+	}
 
-		// Unset dependencies by disabling them.
-		// Using appCh.SetDependencies() does not cut it since some charts rely on subcharts for tpl helpers
-		// provided in the charts directory.
-		// IMPORTANT: This expects charts to follow best practices to allow enabling and disabling subcharts
-		// See: https://helm.sh/docs/topics/charts/ #Chart Dependencies
-		if mustStageSubcharts {
-			for _, dep := range appCh.Metadata.Dependencies {
-				// Disable subchart through metadata
-				dep.Enabled = false
-				// Precautionary - overwrite values with subcharts disabled
-				appCh.Values[dep.Name] = map[string]interface{}{
-					"enabled": false,
-				}
+	// Unset dependencies by disabling them.
+	// Using appCh.SetDependencies() does not cut it since some charts rely on subcharts for tpl helpers
+	// provided in the charts directory.
+	// IMPORTANT: This expects charts to follow best practices to allow enabling and disabling subcharts
+	// See: https://helm.sh/docs/topics/charts/ #Chart Dependencies
+	if mustStageSubcharts {
+		for _, dep := range appCh.Metadata.Dependencies {
+			// Disable subchart through metadata
+			dep.Enabled = false
+			// Precautionary - overwrite values with subcharts disabled
+			appCh.Values[dep.Name] = map[string]interface{}{
+				"enabled": false,
 			}
 		}
+	}
 
-		templateHasYAML, err := utils.TemplateContainsYaml(appCh)
-		if err != nil {
-			err = fmt.Errorf("chart templates directory yaml check failed: %w", err)
-			helper.Instance.Status.Applications[i].ChartStatus.Error = err.Error()
-			return err
-		}
+	templateHasYAML, err := utils.TemplateContainsYaml(appCh)
+	if err != nil {
+		err = fmt.Errorf("chart templates directory yaml check failed: %w", err)
+		helper.Instance.Status.Applications[i].ChartStatus.Error = err.Error()
+		return fpath, err
+	}
 
-		// If the parent chart doesnt contain any templates and all subcharts (if any) have been disabled we must create a dummy yaml to circumvent https://github.com/helm/helm/issues/4670
-		if appCh.Templates == nil || len(appCh.Templates) == 0 || !templateHasYAML {
-			if appCh.Templates == nil {
-				appCh.Templates = make([]*chart.File, 0)
-			}
-			appCh.Templates = append(appCh.Templates, &chart.File{
-				Name: dummyConfigmapYAMLName,
-				Data: []byte(dummyConfigmapYAMLSpec),
-			})
+	// If the parent chart doesnt contain any templates and all subcharts (if any) have been disabled we must create a dummy yaml to circumvent https://github.com/helm/helm/issues/4670
+	if appCh.Templates == nil || len(appCh.Templates) == 0 || !templateHasYAML {
+		if appCh.Templates == nil {
+			appCh.Templates = make([]*chart.File, 0)
 		}
+		appCh.Templates = append(appCh.Templates, &chart.File{
+			Name: dummyConfigmapYAMLName,
+			Data: []byte(dummyConfigmapYAMLSpec),
+		})
+	}
 
-		if err := appCh.Validate(); err != nil {
-			err = fmt.Errorf("failed to validate application chart for staging registry: %w", err)
-			helper.Instance.Status.Applications[i].ChartStatus.Error = err.Error()
-			return err
+	if err := appCh.Validate(); err != nil {
+		err = fmt.Errorf("failed to validate application chart for staging registry: %w", err)
+		helper.Instance.Status.Applications[i].ChartStatus.Error = err.Error()
+		return fpath, err
+	}
+
+	appCh.Metadata.Name = utils.ConvertToDNS1123(appCh.Metadata.Name)
+
+	chartPath, err := registry.SaveChartPackage(appCh, stagingDir)
+	if err != nil {
+		err = fmt.Errorf("failed to save modified app chart to filesystem: %w", err)
+		helper.Instance.Status.Applications[i].ChartStatus.Error = err.Error()
+		return fpath, err
+	}
+
+	// Replace existing chart with modified chart
+	err = registry.PushChart(ctx, ll, helper.StagingStore, chartPath, appCh)
+	defer func() {
+		if helper.RegistryOptions.CleanupDownloadedCharts {
+			os.Remove(chartPath)
 		}
+	}()
+	if err != nil {
+		err = fmt.Errorf("failed to push modified application chart to staging registry: %w", err)
+		helper.Instance.Status.Applications[i].ChartStatus.Error = err.Error()
+		return fpath, err
+	}
+
+	helper.Instance.Status.Applications[i].ChartStatus.Staged = true
+	return fpath, nil
+}
 
-		appCh.Metadata.Name = utils.ConvertToDNS1123(appCh.Metadata.Name)
+// pullApplicationChart pulls the application chart from its helm repository or OCI registry,
+// or fetches it from its Git repository or bucket through a Flux source
+func (helper *ReconcileHelper) pullApplicationChart(ctx context.Context, ll logr.Logger, application *v1alpha1.Application) (string, *chart.Chart, error) {
+	name := application.Spec.Chart.Name
+	version := application.Spec.Chart.Version
+	repoKey := helper.repoKey(application)
 
-		_, err = registry.SaveChartPackage(appCh, helper.getStagingDirectory())
+	if kind := application.Spec.Chart.GetSourceKind(); kind != "" {
+		fpath, appCh, err := helper.RegistryClient.PullSourceChart(ctx, ll, helper.Client, helper.Instance, application, workflow.GetNamespace())
 		if err != nil {
-			err = fmt.Errorf("failed to save modified app chart to filesystem: %w", err)
-			helper.Instance.Status.Applications[i].ChartStatus.Error = err.Error()
-			return err
+			return fpath, nil, fmt.Errorf("failed to pull application chart %s from %s at URL %s: %w", name, kind, application.Spec.Chart.URL, err)
 		}
+		return fpath, appCh, nil
+	}
 
-		// Replace existing chart with modified chart
-		chartPath := helper.getChartPath(application.Spec.Chart.Name, appCh.Metadata.Version)
-		err = helper.RegistryClient.PushChart(ll, helper.RegistryOptions.StagingRepoName, chartPath, appCh)
-		defer func() {
-			if helper.RegistryOptions.CleanupDownloadedCharts {
-				os.Remove(chartPath)
-			}
-		}()
+	version, err := helper.resolveChartVersion(ctx, application)
+	if err != nil {
+		return "", nil, err
+	}
+	if version != application.Spec.Chart.Version {
+		ll.V(1).Info("resolved application chart version", "chart-name", name, "chart-version", application.Spec.Chart.Version, "resolved-version", version)
+	}
+
+	fpath, appCh, err := helper.RegistryClient.PullChart(ctx, ll, repoKey, name, version)
+	if err != nil || appCh == nil {
+		return fpath, nil, fmt.Errorf("failed to pull application chart %s/%s:%s: %w", repoKey, name, version, err)
+	}
+
+	// Verify the chart before it is repackaged and staged
+	if provider := application.Spec.Chart.GetVerificationProvider(); provider != "" {
+		key, err := registry.GetVerificationKey(ctx, application.Spec.Chart.Verify, provider, workflow.GetNamespace(), helper.apiReader())
 		if err != nil {
-			err = fmt.Errorf("failed to push modified application chart to staging registry: %w", err)
-			helper.Instance.Status.Applications[i].ChartStatus.Error = err.Error()
-			return err
+			return fpath, nil, err
+		}
+		if err := helper.RegistryClient.VerifyChart(ctx, ll, repoKey, name, version, fpath, provider, key); err != nil {
+			return fpath, nil, err
 		}
+	}
+	return fpath, appCh, nil
+}
 
-		helper.Instance.Status.Applications[i].ChartStatus.Staged = true
+// resolveChartVersion adds the helm repository or OCI registry of the application chart and
+// returns the latest chart version that satisfies the version of the chart reference
+func (helper *ReconcileHelper) resolveChartVersion(ctx context.Context, application *v1alpha1.Application) (string, error) {
+	repoCfg, err := registry.GetHelmRepoConfig(application, workflow.GetNamespace(), helper.apiReader())
+	if err != nil {
+		return "", fmt.Errorf("failed to get repo configuration for repo at URL %s: %w", application.Spec.Chart.URL, err)
 	}
-	return nil
+	repoCfg.Name = helper.repoKey(application)
+
+	if err := helper.RegistryClient.AddRepo(repoCfg); err != nil {
+		return "", fmt.Errorf("failed to add helm repo at URL %s: %w", application.Spec.Chart.URL, err)
+	}
+
+	version, err := helper.RegistryClient.ResolveVersion(ctx, repoCfg.Name, application.Spec.Chart.Name, application.Spec.Chart.Version)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve application chart %s version %q: %w", application.Spec.Chart.Name, application.Spec.Chart.Version, err)
+	}
+	return version, nil
+}
+
+// ChartUpdates returns the names of the applications whose chart version range now resolves
+// to a different version than the one that was last deployed
+func (helper *ReconcileHelper) ChartUpdates(ctx context.Context) ([]string, error) {
+	var updates []string
+	for _, application := range helper.Instance.Spec.Applications {
+		if application.Spec.Chart == nil || application.Spec.Chart.GetSourceKind() != "" || !application.Spec.Chart.IsVersionRange() {
+			continue
+		}
+		appStatus := helper.Instance.GetApplicationStatus(application.Name)
+		if appStatus == nil {
+			continue
+		}
+		version, err := helper.resolveChartVersion(ctx, &application)
+		if err != nil {
+			return nil, err
+		}
+		if deployed := appStatus.ChartStatus.Version; version != deployed {
+			helper.V(1).Info("found chart update", "application", application.Name, "chart-version", deployed, "resolved-version", version)
+			updates = append(updates, application.Name)
+		}
+	}
+	return updates, nil
 }
 
-func (helper *ReconcileHelper) getStagingDirectory() string {
-	return helper.RegistryOptions.TargetDir + "/" + helper.RegistryOptions.StagingRepoName
+// repoKey returns the key the registry of the application chart is added to the registry client under
+func (helper *ReconcileHelper) repoKey(application *v1alpha1.Application) string {
+	return registry.RepoKey(helper.Instance.Name, application.Name)
 }
 
-func (helper *ReconcileHelper) getChartPath(name, version string) string {
-	return helper.getStagingDirectory() + "/" + utils.ConvertToDNS1123(name) + "-" + version + ".tgz"
+// getStagingDirectory returns the directory that the charts of the application are packaged in before they are staged
+func (helper *ReconcileHelper) getStagingDirectory(appName string) string {
+	return filepath.Join(helper.RegistryOptions.TargetDir, helper.RegistryOptions.StagingRepoName, helper.Instance.Name, appName)
 }
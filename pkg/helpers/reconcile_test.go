@@ -0,0 +1,131 @@
+package helpers
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/workflow"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileHelper_Resume(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := v1alpha13.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	// The ApplicationGroup observed generation 2 and was resumed in generation 3
+	appGroup := func(url string) *v1alpha1.ApplicationGroup {
+		g := &v1alpha1.ApplicationGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "bookinfo", Generation: 3},
+			Status:     v1alpha1.ApplicationGroupStatus{ObservedGeneration: 2},
+		}
+		app := v1alpha1.Application{DAG: v1alpha1.DAG{Name: "bookinfo"}}
+		app.Spec.Chart = &v1alpha1.ChartRef{URL: url}
+		g.Spec.Applications = []v1alpha1.Application{app}
+		return g
+	}
+	suspended := appGroup("https://charts.example.com")
+	suspended.Spec.Suspend = true
+
+	suspend := true
+	newWorkflow := func(name string, generation int, specHash string, phase v1alpha13.WorkflowPhase) *v1alpha13.Workflow {
+		return &v1alpha13.Workflow{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "orkestra",
+				Labels:      map[string]string{v1alpha1.WorkflowAppGroupGenerationLabel: strconv.Itoa(generation)},
+				Annotations: map[string]string{v1alpha1.SpecHashAnnotation: specHash},
+			},
+			Spec:   v1alpha13.WorkflowSpec{Suspend: &suspend},
+			Status: v1alpha13.WorkflowStatus{Phase: phase},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		appGroup       *v1alpha1.ApplicationGroup
+		forward        *v1alpha13.Workflow
+		rollback       *v1alpha13.Workflow
+		want           bool
+		wantGeneration map[string]string
+	}{
+		{
+			name:           "Suspend Flag Cleared",
+			appGroup:       appGroup("https://charts.example.com"),
+			forward:        newWorkflow("bookinfo", 2, suspended.SpecHash(), v1alpha13.WorkflowRunning),
+			rollback:       newWorkflow("bookinfo-rollback", 2, "", v1alpha13.WorkflowRunning),
+			want:           true,
+			wantGeneration: map[string]string{"bookinfo": "3", "bookinfo-rollback": "3"},
+		},
+		{
+			name:           "Rollback Workflow Of A Previous Generation",
+			appGroup:       appGroup("https://charts.example.com"),
+			forward:        newWorkflow("bookinfo", 2, suspended.SpecHash(), v1alpha13.WorkflowRunning),
+			rollback:       newWorkflow("bookinfo-rollback", 1, "", v1alpha13.WorkflowRunning),
+			want:           true,
+			wantGeneration: map[string]string{"bookinfo": "3", "bookinfo-rollback": "1"},
+		},
+		{
+			name:           "Spec Changed While Suspended",
+			appGroup:       appGroup("https://other.example.com"),
+			forward:        newWorkflow("bookinfo", 2, suspended.SpecHash(), v1alpha13.WorkflowRunning),
+			wantGeneration: map[string]string{"bookinfo": "2"},
+		},
+		{
+			name:           "Failed Forward Workflow",
+			appGroup:       appGroup("https://charts.example.com"),
+			forward:        newWorkflow("bookinfo", 2, suspended.SpecHash(), v1alpha13.WorkflowFailed),
+			wantGeneration: map[string]string{"bookinfo": "2"},
+		},
+		{
+			name:     "No Forward Workflow",
+			appGroup: appGroup("https://charts.example.com"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			for _, wf := range []*v1alpha13.Workflow{tt.forward, tt.rollback} {
+				if wf != nil {
+					builder = builder.WithObjects(wf)
+				}
+			}
+			k := builder.Build()
+			helper := &ReconcileHelper{
+				Client:                k,
+				Logger:                logr.Discard(),
+				Instance:              tt.appGroup,
+				WorkflowClientBuilder: workflow.NewBuilder(k, logr.Discard()).InNamespace("orkestra"),
+			}
+
+			got, err := helper.Resume(ctx)
+			if err != nil {
+				t.Fatalf("Resume() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resume() = %v, want %v", got, tt.want)
+			}
+			for name, wantGeneration := range tt.wantGeneration {
+				wf := &v1alpha13.Workflow{}
+				if err := k.Get(ctx, types.NamespacedName{Namespace: "orkestra", Name: name}, wf); err != nil {
+					t.Fatal(err)
+				}
+				if generation := wf.Labels[v1alpha1.WorkflowAppGroupGenerationLabel]; generation != wantGeneration {
+					t.Errorf("Resume() workflow %s generation = %v, want %v", name, generation, wantGeneration)
+				}
+				if resumed := wf.Spec.Suspend == nil; resumed != (wantGeneration == "3") {
+					t.Errorf("Resume() workflow %s suspend = %v", name, wf.Spec.Suspend)
+				}
+			}
+		})
+	}
+}
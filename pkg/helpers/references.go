@@ -0,0 +1,128 @@
+package helpers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/executor"
+	"github.com/Azure/Orkestra/pkg/registry"
+	"github.com/Azure/Orkestra/pkg/workflow"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SecretReferences returns the namespace/name keys of the Secrets read by the controller for the applications of the
+// ApplicationGroup, i.e. the auth secrets of the helm repositories and OCI registries and the chart verification secrets.
+// The secrets without a namespace are read in the default namespace.
+func SecretReferences(appGroup *v1alpha1.ApplicationGroup, defaultNamespace string) []string {
+	var keys []string
+	for _, application := range appGroup.Spec.Applications {
+		chartRef := application.Spec.Chart
+		if chartRef == nil {
+			continue
+		}
+		// The secrets of the Git repositories and buckets are read by the Flux source-controller
+		if chartRef.AuthSecretRef != nil && chartRef.GetSourceKind() == "" {
+			keys = append(keys, registry.SecretKey(chartRef.AuthSecretRef, defaultNamespace).String())
+		}
+		if chartRef.Verify != nil && chartRef.Verify.SecretRef != nil {
+			keys = append(keys, registry.SecretKey(chartRef.Verify.SecretRef, defaultNamespace).String())
+		}
+	}
+	return uniqueKeys(keys)
+}
+
+// ConfigMapReferences returns the namespace/name keys of the ConfigMaps referenced by the keptn executors of the
+// applications of the ApplicationGroup. The ConfigMaps without a namespace are read in the default namespace.
+func ConfigMapReferences(appGroup *v1alpha1.ApplicationGroup, defaultNamespace string) []string {
+	var keys []string
+	for _, application := range appGroup.Spec.Applications {
+		for _, e := range application.Spec.Workflow {
+			if e.Type != v1alpha1.KeptnExecutor || e.Params == nil {
+				continue
+			}
+			params := &executor.KeptnParameters{}
+			if err := json.Unmarshal(e.Params.Raw, params); err != nil || params.ConfigMapRef.Name == "" {
+				continue
+			}
+			key := types.NamespacedName{Namespace: params.ConfigMapRef.Namespace, Name: params.ConfigMapRef.Name}
+			if key.Namespace == "" {
+				key.Namespace = defaultNamespace
+			}
+			keys = append(keys, key.String())
+		}
+	}
+	return uniqueKeys(keys)
+}
+
+// ReferencesHash returns the hash of the data of the Secrets and ConfigMaps referenced by the ApplicationGroup,
+// which are read in the workflow namespace by default, so that a change to any of them rolls the ApplicationGroup
+// out again. Missing objects are part of the hash as well, so that creating them later is also picked up.
+// The objects are read with the API reader, since only their metadata is cached.
+func (helper *ReconcileHelper) ReferencesHash(ctx context.Context) (string, error) {
+	defaultNamespace := workflow.GetNamespace()
+	h := sha256.New()
+	for _, ref := range []struct {
+		keys []string
+		obj  func() client.Object
+	}{
+		{SecretReferences(helper.Instance, defaultNamespace), func() client.Object { return &corev1.Secret{} }},
+		{ConfigMapReferences(helper.Instance, defaultNamespace), func() client.Object { return &corev1.ConfigMap{} }},
+	} {
+		for _, key := range ref.keys {
+			obj := ref.obj()
+			found := true
+			if err := helper.apiReader().Get(ctx, namespacedName(key), obj); kerrors.IsNotFound(err) {
+				found = false
+			} else if err != nil {
+				return "", fmt.Errorf("failed to get the referenced %T %s: %w", obj, key, err)
+			}
+			data, err := referencedData(obj)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "%T/%s found=%t data=%s\n", obj, key, found, data)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// referencedData returns the JSON encoded data of the Secret or ConfigMap, with the keys in sorted order
+func referencedData(obj client.Object) ([]byte, error) {
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		return json.Marshal(o.Data)
+	case *corev1.ConfigMap:
+		return json.Marshal([]interface{}{o.Data, o.BinaryData})
+	default:
+		return nil, fmt.Errorf("unexpected referenced object %T", obj)
+	}
+}
+
+// namespacedName parses the namespace/name key
+func namespacedName(key string) types.NamespacedName {
+	parts := strings.SplitN(key, string(types.Separator), 2)
+	if len(parts) == 1 {
+		return types.NamespacedName{Name: parts[0]}
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+}
+
+func uniqueKeys(keys []string) []string {
+	sort.Strings(keys)
+	unique := keys[:0]
+	for i, key := range keys {
+		if i == 0 || key != keys[i-1] {
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testReferencingAppGroup() *v1alpha1.ApplicationGroup {
+	app := func(name string, chart *v1alpha1.ChartRef, workflow ...v1alpha1.Executor) v1alpha1.Application {
+		a := v1alpha1.Application{DAG: v1alpha1.DAG{Name: name}}
+		a.Spec.Chart = chart
+		a.Spec.Workflow = workflow
+		return a
+	}
+	keptn := func(params string) v1alpha1.Executor {
+		return v1alpha1.Executor{DAG: v1alpha1.DAG{Name: "keptn"}, Type: v1alpha1.KeptnExecutor, Params: &apiextensionsv1.JSON{Raw: []byte(params)}}
+	}
+	return &v1alpha1.ApplicationGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo"},
+		Spec: v1alpha1.ApplicationGroupSpec{
+			Applications: []v1alpha1.Application{
+				app("ambassador", &v1alpha1.ChartRef{
+					URL:           "https://charts.example.com",
+					AuthSecretRef: &corev1.ObjectReference{Name: "repo-creds"},
+					Verify:        &v1alpha1.ChartVerification{SecretRef: &corev1.ObjectReference{Name: "keyring", Namespace: "security"}},
+				}, keptn(`{"configMapRef":{"name":"keptn-config"}}`)),
+				// The same secret is only referenced once
+				app("bookinfo", &v1alpha1.ChartRef{
+					URL:           "https://charts.example.com",
+					AuthSecretRef: &corev1.ObjectReference{Name: "repo-creds", Namespace: "orkestra"},
+				}, keptn(`{"configMapRef":{"name":"keptn-config","namespace":"bookinfo"}}`), v1alpha1.Executor{DAG: v1alpha1.DAG{Name: "default"}, Type: v1alpha1.HelmReleaseExecutor}),
+				// The secret of a Git repository is read by the Flux source-controller
+				app("podinfo", &v1alpha1.ChartRef{
+					URL:           "https://github.com/stefanprodan/podinfo",
+					Git:           &v1alpha1.GitChartSource{Path: "charts/podinfo"},
+					AuthSecretRef: &corev1.ObjectReference{Name: "git-creds"},
+				}),
+			},
+		},
+	}
+}
+
+func TestSecretReferences(t *testing.T) {
+	want := []string{"orkestra/repo-creds", "security/keyring"}
+	if got := SecretReferences(testReferencingAppGroup(), "orkestra"); !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretReferences() = %v, want %v", got, want)
+	}
+}
+
+func TestConfigMapReferences(t *testing.T) {
+	want := []string{"bookinfo/keptn-config", "orkestra/keptn-config"}
+	if got := ConfigMapReferences(testReferencingAppGroup(), "orkestra"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigMapReferences() = %v, want %v", got, want)
+	}
+}
+
+func TestReconcileHelper_ReferencesHash(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "repo-creds", Namespace: "orkestra"}, Data: map[string][]byte{"password": []byte("old")}}
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	// The referenced objects are read with the API reader rather than the cached client
+	helper := &ReconcileHelper{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), APIReader: k, Instance: testReferencingAppGroup()}
+
+	hash := func() string {
+		t.Helper()
+		h, err := helper.ReferencesHash(ctx)
+		if err != nil {
+			t.Fatalf("ReferencesHash() error = %v", err)
+		}
+		return h
+	}
+
+	initial := hash()
+	if again := hash(); again != initial {
+		t.Errorf("ReferencesHash() = %v, want the unchanged hash %v", again, initial)
+	}
+
+	// Rotating the secret changes the hash
+	secret.Data["password"] = []byte("new")
+	if err := k.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	rotated := hash()
+	if rotated == initial {
+		t.Errorf("ReferencesHash() = %v after the secret was rotated, want a new hash", rotated)
+	}
+
+	// Changing only the metadata of the secret keeps the hash
+	secret.Labels = map[string]string{"rotated": "true"}
+	if err := k.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if relabeled := hash(); relabeled != rotated {
+		t.Errorf("ReferencesHash() = %v after the secret was relabeled, want the unchanged hash %v", relabeled, rotated)
+	}
+
+	// Creating a referenced configmap that was missing changes the hash
+	if err := k.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "keptn-config", Namespace: "bookinfo"}}); err != nil {
+		t.Fatal(err)
+	}
+	if created := hash(); created == rotated {
+		t.Errorf("ReferencesHash() = %v after the configmap was created, want a new hash", created)
+	}
+
+	// Unreferenced objects don't change the hash
+	before := hash()
+	if err := k.Create(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "orkestra"}}); err != nil {
+		t.Fatal(err)
+	}
+	if after := hash(); after != before {
+		t.Errorf("ReferencesHash() = %v after an unreferenced secret was created, want %v", after, before)
+	}
+}
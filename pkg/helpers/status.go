@@ -3,11 +3,14 @@ package helpers
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 
 	"github.com/Azure/Orkestra/api/v1alpha1"
 	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/Azure/Orkestra/pkg/metrics"
+	"github.com/Azure/Orkestra/pkg/revision"
 	"github.com/Azure/Orkestra/pkg/workflow"
 	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
 	"github.com/go-logr/logr"
@@ -19,8 +22,9 @@ import (
 type StatusHelper struct {
 	client.Client
 	logr.Logger
-	PatchFrom client.Patch
-	Recorder  record.EventRecorder
+	PatchFrom       client.Patch
+	Recorder        record.EventRecorder
+	RevisionHistory *revision.History
 }
 
 func (helper *StatusHelper) UpdateStatus(ctx context.Context, parent *v1alpha1.ApplicationGroup, instance *v1alpha13.Workflow, wfType v1alpha1.WorkflowType) error {
@@ -30,6 +34,7 @@ func (helper *StatusHelper) UpdateStatus(ctx context.Context, parent *v1alpha1.A
 	}
 	parent.Status.Applications = getAppStatus(parent, chartConditionMap, subChartConditionMap)
 	if wfType == v1alpha1.Forward {
+		helper.recordPendingApprovals(parent, workflow.PendingApprovals(parent, instance))
 		if workflow.ToConditionReason(instance.Status.Phase) == meta.FailedReason {
 			helper.Info("workflow rollout is in a failed state")
 			helper.MarkFailed(parent, fmt.Errorf("workflow in failed state"))
@@ -57,11 +62,9 @@ func (helper *StatusHelper) UpdateFromWorkflowStatus(parent *v1alpha1.Applicatio
 }
 
 func (helper *StatusHelper) MarkSucceeded(ctx context.Context, instance *v1alpha1.ApplicationGroup) error {
-	// Set the last successful annotation for rollback scenarios
-	patch := client.MergeFrom(instance.DeepCopy())
-	instance.SetLastSuccessful()
-	if err := helper.Patch(ctx, instance, patch); err != nil {
-		helper.V(1).Error(err, "failed to patch the application group annotations")
+	// Record the successful revision for rollback scenarios
+	if err := helper.RevisionHistory.Record(ctx, instance); err != nil {
+		helper.V(1).Error(err, "failed to record the application group revision")
 		return err
 	}
 
@@ -73,9 +76,75 @@ func (helper *StatusHelper) MarkSucceeded(ctx context.Context, instance *v1alpha
 
 // MarkProgressing resets the conditions of the ApplicationGroup to
 // metav1.Condition of type meta.ReadyCondition with status 'Unknown' and
-// meta.StartingReason reason and message.
+// meta.StartingReason reason and message, and clears the remediation attempts of the previous generation.
 func (helper *StatusHelper) MarkProgressing(instance *v1alpha1.ApplicationGroup) {
 	instance.ReadyProgressing()
+	instance.Status.RemediationAttempts = 0
+	instance.Status.PendingApprovals = nil
+}
+
+// recordPendingApprovals sets the pending approval gates of the forward workflow
+// and raises an event for each gate that the workflow newly reached
+func (helper *StatusHelper) recordPendingApprovals(instance *v1alpha1.ApplicationGroup, approvals []v1alpha1.PendingApproval) {
+	pending := make(map[string]bool)
+	for _, approval := range instance.Status.PendingApprovals {
+		pending[workflow.ApprovalName(approval.Application, approval.Executor)] = true
+	}
+	for _, approval := range approvals {
+		if name := workflow.ApprovalName(approval.Application, approval.Executor); !pending[name] {
+			helper.Recorder.Event(instance, "Normal", "ApprovalPending", fmt.Sprintf("ApplicationGroup %s is waiting for the approval of %s, approve it with the %s annotation", instance.Name, name, v1alpha1.ApproveAnnotation))
+		}
+	}
+	instance.Status.PendingApprovals = approvals
+}
+
+// MarkApproved records the approval of the pending approval gates and warns about the gates that were not pending
+func (helper *StatusHelper) MarkApproved(instance *v1alpha1.ApplicationGroup, approvals, notPending []string) {
+	skipped := make(map[string]bool)
+	for _, approval := range notPending {
+		skipped[approval] = true
+	}
+	var approved []string
+	for _, approval := range approvals {
+		if !skipped[approval] {
+			approved = append(approved, approval)
+		}
+	}
+	if len(approved) > 0 {
+		helper.Recorder.Event(instance, "Normal", "Approved", fmt.Sprintf("Approved %s of ApplicationGroup %s", strings.Join(approved, ", "), instance.Name))
+	}
+	if len(notPending) > 0 {
+		helper.Recorder.Event(instance, "Warning", "ApprovalNotPending", fmt.Sprintf("Ignored the approval of %s of ApplicationGroup %s since the workflow is not waiting for it", strings.Join(notPending, ", "), instance.Name))
+	}
+}
+
+// MarkChartUpdating sets the meta.ReadyCondition to a progressing state after a new chart version
+// matching the version range of the given applications was published
+func (helper *StatusHelper) MarkChartUpdating(instance *v1alpha1.ApplicationGroup, applications []string) {
+	helper.Recorder.Event(instance, "Normal", "ChartUpdate", fmt.Sprintf("Updating the charts of applications %s of ApplicationGroup %s", strings.Join(applications, ", "), instance.Name))
+	helper.MarkProgressing(instance)
+}
+
+// MarkReferencesChanged records that the Secrets or ConfigMaps referenced by the ApplicationGroup changed
+// and marks the ApplicationGroup as progressing while it is rolled out again
+func (helper *StatusHelper) MarkReferencesChanged(instance *v1alpha1.ApplicationGroup) {
+	helper.Recorder.Event(instance, "Normal", "ReferencesChanged", fmt.Sprintf("Rolling out ApplicationGroup %s again since its referenced secrets or configmaps changed", instance.Name))
+	helper.MarkProgressing(instance)
+}
+
+// MarkSuspended sets the meta.ReadyCondition to 'False', with the meta.SuspendedReason reason
+func (helper *StatusHelper) MarkSuspended(instance *v1alpha1.ApplicationGroup) {
+	if instance.GetReadyCondition() != meta.SuspendedReason {
+		helper.Recorder.Event(instance, "Normal", meta.SuspendedReason, fmt.Sprintf("Suspended ApplicationGroup %s", instance.Name))
+	}
+	instance.ReadySuspended()
+}
+
+// MarkResumed sets the meta.ReadyCondition to a progressing state after the suspended workflows were resumed.
+// The remediation attempts are kept since the same generation is rolled out.
+func (helper *StatusHelper) MarkResumed(instance *v1alpha1.ApplicationGroup) {
+	helper.Recorder.Event(instance, "Normal", "Resumed", fmt.Sprintf("Resumed ApplicationGroup %s", instance.Name))
+	instance.ReadyProgressing()
 }
 
 // MarkTerminating sets the meta.ReadyCondition to 'False', with the given
@@ -96,12 +165,73 @@ func (helper *StatusHelper) MarkChartPullFailed(instance *v1alpha1.ApplicationGr
 	instance.ChartPullFailed(err.Error())
 }
 
+// MarkChartVerificationFailed sets the meta.ReadyCondition to 'False', with a chart verification failed reason
+func (helper *StatusHelper) MarkChartVerificationFailed(instance *v1alpha1.ApplicationGroup, err error) {
+	helper.Recorder.Event(instance, "Warning", meta.ChartVerificationFailedReason, err.Error())
+	instance.ChartVerificationFailed(err.Error())
+}
+
 // MarkWorkflowTemplateGenerationFailed sets the meta.ReadyCondition to 'False', with a workflow template generation failed reason
 func (helper *StatusHelper) MarkWorkflowTemplateGenerationFailed(instance *v1alpha1.ApplicationGroup, err error) {
 	helper.Recorder.Event(instance, "Warning", "ReconcileError", err.Error())
 	instance.WorkflowTemplateGenerationFailed(err.Error())
 }
 
+// MarkRollbackProgressing sets the rollback workflow condition to a progressing state
+// after a rollback to the revision with the given generation was requested
+func (helper *StatusHelper) MarkRollbackProgressing(instance *v1alpha1.ApplicationGroup, generation int64) {
+	helper.Recorder.Event(instance, "Normal", "RollbackRequested", fmt.Sprintf("Rolling back ApplicationGroup %s to generation %d", instance.Name, generation))
+	workflow.SetProgressing(instance, v1alpha1.Rollback)
+}
+
+// MarkRollbackFailed sets the rollback workflow condition to a failed state
+// when the requested rollback could not be started
+func (helper *StatusHelper) MarkRollbackFailed(instance *v1alpha1.ApplicationGroup, err error) {
+	helper.Recorder.Event(instance, "Warning", "RollbackFailed", err.Error())
+	workflow.SetFailed(instance, v1alpha1.Rollback, err.Error())
+}
+
+// MarkRemediating sets the remediation condition to a progressing state with the given reason
+// after the remediation workflow of the failed generation was submitted
+func (helper *StatusHelper) MarkRemediating(instance *v1alpha1.ApplicationGroup, reason string) {
+	message := fmt.Sprintf("remediating the failed workflow with strategy %s, attempt %d of %d",
+		instance.GetRemediationStrategy(), instance.Status.RemediationAttempts, instance.GetRemediationMaxAttempts())
+	helper.Recorder.Event(instance, "Normal", reason, fmt.Sprintf("ApplicationGroup %s: %s", instance.Name, message))
+	meta.SetResourceCondition(instance, meta.RemediationCondition, metav1.ConditionUnknown, reason, message)
+}
+
+// MarkRemediationSucceeded sets the remediation condition to 'True' once the remediation workflow succeeded
+func (helper *StatusHelper) MarkRemediationSucceeded(instance *v1alpha1.ApplicationGroup) {
+	helper.Recorder.Event(instance, "Normal", "RemediationSucceeded", fmt.Sprintf("Successfully remediated ApplicationGroup %s", instance.Name))
+	meta.SetResourceCondition(instance, meta.RemediationCondition, metav1.ConditionTrue, meta.SucceededReason, "remediation workflow succeeded")
+}
+
+// MarkRemediationPaused sets the remediation condition to 'False' and raises a warning event so that
+// an operator can inspect the failed releases
+func (helper *StatusHelper) MarkRemediationPaused(instance *v1alpha1.ApplicationGroup) {
+	helper.Recorder.Event(instance, "Warning", meta.RemediationPausedReason, fmt.Sprintf("ApplicationGroup %s failed, the failed releases are left in place for manual intervention", instance.Name))
+	meta.SetResourceCondition(instance, meta.RemediationCondition, metav1.ConditionFalse, meta.RemediationPausedReason, "remediation is paused for manual intervention")
+}
+
+// MarkRemediationDisabled sets the remediation condition to 'False' when the failed releases are left in place
+func (helper *StatusHelper) MarkRemediationDisabled(instance *v1alpha1.ApplicationGroup, message string) {
+	helper.Recorder.Event(instance, "Normal", meta.RemediationDisabledReason, fmt.Sprintf("ApplicationGroup %s failed, %s", instance.Name, message))
+	meta.SetResourceCondition(instance, meta.RemediationCondition, metav1.ConditionFalse, meta.RemediationDisabledReason, message)
+}
+
+// MarkRemediationFailed sets the remediation condition to 'False' when the remediation attempts ran out
+func (helper *StatusHelper) MarkRemediationFailed(instance *v1alpha1.ApplicationGroup) {
+	message := fmt.Sprintf("remediation workflow failed after %d attempts", instance.Status.RemediationAttempts)
+	helper.Recorder.Event(instance, "Warning", meta.RemediationFailedReason, fmt.Sprintf("ApplicationGroup %s: %s", instance.Name, message))
+	meta.SetResourceCondition(instance, meta.RemediationCondition, metav1.ConditionFalse, meta.RemediationFailedReason, message)
+}
+
+// RecordDAGValidationFailed records the DAG validation failure of the forward graph as an event,
+// the meta.ReadyCondition is set by the forward workflow client when validating the graph
+func (helper *StatusHelper) RecordDAGValidationFailed(instance *v1alpha1.ApplicationGroup, err error) {
+	helper.Recorder.Event(instance, "Warning", meta.DAGValidationFailedReason, err.Error())
+}
+
 func initAppStatus(appGroup *v1alpha1.ApplicationGroup) {
 	// Initialize the Status fields if not already setup
 	appGroup.Status.Applications = make([]v1alpha1.ApplicationStatus, 0, len(appGroup.Spec.Applications))
@@ -181,5 +311,6 @@ func (helper *StatusHelper) PatchStatus(ctx context.Context, instance *v1alpha1.
 		helper.V(1).Error(err, "failed to patch the application group status")
 		return err
 	}
+	metrics.SetProgressing(instance.Name, instance.DeletionTimestamp.IsZero() && instance.GetReadyCondition() == meta.ProgressingReason)
 	return nil
 }
@@ -29,6 +29,9 @@ const (
 	ReverseWorkflowSucceededCondition string = "ReverseWorkflowSucceeded"
 
 	RollbackWorkflowSucceededCondition string = "RollbackWorkflowSucceeded"
+
+	// RemediationCondition captures the remediation of a failed forward workflow
+	RemediationCondition string = "Remediation"
 )
 
 const (
@@ -53,6 +56,10 @@ const (
 	// was unable to pull from the chart repo specified
 	ChartPullFailedReason string = "ChartPullFailed"
 
+	// ChartVerificationFailedReason represents the fact that the provenance file or the signature
+	// of an application chart could not be verified, so that the chart was not staged
+	ChartVerificationFailedReason string = "ChartVerificationFailed"
+
 	// WorkflowFailedReason represents the fact that a workflow step failed and is the reason
 	// why the application group was unable to successfully reconcile
 	WorkflowFailedReason string = "WorkflowFailed"
@@ -60,6 +67,30 @@ const (
 	// WorkflowTemplateGenerationFailedReason represents the fact that the application group was unable
 	// to generate the templates for the workflow reconciliation
 	WorkflowTemplateGenerationFailedReason string = "WorkflowTemplateGenerationFailed"
+
+	// DAGValidationFailedReason represents the fact that the application, subchart or executor
+	// dependencies of the application group contain a cycle or a reference to an unknown node
+	DAGValidationFailedReason string = "DAGValidationFailed"
+
+	// RollingBackReason represents that the failed forward workflow is remediated
+	// by rolling back to the last successful revision
+	RollingBackReason string = "RollingBack"
+
+	// ReversingReason represents that the failed forward workflow is remediated
+	// by uninstalling the applications of the application group
+	ReversingReason string = "Reversing"
+
+	// RemediationPausedReason represents that the failed releases are left in place
+	// until an operator intervenes
+	RemediationPausedReason string = "RemediationPaused"
+
+	// RemediationDisabledReason represents that the failed releases are left in place
+	// because the remediation is turned off
+	RemediationDisabledReason string = "RemediationDisabled"
+
+	// RemediationFailedReason represents that the remediation workflow kept failing
+	// until the remediation attempts ran out
+	RemediationFailedReason string = "RemediationFailed"
 )
 
 // ObjectWithStatusConditions is an interface that describes kubernetes resource
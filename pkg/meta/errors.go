@@ -11,4 +11,8 @@ var (
 
 	ErrForwardWorkflowNotFound = errors.New("forward workflow not found")
 	ErrPreviousSpecNotSet      = errors.New("failed to generate rollback workflow, previous spec is unset")
+	ErrRevisionNotFound        = errors.New("failed to generate rollback workflow, revision not found in the revision history")
+
+	ErrChartSourceNotReady     = errors.New("chart source artifact is not ready")
+	ErrChartVerificationFailed = errors.New("chart verification failed")
 )
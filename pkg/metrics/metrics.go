@@ -0,0 +1,138 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+// Package metrics defines the Orkestra specific Prometheus metrics. The collectors are registered
+// with the controller-runtime metrics registry and served by the manager on --metrics-addr.
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	namespace = "orkestra"
+
+	// SucceededOutcome is the outcome label value of the successful operations
+	SucceededOutcome = "succeeded"
+	// FailedOutcome is the outcome label value of the failed operations
+	FailedOutcome = "failed"
+
+	// PullOperation is the operation label value of the chart pulls
+	PullOperation = "pull"
+	// PushOperation is the operation label value of the chart pushes
+	PushOperation = "push"
+)
+
+var (
+	workflowsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "workflows_total",
+		Help:      "Number of completed workflows by workflow type and outcome",
+	}, []string{"type", "outcome"})
+
+	workflowDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "workflow_duration_seconds",
+		Help:      "Duration of the completed workflows by workflow type and outcome",
+		Buckets:   prometheus.ExponentialBuckets(10, 2, 10),
+	}, []string{"type", "outcome"})
+
+	applicationDeployDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "application_deploy_duration_seconds",
+		Help:      "Duration of the application deployments of the forward workflows by application and outcome",
+		Buckets:   prometheus.ExponentialBuckets(5, 2, 10),
+	}, []string{"appgroup", "application", "outcome"})
+
+	chartOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "chart_operation_duration_seconds",
+		Help:      "Latency of the chart pulls and pushes by operation and outcome",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "outcome"})
+
+	chartOperationFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chart_operation_failures_total",
+		Help:      "Number of failed chart pulls and pushes by operation",
+	}, []string{"operation"})
+
+	progressingAppGroups = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "appgroups_progressing",
+		Help:      "Number of ApplicationGroups that are currently progressing",
+	})
+
+	remediationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "remediations_total",
+		Help:      "Number of remediations of failed workflows by remediation strategy",
+	}, []string{"strategy"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		workflowsTotal,
+		workflowDuration,
+		applicationDeployDuration,
+		chartOperationDuration,
+		chartOperationFailuresTotal,
+		progressingAppGroups,
+		remediationsTotal,
+	)
+}
+
+// Outcome returns the outcome label value for the error of an operation
+func Outcome(err error) string {
+	if err != nil {
+		return FailedOutcome
+	}
+	return SucceededOutcome
+}
+
+// RecordWorkflow records the outcome and the duration of a completed workflow
+func RecordWorkflow(wfType v1alpha1.WorkflowType, outcome string, duration time.Duration) {
+	workflowsTotal.WithLabelValues(string(wfType), outcome).Inc()
+	workflowDuration.WithLabelValues(string(wfType), outcome).Observe(duration.Seconds())
+}
+
+// RecordApplicationDeploy records the outcome and the duration of an application deployment
+func RecordApplicationDeploy(appGroup, application, outcome string, duration time.Duration) {
+	applicationDeployDuration.WithLabelValues(appGroup, application, outcome).Observe(duration.Seconds())
+}
+
+// RecordChartOperation records the latency of a chart pull or push started at the given time
+// and counts the operation as failed if it returned an error
+func RecordChartOperation(operation string, start time.Time, err error) {
+	chartOperationDuration.WithLabelValues(operation, Outcome(err)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		chartOperationFailuresTotal.WithLabelValues(operation).Inc()
+	}
+}
+
+// RecordRemediation counts a remediation of a failed workflow with the given strategy
+func RecordRemediation(strategy string) {
+	remediationsTotal.WithLabelValues(strategy).Inc()
+}
+
+var progressing = struct {
+	sync.Mutex
+	appGroups map[string]bool
+}{appGroups: make(map[string]bool)}
+
+// SetProgressing tracks whether the named ApplicationGroup is currently progressing
+func SetProgressing(appGroup string, isProgressing bool) {
+	progressing.Lock()
+	defer progressing.Unlock()
+	if isProgressing {
+		progressing.appGroups[appGroup] = true
+	} else {
+		delete(progressing.appGroups, appGroup)
+	}
+	progressingAppGroups.Set(float64(len(progressing.appGroups)))
+}
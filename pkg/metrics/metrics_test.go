@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordChartOperation(t *testing.T) {
+	RecordChartOperation(PullOperation, time.Now(), nil)
+	RecordChartOperation(PullOperation, time.Now(), errors.New("not found"))
+	RecordChartOperation(PushOperation, time.Now(), nil)
+
+	if got := testutil.ToFloat64(chartOperationFailuresTotal.WithLabelValues(PullOperation)); got != 1 {
+		t.Errorf("chart pull failures = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(chartOperationFailuresTotal.WithLabelValues(PushOperation)); got != 0 {
+		t.Errorf("chart push failures = %v, want 0", got)
+	}
+	if got := testutil.CollectAndCount(chartOperationDuration); got != 3 {
+		t.Errorf("chart operation duration series = %v, want 3", got)
+	}
+}
+
+func TestRecordWorkflow(t *testing.T) {
+	RecordWorkflow(v1alpha1.Forward, FailedOutcome, time.Minute)
+	RecordWorkflow(v1alpha1.Rollback, SucceededOutcome, time.Minute)
+	RecordWorkflow(v1alpha1.Rollback, SucceededOutcome, time.Minute)
+
+	if got := testutil.ToFloat64(workflowsTotal.WithLabelValues(string(v1alpha1.Rollback), SucceededOutcome)); got != 2 {
+		t.Errorf("succeeded rollback workflows = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(workflowsTotal.WithLabelValues(string(v1alpha1.Forward), FailedOutcome)); got != 1 {
+		t.Errorf("failed forward workflows = %v, want 1", got)
+	}
+}
+
+func TestSetProgressing(t *testing.T) {
+	SetProgressing("bookinfo", true)
+	SetProgressing("bookinfo", true)
+	SetProgressing("ambassador", true)
+	if got := testutil.ToFloat64(progressingAppGroups); got != 2 {
+		t.Errorf("progressing application groups = %v, want 2", got)
+	}
+	SetProgressing("bookinfo", false)
+	SetProgressing("podinfo", false)
+	if got := testutil.ToFloat64(progressingAppGroups); got != 1 {
+		t.Errorf("progressing application groups = %v, want 1", got)
+	}
+}
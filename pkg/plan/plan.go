@@ -0,0 +1,148 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/executor"
+	"github.com/Azure/Orkestra/pkg/graph"
+	"github.com/Azure/Orkestra/pkg/utils"
+	"github.com/Azure/Orkestra/pkg/workflow"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// Plan holds the objects rendered by a workflow client that has
+// been generated but not submitted to the workflow engine
+type Plan struct {
+	Type v1alpha1.WorkflowType
+
+	// Workflow is the generated Argo Workflow
+	Workflow *v1alpha13.Workflow
+
+	// HelmReleases are the decoded HelmReleases passed to the workflow executor tasks
+	HelmReleases []*fluxhelmv2beta1.HelmRelease
+
+	// Graph is the combined graph of the rolled back and reversed applications
+	// that is only set for the rollback workflow
+	Graph *graph.Graph
+}
+
+// Generate runs the Generate method of the workflow client without submitting the
+// workflow and collects the rendered workflow and HelmReleases into a Plan
+func Generate(ctx context.Context, wfClient workflow.Client) (*Plan, error) {
+	if err := wfClient.Generate(ctx); err != nil {
+		return nil, fmt.Errorf("failed to generate workflow: %w", err)
+	}
+	p := &Plan{
+		Type:     wfClient.GetType(),
+		Workflow: wfClient.GetWorkflow(),
+	}
+	helmReleases, err := HelmReleases(p.Workflow)
+	if err != nil {
+		return nil, err
+	}
+	p.HelmReleases = helmReleases
+
+	if p.Type == v1alpha1.Rollback {
+		if p.Graph, err = workflow.RollbackGraph(ctx, wfClient); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// HelmReleases decodes the base64 encoded HelmReleases from the executor task arguments
+// of the workflow. HelmReleases shared by several executor tasks are only returned once.
+func HelmReleases(wf *v1alpha13.Workflow) ([]*fluxhelmv2beta1.HelmRelease, error) {
+	seen := make(map[string]bool)
+	var helmReleases []*fluxhelmv2beta1.HelmRelease
+	for _, template := range wf.Spec.Templates {
+		if template.DAG == nil {
+			continue
+		}
+		for _, task := range template.DAG.Tasks {
+			for _, param := range task.Arguments.Parameters {
+				if param.Name != executor.HelmReleaseArg || param.Value == nil {
+					continue
+				}
+				hr, err := utils.B64ToHr(param.Value.String())
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode the helmrelease of task %s: %w", task.Name, err)
+				}
+				key := hr.Namespace + "/" + hr.Name
+				if !seen[key] {
+					seen[key] = true
+					helmReleases = append(helmReleases, hr)
+				}
+			}
+		}
+	}
+	sort.Slice(helmReleases, func(i, j int) bool {
+		if helmReleases[i].Namespace != helmReleases[j].Namespace {
+			return helmReleases[i].Namespace < helmReleases[j].Namespace
+		}
+		return helmReleases[i].Name < helmReleases[j].Name
+	})
+	return helmReleases, nil
+}
+
+// Write writes the plan as a multi-document YAML stream with the rollback
+// graph, if any, written as a comment at the top of the stream
+func (p *Plan) Write(w io.Writer) error {
+	if p.Graph != nil {
+		var sb strings.Builder
+		WriteGraph(&sb, p.Graph)
+		for _, line := range strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n") {
+			if _, err := fmt.Fprintf(w, "# %s\n", line); err != nil {
+				return err
+			}
+		}
+	}
+	objects := []interface{}{p.Workflow}
+	for _, hr := range p.HelmReleases {
+		objects = append(objects, hr)
+	}
+	for _, obj := range objects {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the plan: %w", err)
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteGraph writes the app nodes, task nodes and executor nodes of the graph
+// as an indented tree along with their dependencies
+func WriteGraph(w io.Writer, g *graph.Graph) {
+	fmt.Fprintf(w, "graph %s\n", g.Name)
+	for _, appName := range graph.SortedAppNames(g.Nodes) {
+		appNode := g.Nodes[appName]
+		fmt.Fprintf(w, "  app %s%s\n", appName, dependencyString(appNode.Dependencies))
+		for _, taskName := range graph.SortedTaskNames(appNode.Tasks) {
+			task := appNode.Tasks[taskName]
+			fmt.Fprintf(w, "    task %s (chart %s:%s)%s\n", taskName, task.ChartName, task.ChartVersion, dependencyString(task.Dependencies))
+			for _, executorName := range graph.SortedExecutorNames(task.Executors) {
+				executorNode := task.Executors[executorName]
+				fmt.Fprintf(w, "      executor %s (%s)%s\n", executorName, executorNode.Executor.GetName(), dependencyString(executorNode.Dependencies))
+			}
+		}
+	}
+}
+
+func dependencyString(dependencies []string) string {
+	if len(dependencies) == 0 {
+		return ""
+	}
+	deps := append([]string{}, dependencies...)
+	sort.Strings(deps)
+	return " <- " + strings.Join(deps, ", ")
+}
@@ -0,0 +1,103 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/executor"
+	"github.com/Azure/Orkestra/pkg/graph"
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func helmReleaseTask(name, namespace string) v1alpha13.DAGTask {
+	hr := &fluxhelmv2beta1.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+	return v1alpha13.DAGTask{
+		Name: name,
+		Arguments: v1alpha13.Arguments{
+			Parameters: []v1alpha13.Parameter{
+				{Name: executor.HelmReleaseArg, Value: v1alpha13.AnyStringPtr(utils.HrToB64(hr))},
+			},
+		},
+	}
+}
+
+func TestHelmReleases(t *testing.T) {
+	wf := &v1alpha13.Workflow{
+		Spec: v1alpha13.WorkflowSpec{
+			Templates: []v1alpha13.Template{
+				{
+					Name: "application1",
+					DAG: &v1alpha13.DAGTemplate{
+						Tasks: []v1alpha13.DAGTask{
+							helmReleaseTask("subchart1", "ns2"),
+							helmReleaseTask("application1", "ns2"),
+						},
+					},
+				},
+				{
+					Name: "application2",
+					DAG: &v1alpha13.DAGTemplate{
+						Tasks: []v1alpha13.DAGTask{
+							helmReleaseTask("application2", "ns1"),
+							helmReleaseTask("application1", "ns2"),
+						},
+					},
+				},
+				{Name: "executor"},
+			},
+		},
+	}
+	got, err := HelmReleases(wf)
+	if err != nil {
+		t.Fatalf("HelmReleases() error = %v", err)
+	}
+	var names []string
+	for _, hr := range got {
+		names = append(names, hr.Namespace+"/"+hr.Name)
+	}
+	want := []string{"ns1/application2", "ns2/application1", "ns2/subchart1"}
+	if !cmp.Equal(names, want) {
+		t.Errorf("HelmReleases() diff = %v", cmp.Diff(names, want))
+	}
+}
+
+func TestWriteGraph(t *testing.T) {
+	g := &graph.Graph{
+		Name: "bookinfo",
+		Nodes: map[string]*graph.AppNode{
+			"bookinfo": {
+				Name:         "bookinfo",
+				Dependencies: []string{"ambassador"},
+				Tasks: map[string]*graph.TaskNode{
+					"bookinfo-bookinfo": {
+						Name:         "bookinfo-bookinfo",
+						ChartName:    "bookinfo",
+						ChartVersion: "v1",
+						Executors: map[string]*graph.ExecutorNode{
+							"helmrelease": {Name: "helmrelease", Executor: executor.ForwardFactory(v1alpha1.HelmReleaseExecutor, nil, nil)},
+						},
+					},
+				},
+			},
+			"ambassador": {Name: "ambassador"},
+		},
+	}
+	var sb strings.Builder
+	WriteGraph(&sb, g)
+	want := `graph bookinfo
+  app ambassador
+  app bookinfo <- ambassador
+    task bookinfo-bookinfo (chart bookinfo:v1)
+      executor helmrelease (helmrelease-forward-executor)
+`
+	if sb.String() != want {
+		t.Errorf("WriteGraph() diff = %v", cmp.Diff(sb.String(), want))
+	}
+}
@@ -0,0 +1,188 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/chartmuseum/helm-push/pkg/chartmuseum"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// ChartMuseumStore stages the charts in a ChartMuseum helm repository
+type ChartMuseumStore struct {
+	cfg *Config
+}
+
+// NewChartMuseumStore is the constructor for the ChartMuseum staging store
+func NewChartMuseumStore(cfg *Config) *ChartMuseumStore {
+	return &ChartMuseumStore{cfg: cfg}
+}
+
+// apiURL returns the URL of the ChartMuseum API
+func (s *ChartMuseumStore) apiURL() string {
+	// Set the URL to the port-forward address:port of chartmuseum (http://localhost:8080)
+	if url := os.Getenv("CI_ENVTEST_CHARTMUSEUM_URL"); url != "" {
+		return url
+	}
+	return s.cfg.URL
+}
+
+func (s *ChartMuseumStore) client() (*chartmuseum.Client, error) {
+	// The helm push client only reads the TLS files when it is created
+	caFile, certFile, keyFile, cleanup, err := s.cfg.tlsFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write the TLS files of the helm push client : %w", err)
+	}
+	defer cleanup()
+
+	// logic is derived from the "helm push" extension from the chartmuseum folks
+	c, err := chartmuseum.NewClient(
+		chartmuseum.URL(s.apiURL()),
+		chartmuseum.Username(s.cfg.Username),
+		chartmuseum.Password(s.cfg.Password),
+		chartmuseum.AccessToken(s.cfg.AccessToken),
+		chartmuseum.AuthHeader(s.cfg.AuthHeader),
+		chartmuseum.CAFile(caFile),
+		chartmuseum.CertFile(certFile),
+		chartmuseum.KeyFile(keyFile),
+		chartmuseum.InsecureSkipVerify(s.cfg.InsecureSkipVerify),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new helm push client : %w", err)
+	}
+	return c, nil
+}
+
+// Push uploads the chart package to ChartMuseum (POST /api/charts)
+func (s *ChartMuseumStore) Push(ctx context.Context, pkgPath string, ch *chart.Chart) error {
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.UploadChartPackage(pkgPath, true)
+	if err != nil {
+		return fmt.Errorf("failed to upload chart package : %w", err)
+	}
+	defer resp.Body.Close()
+	if err := handlePushResponse(resp); err != nil {
+		return fmt.Errorf("failed to handle upload/push http response : %w", err)
+	}
+	return nil
+}
+
+// Exists looks up the chart version in ChartMuseum (GET /api/charts/<name>/<version>)
+func (s *ChartMuseumStore) Exists(ctx context.Context, chartName, version string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodGet, "api/charts/"+chartName+"/"+version)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, chartmuseumResponseError(resp)
+	}
+}
+
+// Delete removes the chart version from ChartMuseum (DELETE /api/charts/<name>/<version>)
+func (s *ChartMuseumStore) Delete(ctx context.Context, chartName, version string) error {
+	resp, err := s.do(ctx, http.MethodDelete, "api/charts/"+chartName+"/"+version)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return chartmuseumResponseError(resp)
+	}
+	return nil
+}
+
+// List returns the chart versions stored in ChartMuseum (GET /api/charts)
+func (s *ChartMuseumStore) List(ctx context.Context) (map[string][]string, error) {
+	resp, err := s.do(ctx, http.MethodGet, "api/charts")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, chartmuseumResponseError(resp)
+	}
+	var entries map[string][]struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode the chart list: %w", err)
+	}
+	charts := make(map[string][]string, len(entries))
+	for name, versions := range entries {
+		for _, v := range versions {
+			charts[name] = append(charts[name], v.Version)
+		}
+	}
+	return charts, nil
+}
+
+// URL returns the URL of the ChartMuseum helm repository
+func (s *ChartMuseumStore) URL() string {
+	return s.cfg.URL
+}
+
+// Ready checks the ChartMuseum health endpoint
+func (s *ChartMuseumStore) Ready(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodGet, "health")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return chartmuseumResponseError(resp)
+	}
+	return nil
+}
+
+func (s *ChartMuseumStore) do(ctx context.Context, method, path string) (*http.Response, error) {
+	c, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(s.apiURL(), "/")+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.cfg.authorize(req)
+	return c.Do(req)
+}
+
+func handlePushResponse(resp *http.Response) error {
+	if resp.StatusCode != 201 {
+		return chartmuseumResponseError(resp)
+	}
+	return nil
+}
+
+func chartmuseumResponseError(resp *http.Response) error {
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return getChartmuseumError(b, resp.StatusCode)
+}
+
+func getChartmuseumError(b []byte, code int) error {
+	var er struct {
+		Error string `json:"error"`
+	}
+	err := json.Unmarshal(b, &er)
+	if err != nil || er.Error == "" {
+		return fmt.Errorf("%d: could not properly parse response JSON: %s", code, string(b))
+	}
+	return fmt.Errorf("%d: %s", code, er.Error)
+}
@@ -1,5 +1,15 @@
 package registry
 
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
 // Config struct captures the configuration fields as per the repoAddOptions - https://github.com/helm/helm/blob/v3.1.2/cmd/helm/repo_add.go#L39
 type Config struct {
 	Name               string `yaml:"name" json:"name,omitempty"`
@@ -12,4 +22,129 @@ type Config struct {
 	KeyFile            string `yaml:"keyFile" json:"key_file,omitempty"`
 	InsecureSkipVerify bool   `yaml:"insecureSkipVerify" json:"insecure_skip_verify,omitempty"`
 	AccessToken        string `yaml:"accessToken" json:"access_token,omitempty"`
+
+	// CaData, CertData and KeyData hold the PEM encoded CA bundle, client certificate and client key
+	// read from the auth secret. They are kept in memory and take precedence over the files.
+	CaData   []byte `yaml:"-" json:"-"`
+	CertData []byte `yaml:"-" json:"-"`
+	KeyData  []byte `yaml:"-" json:"-"`
+}
+
+// tlsConfig returns the TLS client config with the client certificate and the CA bundle of the config
+func (cfg *Config) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec
+	}
+
+	certData, keyData := cfg.CertData, cfg.KeyData
+	if len(certData) == 0 && cfg.CertFile != "" {
+		var err error
+		if certData, err = os.ReadFile(cfg.CertFile); err != nil {
+			return nil, fmt.Errorf("failed to read the client certificate file: %w", err)
+		}
+	}
+	if len(keyData) == 0 && cfg.KeyFile != "" {
+		var err error
+		if keyData, err = os.ReadFile(cfg.KeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read the client key file: %w", err)
+		}
+	}
+	if len(certData) != 0 || len(keyData) != 0 {
+		if len(certData) == 0 || len(keyData) == 0 {
+			return nil, errors.New("the client certificate and the client key must be set together")
+		}
+		cert, err := tls.X509KeyPair(certData, keyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	caData := cfg.CaData
+	if len(caData) == 0 && cfg.CaFile != "" {
+		var err error
+		if caData, err = os.ReadFile(cfg.CaFile); err != nil {
+			return nil, fmt.Errorf("failed to read the CA file: %w", err)
+		}
+	}
+	if len(caData) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, errors.New("failed to append the certificates of the CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// httpClient returns an HTTP client with the TLS client config of the config
+func (cfg *Config) httpClient() (*http.Client, error) {
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
+
+// tokenHeader returns the header that carries the access token of the config, which is
+// the auth header if set and a bearer token in the Authorization header otherwise
+func (cfg *Config) tokenHeader() http.Header {
+	if cfg.AccessToken == "" {
+		return nil
+	}
+	if cfg.AuthHeader != "" {
+		return http.Header{http.CanonicalHeaderKey(cfg.AuthHeader): {cfg.AccessToken}}
+	}
+	return http.Header{"Authorization": {"Bearer " + cfg.AccessToken}}
+}
+
+// authorize sets the access token or the basic auth credentials of the config on the request
+func (cfg *Config) authorize(req *http.Request) {
+	if header := cfg.tokenHeader(); header != nil {
+		for k, v := range header {
+			req.Header[k] = v
+		}
+	} else if cfg.Username != "" || cfg.Password != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+}
+
+// tlsFiles returns the paths of the CA bundle, client certificate and client key files of the config for the clients
+// that only accept files. The in-memory data is written to files in a temporary directory that must be removed with
+// the returned cleanup function as soon as the files have been read.
+func (cfg *Config) tlsFiles() (caFile, certFile, keyFile string, cleanup func(), err error) {
+	caFile, certFile, keyFile, cleanup = cfg.CaFile, cfg.CertFile, cfg.KeyFile, func() {}
+	if len(cfg.CaData) == 0 && len(cfg.CertData) == 0 && len(cfg.KeyData) == 0 {
+		return caFile, certFile, keyFile, cleanup, nil
+	}
+
+	dir, err := os.MkdirTemp("", "orkestra-tls-")
+	if err != nil {
+		return "", "", "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	for _, f := range []struct {
+		data []byte
+		path *string
+		name string
+	}{
+		{cfg.CaData, &caFile, "ca.crt"},
+		{cfg.CertData, &certFile, "tls.crt"},
+		{cfg.KeyData, &keyFile, "tls.key"},
+	} {
+		if len(f.data) == 0 {
+			continue
+		}
+		*f.path = filepath.Join(dir, f.name)
+		if err := os.WriteFile(*f.path, f.data, 0600); err != nil {
+			cleanup()
+			return "", "", "", nil, err
+		}
+	}
+	return caFile, certFile, keyFile, cleanup, nil
 }
@@ -0,0 +1,295 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetHelmRepoConfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	secret := func(namespace string, data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: namespace}, Data: data}
+	}
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		secret("orkestra", map[string][]byte{
+			UsernameSecretKey: []byte("user"),
+			PasswordSecretKey: []byte("password"),
+			CertSecretKey:     []byte("cert"),
+			KeySecretKey:      []byte("key"),
+			CASecretKey:       []byte("ca"),
+		}),
+		secret("other", map[string][]byte{
+			AccessTokenSecretKey: []byte("token"),
+			AuthHeaderSecretKey:  []byte("X-Api-Key"),
+		}),
+	).Build()
+
+	tests := []struct {
+		name          string
+		authSecretRef *corev1.ObjectReference
+		want          *Config
+		wantErr       bool
+	}{
+		{
+			name: "No Auth Secret",
+			want: &Config{Name: "bookinfo", URL: "https://charts.example.com"},
+		},
+		{
+			name:          "Auth Secret In The Default Namespace",
+			authSecretRef: &corev1.ObjectReference{Name: "creds"},
+			want: &Config{
+				Name:     "bookinfo",
+				URL:      "https://charts.example.com",
+				Username: "user",
+				Password: "password",
+				CertData: []byte("cert"),
+				KeyData:  []byte("key"),
+				CaData:   []byte("ca"),
+			},
+		},
+		{
+			name:          "Auth Secret In Another Namespace",
+			authSecretRef: &corev1.ObjectReference{Name: "creds", Namespace: "other"},
+			want: &Config{
+				Name:        "bookinfo",
+				URL:         "https://charts.example.com",
+				AccessToken: "token",
+				AuthHeader:  "X-Api-Key",
+			},
+		},
+		{
+			name:          "Missing Auth Secret",
+			authSecretRef: &corev1.ObjectReference{Name: "creds", Namespace: "default"},
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &v1alpha1.Application{DAG: v1alpha1.DAG{Name: "bookinfo"}}
+			app.Spec.Chart = &v1alpha1.ChartRef{URL: "https://charts.example.com", AuthSecretRef: tt.authSecretRef}
+			var namespace string
+			if tt.authSecretRef != nil {
+				namespace = tt.authSecretRef.Namespace
+			}
+
+			got, err := GetHelmRepoConfig(app, "orkestra", k)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetHelmRepoConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Name != tt.want.Name || got.URL != tt.want.URL || got.Username != tt.want.Username || got.Password != tt.want.Password ||
+				got.AccessToken != tt.want.AccessToken || got.AuthHeader != tt.want.AuthHeader ||
+				!bytes.Equal(got.CertData, tt.want.CertData) || !bytes.Equal(got.KeyData, tt.want.KeyData) || !bytes.Equal(got.CaData, tt.want.CaData) {
+				t.Errorf("GetHelmRepoConfig() = %+v, want %+v", got, tt.want)
+			}
+			if got.CertFile != "" || got.KeyFile != "" || got.CaFile != "" {
+				t.Errorf("GetHelmRepoConfig() must not set the TLS file paths, got %+v", got)
+			}
+			if tt.authSecretRef != nil && app.Spec.Chart.AuthSecretRef.Namespace != namespace {
+				t.Errorf("GetHelmRepoConfig() changed the auth secret namespace to %v", app.Spec.Chart.AuthSecretRef.Namespace)
+			}
+		})
+	}
+}
+
+// testCertificate returns the PEM encoded certificate and key signed by the parent, or self-signed if the parent is nil
+func testCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestClient_PullChart_Credentials(t *testing.T) {
+	ctx := context.Background()
+
+	ca, caKey, _, _ := testCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "orkestra-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	_, _, clientCert, clientKey := testCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "orkestra"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+
+	// The TLS repository requires a client certificate signed by the CA
+	tlsServer := httptest.NewUnstartedServer(testHelmRepoHandler(t, "1.0.0", func(*http.Request) bool { return true }))
+	tlsServer.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+
+	tokenServer := httptest.NewServer(testHelmRepoHandler(t, "1.0.0", func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer token" || r.Header.Get("X-Api-Key") == "token"
+	}))
+	defer tokenServer.Close()
+
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr bool
+	}{
+		{
+			name: "Client Certificate And CA Bundle",
+			cfg:  &Config{URL: tlsServer.URL, CertData: clientCert, KeyData: clientKey, CaData: serverCA},
+		},
+		{
+			name:    "Missing Client Certificate",
+			cfg:     &Config{URL: tlsServer.URL, CaData: serverCA},
+			wantErr: true,
+		},
+		{
+			name:    "Missing CA Bundle",
+			cfg:     &Config{URL: tlsServer.URL, CertData: clientCert, KeyData: clientKey},
+			wantErr: true,
+		},
+		{
+			name: "Bearer Token",
+			cfg:  &Config{URL: tokenServer.URL, AccessToken: "token"},
+		},
+		{
+			name: "Token In Auth Header",
+			cfg:  &Config{URL: tokenServer.URL, AccessToken: "token", AuthHeader: "X-Api-Key"},
+		},
+		{
+			name:    "Wrong Token",
+			cfg:     &Config{URL: tokenServer.URL, AccessToken: "other"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t)
+			tt.cfg.Name = "bookinfo"
+			err := c.AddRepo(tt.cfg)
+			if err == nil {
+				_, _, err = c.PullChart(ctx, logr.Discard(), "bookinfo", "bookinfo", "1.0.0")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AddRepo() and PullChart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_PullChart_CrossHostCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	// The chart package is served by another host, which must not receive the credentials of the repository
+	var leaked bool
+	packageServer := httptest.NewServer(testHelmRepoHandler(t, "1.0.0", func(r *http.Request) bool {
+		leaked = leaked || r.Header.Get("Authorization") != ""
+		return true
+	}))
+	defer packageServer.Close()
+
+	index := repo.NewIndexFile()
+	if err := index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "bookinfo", Version: "1.0.0"}, "bookinfo-1.0.0.tgz", packageServer.URL, ""); err != nil {
+		t.Fatal(err)
+	}
+	indexPath := filepath.Join(t.TempDir(), "index.yaml")
+	if err := index.WriteFile(indexPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+	indexData, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(indexData)
+	}))
+	defer repoServer.Close()
+
+	c := newTestClient(t)
+	if err := c.AddRepo(&Config{Name: "bookinfo", URL: repoServer.URL, AccessToken: "token"}); err != nil {
+		t.Fatalf("AddRepo() error = %v", err)
+	}
+	if _, _, err := c.PullChart(ctx, logr.Discard(), "bookinfo", "bookinfo", "1.0.0"); err != nil {
+		t.Fatalf("PullChart() error = %v", err)
+	}
+	if leaked {
+		t.Errorf("PullChart() sent the credentials of the repository to the package host %s", packageServer.URL)
+	}
+}
+
+func TestConfig_tlsFiles(t *testing.T) {
+	cfg := &Config{CertData: []byte("cert"), KeyData: []byte("key"), CaFile: "/etc/ssl/ca.crt"}
+	caFile, certFile, keyFile, cleanup, err := cfg.tlsFiles()
+	if err != nil {
+		t.Fatalf("tlsFiles() error = %v", err)
+	}
+	if caFile != cfg.CaFile {
+		t.Errorf("tlsFiles() CA file = %v, want %v", caFile, cfg.CaFile)
+	}
+	for path, want := range map[string]string{certFile: "cert", keyFile: "key"} {
+		if got, err := os.ReadFile(path); err != nil || string(got) != want {
+			t.Errorf("tlsFiles() file %s = %q, %v, want %q", path, got, err, want)
+		}
+	}
+	cleanup()
+	if _, err := os.Stat(certFile); !os.IsNotExist(err) {
+		t.Errorf("tlsFiles() cleanup did not remove %s", certFile)
+	}
+}
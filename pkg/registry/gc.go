@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/revision"
+	"github.com/Azure/Orkestra/pkg/utils"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// StagingGC removes the staged charts that are no longer referenced by the current spec
+// or by the retained revisions of any ApplicationGroup. It runs every interval and
+// whenever it is triggered, i.e. when an ApplicationGroup is deleted.
+type StagingGC struct {
+	Client   client.Client
+	Store    StagingStore
+	History  *revision.History
+	Interval time.Duration
+	Log      logr.Logger
+
+	trigger chan struct{}
+}
+
+// NewStagingGC is the constructor for the staging garbage collector. A zero interval disables the
+// periodic collection so that the staged charts are only collected when the collector is triggered
+func NewStagingGC(c client.Client, store StagingStore, history *revision.History, interval time.Duration, l logr.Logger) *StagingGC {
+	return &StagingGC{
+		Client:   c,
+		Store:    store,
+		History:  history,
+		Interval: interval,
+		Log:      l,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Trigger requests a collection without waiting for it. It is a no-op on a nil collector
+func (gc *StagingGC) Trigger() {
+	if gc == nil {
+		return
+	}
+	select {
+	case gc.trigger <- struct{}{}:
+	default:
+		// A collection is already pending
+	}
+}
+
+// Start runs the collector until the context is cancelled, it implements the manager.Runnable interface
+func (gc *StagingGC) Start(ctx context.Context) error {
+	var tick <-chan time.Time
+	if gc.Interval > 0 {
+		ticker := time.NewTicker(gc.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick:
+		case <-gc.trigger:
+		}
+		if err := gc.Collect(ctx); err != nil {
+			gc.Log.Error(err, "failed to garbage collect the staged charts")
+		}
+	}
+}
+
+// NeedLeaderElection makes sure that only the leader deletes the staged charts
+func (gc *StagingGC) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect deletes the staged chart versions that are not referenced by any ApplicationGroup
+func (gc *StagingGC) Collect(ctx context.Context) error {
+	// List the staged charts before the ApplicationGroups, so that the charts staged
+	// for an ApplicationGroup created in the meantime are never considered for deletion
+	staged, err := gc.Store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the staged charts: %w", err)
+	}
+
+	appGroups := &v1alpha1.ApplicationGroupList{}
+	if err := gc.Client.List(ctx, appGroups); err != nil {
+		return fmt.Errorf("failed to list the application groups: %w", err)
+	}
+	refs := newChartReferences()
+	for i := range appGroups.Items {
+		appGroup := &appGroups.Items[i]
+		// The finalizer is removed once the reverse workflow has deleted the releases of the ApplicationGroup
+		if !appGroup.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(appGroup, v1alpha1.AppGroupFinalizer) {
+			continue
+		}
+		specs, err := gc.History.Specs(ctx, appGroup)
+		if err != nil {
+			return fmt.Errorf("failed to get the revisions of %s: %w", appGroup.Name, err)
+		}
+		refs.addApplicationGroup(appGroup, append([]*v1alpha1.ApplicationGroupSpec{&appGroup.Spec}, specs...))
+	}
+
+	var deleted int
+	for chartName, versions := range staged {
+		for _, version := range versions {
+			if refs.has(chartName, version) {
+				continue
+			}
+			gc.Log.V(1).Info("deleting unreferenced staged chart", "chart-name", chartName, "chart-version", version)
+			if err := gc.Store.Delete(ctx, chartName, version); err != nil {
+				return fmt.Errorf("failed to delete staged chart %s-%s: %w", chartName, version, err)
+			}
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		gc.Log.Info("garbage collected the staged charts", "deleted", deleted)
+	}
+	return nil
+}
+
+// chartReferences holds the staged chart versions by chart name
+type chartReferences struct {
+	versions map[string]map[string]bool
+	// all holds the charts that are referenced in every version
+	all map[string]bool
+}
+
+func newChartReferences() *chartReferences {
+	return &chartReferences{
+		versions: make(map[string]map[string]bool),
+		all:      make(map[string]bool),
+	}
+}
+
+func (r *chartReferences) add(chartName, version string) {
+	if r.versions[chartName] == nil {
+		r.versions[chartName] = make(map[string]bool)
+	}
+	r.versions[chartName][version] = true
+}
+
+func (r *chartReferences) has(chartName, version string) bool {
+	return r.versions[chartName][version] || r.all[chartName]
+}
+
+// addApplicationGroup references the application charts of the specs and their subcharts. The staged versions
+// of the subcharts and of the charts from Git repositories and buckets are only known from the status, which is
+// also what the rollback workflows stage them with. The subcharts of the applications that are missing from the
+// status are kept in every version. The embedded dependencies that are staged without being declared as
+// subcharts in the spec are only known from the status.
+func (r *chartReferences) addApplicationGroup(appGroup *v1alpha1.ApplicationGroup, specs []*v1alpha1.ApplicationGroupSpec) {
+	statuses := make(map[string]v1alpha1.ApplicationStatus, len(appGroup.Status.Applications))
+	for _, app := range appGroup.Status.Applications {
+		statuses[app.Name] = app
+	}
+	for _, spec := range specs {
+		for _, application := range spec.Applications {
+			if application.Spec.Chart == nil {
+				continue
+			}
+			chartName := utils.ConvertToDNS1123(application.Spec.Chart.Name)
+			r.add(chartName, application.Spec.Chart.Version)
+
+			status, ok := statuses[application.Name]
+			if ok {
+				r.add(chartName, status.Version)
+			}
+			if !ok {
+				for _, sc := range application.Spec.Subcharts {
+					for _, name := range subchartNames(application, sc.Name) {
+						r.all[name] = true
+					}
+				}
+			}
+			for subchartName, subchartStatus := range status.Subcharts {
+				for _, name := range subchartNames(application, subchartName) {
+					r.add(name, subchartStatus.Version)
+				}
+			}
+		}
+	}
+}
+
+// subchartNames returns the names the subchart of the application is staged under. The subcharts are
+// staged under the chart name, while the releases refer to them by the application name
+func subchartNames(application v1alpha1.Application, subchartName string) []string {
+	return []string{utils.GetSubchartName(application.Spec.Chart.Name, subchartName), utils.GetSubchartName(application.Name, subchartName)}
+}
@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/revision"
+	"github.com/Azure/Orkestra/pkg/utils"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"helm.sh/helm/v3/pkg/chart"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func gcAppGroup(generation int64, version, subchartVersion string) *v1alpha1.ApplicationGroup {
+	return &v1alpha1.ApplicationGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "bookinfo",
+			UID:        "bookinfo-uid",
+			Generation: generation,
+			Finalizers: []string{v1alpha1.AppGroupFinalizer},
+		},
+		Spec: v1alpha1.ApplicationGroupSpec{
+			Applications: []v1alpha1.Application{
+				{
+					DAG: v1alpha1.DAG{Name: "bookinfo"},
+					Spec: v1alpha1.ApplicationSpec{
+						Chart:     &v1alpha1.ChartRef{Name: "bookinfo", Version: version},
+						Subcharts: []v1alpha1.DAG{{Name: "productpage"}},
+					},
+				},
+			},
+		},
+		Status: v1alpha1.ApplicationGroupStatus{
+			Applications: []v1alpha1.ApplicationStatus{
+				{
+					Name:        "bookinfo",
+					ChartStatus: v1alpha1.ChartStatus{Version: version},
+					Subcharts: map[string]v1alpha1.ChartStatus{
+						"productpage": {Version: subchartVersion},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestStagingGC_Collect(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	history := revision.NewHistory(c, "orkestra")
+
+	// The previous revision of the ApplicationGroup is retained, while the current spec is being rolled out
+	if err := history.Record(ctx, gcAppGroup(1, "1.0.0", "0.1.0")); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	appGroup := gcAppGroup(2, "1.1.0", "0.2.0")
+	if err := c.Create(ctx, appGroup); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	store, err := NewLocalStore(t.TempDir(), "http://orkestra.orkestra:8082")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	subchartName := utils.GetSubchartName("bookinfo", "productpage")
+	for name, versions := range map[string][]string{
+		"bookinfo":   {"0.9.0", "1.0.0", "1.1.0"},
+		subchartName: {"0.1.0", "0.2.0"},
+		"ambassador": {"6.6.0"},
+	} {
+		for _, version := range versions {
+			ch := &chart.Chart{Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: version}}
+			pkgPath, err := SaveChartPackage(ch, t.TempDir())
+			if err != nil {
+				t.Fatalf("SaveChartPackage() error = %v", err)
+			}
+			if err := store.Push(ctx, pkgPath, ch); err != nil {
+				t.Fatalf("Push() error = %v", err)
+			}
+		}
+	}
+
+	gc := NewStagingGC(c, store, history, time.Hour, logr.Discard())
+	if err := gc.Collect(ctx); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	got, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	// The index entries are sorted from the latest version
+	want := map[string][]string{
+		"bookinfo":   {"1.1.0", "1.0.0"},
+		subchartName: {"0.2.0"},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Collect() staged charts diff = %v", cmp.Diff(want, got))
+	}
+
+	// Nothing is referenced anymore once the reverse workflow has completed and the ApplicationGroup is deleted
+	appGroup.Finalizers = nil
+	if err := c.Update(ctx, appGroup); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := c.Delete(ctx, appGroup); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := gc.Collect(ctx); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if got, _ := store.List(ctx); len(got) != 0 {
+		t.Errorf("Collect() staged charts = %v, want none after the application group is deleted", got)
+	}
+}
+
+func TestStagingGC_Collect_EmbeddedDependency(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	// The embedded details dependency of the chart is staged as a subchart without being declared in the spec
+	appGroup := gcAppGroup(1, "1.0.0", "0.1.0")
+	appGroup.Status.Applications[0].Subcharts["details"] = v1alpha1.ChartStatus{Version: "0.3.0"}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(appGroup).Build()
+
+	store, err := NewLocalStore(t.TempDir(), "http://orkestra.orkestra:8082")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	subchartName := utils.GetSubchartName("bookinfo", "details")
+	for _, version := range []string{"0.2.0", "0.3.0"} {
+		ch := &chart.Chart{Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: subchartName, Version: version}}
+		pkgPath, err := SaveChartPackage(ch, t.TempDir())
+		if err != nil {
+			t.Fatalf("SaveChartPackage() error = %v", err)
+		}
+		if err := store.Push(ctx, pkgPath, ch); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+
+	gc := NewStagingGC(c, store, revision.NewHistory(c, "orkestra"), time.Hour, logr.Discard())
+	if err := gc.Collect(ctx); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	got, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := map[string][]string{subchartName: {"0.3.0"}}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Collect() staged charts diff = %v", cmp.Diff(want, got))
+	}
+}
+
+func TestStagingGC_Trigger(t *testing.T) {
+	var gc *StagingGC
+	// A nil collector is used when the controller is run without the staging garbage collection
+	gc.Trigger()
+
+	gc = NewStagingGC(nil, nil, nil, 0, logr.Discard())
+	gc.Trigger()
+	gc.Trigger()
+	if len(gc.trigger) != 1 {
+		t.Errorf("Trigger() pending collections = %d, want 1", len(gc.trigger))
+	}
+}
@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"helm.sh/helm/v3/pkg/getter"
+)
+
+// httpGetter downloads the repository index, the chart packages and the provenance files of helm repositories
+// with the credentials of the registry config. Unlike the helm HTTP getter it takes the TLS client certificate
+// and the CA bundle from memory and sends the access token of the config.
+type httpGetter struct {
+	cfg    *Config
+	client *http.Client
+}
+
+func newHTTPGetter(cfg *Config) (*httpGetter, error) {
+	client, err := cfg.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	return &httpGetter{cfg: cfg, client: client}, nil
+}
+
+// Get downloads the URL, the options are ignored since the getter is configured with the registry config.
+// Like helm without --pass-credentials, the credentials are only sent to the host of the registry,
+// since the repository index may refer to chart packages on other hosts.
+func (g *httpGetter) Get(href string, _ ...getter.Option) (*bytes.Buffer, error) {
+	req, err := http.NewRequest(http.MethodGet, href, nil)
+	if err != nil {
+		return nil, err
+	}
+	if sameHost(g.cfg.URL, req.URL) {
+		g.cfg.authorize(req)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s : %s", href, resp.Status)
+	}
+
+	buf := &bytes.Buffer{}
+	_, err = io.Copy(buf, resp.Body)
+	return buf, err
+}
+
+// sameHost returns whether the URL has the scheme and the host of the registry URL
+func sameHost(registryURL string, u *url.URL) bool {
+	r, err := url.Parse(registryURL)
+	if err != nil {
+		return false
+	}
+	return r.Scheme == u.Scheme && r.Host == u.Host
+}
+
+// httpProviders returns the getter providers of the helm repository with the registry config
+func httpProviders(cfg *Config) getter.Providers {
+	return getter.Providers{
+		{
+			Schemes: []string{"http", "https"},
+			New: func(...getter.Option) (getter.Getter, error) {
+				return newHTTPGetter(cfg)
+			},
+		},
+	}
+}
@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// LocalStore stages the charts in a directory of the controller and serves
+// them as a helm repository, so that no ChartMuseum deployment is required
+type LocalStore struct {
+	dir string
+	url string
+
+	// mu serializes the updates of the chart packages and the repository index.
+	// The packages and the index are replaced with a rename, so that they are read and served without it
+	mu sync.Mutex
+}
+
+// NewLocalStore is the constructor for the local staging store. The url is the in-cluster
+// URL that the HelmRepository uses to reach the file server of the controller
+func NewLocalStore(dir, url string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the local staging store requires a directory")
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	s := &LocalStore{dir: dir, url: url}
+	if err := s.reindex(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Push copies the chart package to the store directory and updates the repository index
+func (s *LocalStore) Push(ctx context.Context, pkgPath string, ch *chart.Chart) error {
+	dst := s.packagePath(ch.Name(), ch.Metadata.Version)
+	if filepath.Clean(pkgPath) == dst {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.reindex()
+	}
+
+	// The package is copied outside of the lock to a temporary file that is not indexed
+	tmp, err := s.tempFile(filepath.Base(dst))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if err := copyFile(pkgPath, tmp); err != nil {
+		return fmt.Errorf("failed to copy chart package to %s: %w", dst, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("failed to copy chart package to %s: %w", dst, err)
+	}
+	return s.reindex()
+}
+
+// Exists checks for the chart package in the store directory
+func (s *LocalStore) Exists(ctx context.Context, chartName, version string) (bool, error) {
+	if _, err := os.Stat(s.packagePath(chartName, version)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the chart package from the store directory and updates the repository index
+func (s *LocalStore) Delete(ctx context.Context, chartName, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.packagePath(chartName, version)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return s.reindex()
+}
+
+// List returns the chart versions of the repository index
+func (s *LocalStore) List(ctx context.Context) (map[string][]string, error) {
+	index, err := repo.LoadIndexFile(filepath.Join(s.dir, "index.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the repository index: %w", err)
+	}
+	charts := make(map[string][]string, len(index.Entries))
+	for name, versions := range index.Entries {
+		for _, v := range versions {
+			charts[name] = append(charts[name], v.Version)
+		}
+	}
+	return charts, nil
+}
+
+// URL returns the in-cluster URL of the file server
+func (s *LocalStore) URL() string {
+	return s.url
+}
+
+// Ready is a no-op since the store is served by the controller itself
+func (s *LocalStore) Ready(ctx context.Context) error {
+	return nil
+}
+
+// ServeHTTP serves the repository index and the chart packages
+func (s *LocalStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.FileServer(http.Dir(s.dir)).ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the repository on the address until the context is done.
+// It returns the error of the server, i.e. when it fails to bind the address
+func (s *LocalStore) ListenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: s}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-errChan:
+		return fmt.Errorf("failed to serve the local staging store on %s: %w", addr, err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+func (s *LocalStore) packagePath(chartName, version string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s-%s.tgz", chartName, version))
+}
+
+// reindex regenerates the index.yaml of the repository from the chart packages in the store directory.
+// The index is written to a temporary file that replaces index.yaml, so that it is never served partially written
+func (s *LocalStore) reindex() error {
+	index, err := repo.IndexDirectory(s.dir, s.url)
+	if err != nil {
+		return fmt.Errorf("failed to index the chart packages: %w", err)
+	}
+	index.SortEntries()
+	tmp, err := s.tempFile("index.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if err := index.WriteFile(tmp, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, "index.yaml"))
+}
+
+// tempFile returns the path of a new temporary file in the store directory, where it is renamed from.
+// Its name does not end with .tgz, so that it is not indexed
+func (s *LocalStore) tempFile(name string) (string, error) {
+	f, err := os.CreateTemp(s.dir, "."+name+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,318 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/deislabs/oras/pkg/content"
+	"github.com/deislabs/oras/pkg/oras"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// The media types match the ones used by the helm registry client
+// so that the charts can be consumed by the helm cli and vice versa
+const (
+	// HelmChartConfigMediaType is the media type of the helm chart manifest config
+	HelmChartConfigMediaType = "application/vnd.cncf.helm.config.v1+json"
+	// HelmChartContentLayerMediaType is the media type of the helm chart package content
+	HelmChartContentLayerMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	// LegacyHelmChartContentLayerMediaType is the media type of the helm chart package content
+	// pushed by the helm cli before v3.7
+	LegacyHelmChartContentLayerMediaType = "application/tar+gzip"
+)
+
+// ociReference returns the OCI reference of the chart version in the registry,
+// i.e. oci://myregistry.azurecr.io/charts and ambassador:6.6.0 give myregistry.azurecr.io/charts/ambassador:6.6.0
+func ociReference(registryURL, chartName, version string) string {
+	return fmt.Sprintf("%s/%s:%s",
+		strings.Trim(strings.TrimPrefix(registryURL, v1alpha1.OCIScheme+"://"), "/"),
+		strings.Trim(chartName, "/"),
+		version,
+	)
+}
+
+// ociResolver returns the resolver for the OCI registry authenticated with the credentials of the registry config.
+// The registry client of helm v3.6 is internal to helm, so the charts are pushed and pulled with the
+// same oras and containerd versions that it is built on.
+func ociResolver(cfg *Config) (remotes.Resolver, error) {
+	httpClient, authorizer, err := ociClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// The access token is sent with every request, registries that accept it never challenge for credentials
+	return docker.NewResolver(docker.ResolverOptions{
+		Headers: cfg.tokenHeader(),
+		Hosts: docker.ConfigureDefaultRegistries(
+			docker.WithClient(httpClient),
+			docker.WithAuthorizer(authorizer),
+			docker.WithPlainHTTP(docker.MatchLocalhost),
+		),
+	}), nil
+}
+
+// ociClient returns the HTTP client for the OCI registry and the authorizer that answers the authentication
+// challenges of the registry with the basic auth credentials of the registry config
+func ociClient(cfg *Config) (*http.Client, docker.Authorizer, error) {
+	httpClient, err := cfg.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	authorizer := docker.NewDockerAuthorizer(
+		docker.WithAuthClient(httpClient),
+		docker.WithAuthCreds(func(string) (string, string, error) {
+			return cfg.Username, cfg.Password, nil
+		}),
+	)
+	return httpClient, authorizer, nil
+}
+
+// pushOCIChart uploads the chart package to the OCI registry tagged with the chart version
+func pushOCIChart(ctx context.Context, cfg *Config, pkgPath string, ch *chart.Chart) error {
+	resolver, err := ociResolver(cfg)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(pkgPath)
+	if err != nil {
+		return fmt.Errorf("failed to read chart package %s: %w", pkgPath, err)
+	}
+	config, err := json.Marshal(ch.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chart metadata: %w", err)
+	}
+
+	store := content.NewMemoryStore()
+	configDesc := store.Add("", HelmChartConfigMediaType, config)
+	layers := []ocispec.Descriptor{store.Add("", HelmChartContentLayerMediaType, data)}
+
+	ref := ociReference(cfg.URL, ch.Name(), ch.Metadata.Version)
+	if _, err := oras.Push(ctx, resolver, ref, store, layers, oras.WithConfig(configDesc), oras.WithNameValidation(nil)); err != nil {
+		return fmt.Errorf("failed to push %s: %w", ref, err)
+	}
+	return nil
+}
+
+// OCIStore stages the charts in an OCI registry
+type OCIStore struct {
+	cfg *Config
+}
+
+// NewOCIStore is the constructor for the OCI staging store
+func NewOCIStore(cfg *Config) *OCIStore {
+	return &OCIStore{cfg: cfg}
+}
+
+// Push uploads the chart package to the OCI registry
+func (s *OCIStore) Push(ctx context.Context, pkgPath string, ch *chart.Chart) error {
+	return pushOCIChart(ctx, s.cfg, pkgPath, ch)
+}
+
+// Exists resolves the chart version tag in the OCI registry
+func (s *OCIStore) Exists(ctx context.Context, chartName, version string) (bool, error) {
+	resolver, err := ociResolver(s.cfg)
+	if err != nil {
+		return false, err
+	}
+	if _, _, err := resolver.Resolve(ctx, ociReference(s.cfg.URL, chartName, version)); err != nil {
+		if errdefs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the manifest of the chart version from the OCI registry (DELETE /v2/<name>/manifests/<digest>)
+func (s *OCIStore) Delete(ctx context.Context, chartName, version string) error {
+	resolver, err := ociResolver(s.cfg)
+	if err != nil {
+		return err
+	}
+	ref := ociReference(s.cfg.URL, chartName, version)
+	_, desc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	repository := s.repository(chartName)
+	resp, err := s.do(ctx, http.MethodDelete, fmt.Sprintf("/v2/%s/manifests/%s", repository, desc.Digest), fmt.Sprintf("repository:%s:delete", repository))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("failed to delete %s: %s", ref, resp.Status)
+	}
+}
+
+// List returns the tags of the chart repositories under the registry URL (GET /v2/_catalog and /v2/<name>/tags/list)
+func (s *OCIStore) List(ctx context.Context) (map[string][]string, error) {
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := s.getPaginated(ctx, "/v2/_catalog?n=1000", "registry:catalog:*", func(body io.Reader) error {
+		page := catalog.Repositories
+		if err := json.NewDecoder(body).Decode(&catalog); err != nil {
+			return err
+		}
+		catalog.Repositories = append(page, catalog.Repositories...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list the registry catalog: %w", err)
+	}
+
+	_, prefix := s.hostAndPrefix()
+	charts := make(map[string][]string)
+	for _, repository := range catalog.Repositories {
+		chartName := repository
+		if prefix != "" {
+			if !strings.HasPrefix(repository, prefix+"/") {
+				continue
+			}
+			chartName = strings.TrimPrefix(repository, prefix+"/")
+		}
+		tags, err := s.tags(ctx, repository)
+		if err != nil {
+			return nil, err
+		}
+		if len(tags) > 0 {
+			charts[chartName] = tags
+		}
+	}
+	return charts, nil
+}
+
+// tags returns the tags of the registry repository (GET /v2/<name>/tags/list)
+func (s *OCIStore) tags(ctx context.Context, repository string) ([]string, error) {
+	var tags []string
+	if err := s.getPaginated(ctx, fmt.Sprintf("/v2/%s/tags/list", repository), fmt.Sprintf("repository:%s:pull", repository), func(body io.Reader) error {
+		var list struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.NewDecoder(body).Decode(&list); err != nil {
+			return err
+		}
+		tags = append(tags, list.Tags...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list the tags of %s: %w", repository, err)
+	}
+	return tags, nil
+}
+
+// hostAndPrefix splits the registry URL into the registry host and the repository prefix of the charts,
+// i.e. oci://myregistry.azurecr.io/charts gives myregistry.azurecr.io and charts
+func (s *OCIStore) hostAndPrefix() (string, string) {
+	u := strings.Trim(strings.TrimPrefix(s.cfg.URL, v1alpha1.OCIScheme+"://"), "/")
+	if i := strings.Index(u, "/"); i >= 0 {
+		return u[:i], u[i+1:]
+	}
+	return u, ""
+}
+
+// repository returns the name of the registry repository that holds the chart versions
+func (s *OCIStore) repository(chartName string) string {
+	if _, prefix := s.hostAndPrefix(); prefix != "" {
+		return prefix + "/" + chartName
+	}
+	return chartName
+}
+
+// getPaginated follows the Link header of the registry API responses and calls decode with every page
+func (s *OCIStore) getPaginated(ctx context.Context, path, scope string, decode func(io.Reader) error) error {
+	for path != "" {
+		resp, err := s.do(ctx, http.MethodGet, path, scope)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("GET %s: %s", path, resp.Status)
+		}
+		err = decode(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		path = nextPage(resp.Header.Get("Link"))
+	}
+	return nil
+}
+
+// nextPage returns the path of the next page from the Link header, i.e. </v2/_catalog?last=b&n=1000>; rel="next"
+func nextPage(link string) string {
+	if !strings.Contains(link, `rel="next"`) {
+		return ""
+	}
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return link[start+1 : end]
+}
+
+// do sends the request to the registry API with the credentials for the token scope
+func (s *OCIStore) do(ctx context.Context, method, path, scope string) (*http.Response, error) {
+	host, _ := s.hostAndPrefix()
+	scheme := "https"
+	if isLocalhost, _ := docker.MatchLocalhost(host); isLocalhost {
+		scheme = "http"
+	}
+	url := fmt.Sprintf("%s://%s%s", scheme, host, path)
+
+	httpClient, authorizer, err := ociClient(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	ctx = docker.WithScope(ctx, scope)
+	// Retry once with the credentials requested by the authentication challenge
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range s.cfg.tokenHeader() {
+			req.Header[k] = v
+		}
+		if err := authorizer.Authorize(ctx, req); err != nil {
+			return nil, err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if err := authorizer.AddResponses(ctx, []*http.Response{resp}); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// URL returns the oci:// URL of the registry
+func (s *OCIStore) URL() string {
+	return s.cfg.URL
+}
+
+// Ready is a no-op since the registry is not part of the deployment
+func (s *OCIStore) Ready(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+)
+
+func Test_ociReference(t *testing.T) {
+	tests := []struct {
+		name        string
+		registryURL string
+		isOCI       bool
+		want        string
+	}{
+		{
+			name:        "OCI Registry",
+			registryURL: "oci://myregistry.azurecr.io/charts",
+			isOCI:       true,
+			want:        "myregistry.azurecr.io/charts/ambassador:6.6.0",
+		},
+		{
+			name:        "OCI Registry With Trailing Slash",
+			registryURL: "oci://localhost:5000/",
+			isOCI:       true,
+			want:        "localhost:5000/ambassador:6.6.0",
+		},
+		{
+			name:        "Helm Repository",
+			registryURL: "https://www.getambassador.io",
+			isOCI:       false,
+			want:        "https://www.getambassador.io/ambassador:6.6.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v1alpha1.IsOCI(tt.registryURL); got != tt.isOCI {
+				t.Errorf("v1alpha1.IsOCI() = %v, want %v", got, tt.isOCI)
+			}
+			if got := ociReference(tt.registryURL, "ambassador", "6.6.0"); got != tt.want {
+				t.Errorf("ociReference() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nextPage(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{
+			name: "Next Page",
+			link: `</v2/_catalog?last=charts%2Fbookinfo&n=1000>; rel="next"`,
+			want: "/v2/_catalog?last=charts%2Fbookinfo&n=1000",
+		},
+		{
+			name: "Last Page",
+			link: "",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextPage(tt.link); got != tt.want {
+				t.Errorf("nextPage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,47 @@
 package registry
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"strings"
+	"path/filepath"
+	"time"
 
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/metrics"
 	"github.com/go-logr/logr"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
 )
 
-func (c *Client) PullChart(l logr.Logger, repoKey, chartName, version string) (string, *chart.Chart, error) {
-	// logic is derived from the "helm pull" command from the helm cli package
-	l.WithValues("repo-key", repoKey, "chart-name", chartName, "chart-version", version)
+// cacheDir is the directory of the target directory that holds the chart packages by digest
+const cacheDir = "cache"
+
+// chartPackage locates a chart package in a registry
+type chartPackage struct {
+	// digest of the package, if known before the package is downloaded
+	digest digest.Digest
+	// fileName of the package, the provenance files refer to the package by file name
+	fileName string
+	// fetch downloads the package
+	fetch func(w io.Writer) error
+}
+
+// PullChart pulls the chart version from the registry into the chart cache of the target directory and loads it.
+// The chart packages are cached by digest, which is read from the repository index or the OCI manifest before the
+// package is downloaded, so that unchanged charts are not downloaded again and charts with the same name and version
+// from different registries never collide. Every pull is configured with the credentials of its own registry,
+// so that the charts of different applications can be pulled concurrently.
+func (c *Client) PullChart(ctx context.Context, l logr.Logger, repoKey, chartName, version string) (_ string, _ *chart.Chart, err error) {
+	defer func(start time.Time) { metrics.RecordChartOperation(metrics.PullOperation, start, err) }(time.Now())
+
+	l = l.WithValues("repo-key", repoKey, "chart-name", chartName, "chart-version", version)
 
 	l.V(3).Info("pulling chart")
 
@@ -22,37 +51,36 @@ func (c *Client) PullChart(l logr.Logger, repoKey, chartName, version string) (s
 		return "", nil, fmt.Errorf("failed to find registry with repoKey %s Name %s Version %s in registries map : %w", repoKey, chartName, version, err)
 	}
 
-	c.cfg.pull.Username = rCfg.Username
-	c.cfg.pull.Password = rCfg.Password
-	c.cfg.pull.CaFile = rCfg.CaFile
-	c.cfg.pull.CaFile = rCfg.CaFile
-	c.cfg.pull.CertFile = rCfg.CertFile
-	c.cfg.pull.KeyFile = rCfg.KeyFile
-	c.cfg.pull.DestDir = c.TargetDir
-
-	filePath := fmt.Sprintf("%s/%s-%s.tgz", strings.TrimSuffix(c.TargetDir, "/"), chartName, version)
+	var pkg *chartPackage
+	if v1alpha1.IsOCI(rCfg.URL) {
+		pkg, err = ociChartPackage(ctx, rCfg, chartName, version)
+	} else {
+		pkg, err = c.helmChartPackage(repoKey, rCfg, chartName, version)
+	}
+	if err != nil {
+		l.Error(err, "failed to locate chart in repo")
+		return "", nil, fmt.Errorf("failed to locate chart in repoKey %s Name %s Version %s : %w", repoKey, chartName, version, err)
+	}
 
-	if _, err = os.Stat(filePath); os.IsNotExist(err) {
-		l.V(3).Info("chart artifact not found in target directory - downloading")
-		_, err = c.cfg.pull.Run(chartURL(rCfg.URL, chartName, version))
+	var filePath string
+	var data []byte
+	// The package is downloaded again if it was removed from the cache in the meantime
+	for attempt := 0; attempt < 2; attempt++ {
+		filePath, err = c.cachedChart(l, pkg)
 		if err != nil {
 			l.Error(err, "failed to pull chart from repo")
 			return "", nil, fmt.Errorf("failed to pull chart from repoKey %s Name %s Version %s in registries map : %w", repoKey, chartName, version, err)
 		}
-	} else {
-		l.V(3).Info("chart artifact found in target directory - skip downloading")
+		if data, err = os.ReadFile(filePath); !os.IsNotExist(err) {
+			break
+		}
 	}
-
-	_, err = c.cfg.pull.ChartPathOptions.LocateChart(filePath, c.cfg.pull.Settings)
 	if err != nil {
-		l.Error(err, "failed to locate chart in filesystem")
-		return "", nil, fmt.Errorf("failed to locate chart in filesystem at path %s : %w", filePath, err)
+		l.Error(err, "failed to read chart from the chart cache")
+		return "", nil, fmt.Errorf("failed to read chart from the chart cache at path %s : %w", filePath, err)
 	}
 
-	var ch *chart.Chart
-
-	ch, err = loader.LoadFile(filePath)
-
+	ch, err := loader.LoadArchive(bytes.NewReader(data))
 	if err != nil {
 		l.Error(err, "failed to load chart")
 		return "", nil, fmt.Errorf("failed to load chart: %w", err)
@@ -64,3 +92,153 @@ func (c *Client) PullChart(l logr.Logger, repoKey, chartName, version string) (s
 
 	return filePath, ch, nil
 }
+
+// cachedChart returns the path of the chart package in the chart cache, and downloads it first if it is not
+// cached yet. The package is written to a temporary file and moved in place once its digest has been checked,
+// so that concurrent pulls never read a partially written package.
+func (c *Client) cachedChart(l logr.Logger, pkg *chartPackage) (string, error) {
+	dir := filepath.Join(c.TargetDir, cacheDir)
+	if pkg.digest != "" {
+		filePath := cachedChartPath(dir, pkg.digest, pkg.fileName)
+		if _, err := os.Stat(filePath); err == nil {
+			l.V(3).Info("chart artifact found in the chart cache - skip downloading", "digest", pkg.digest)
+			return filePath, nil
+		}
+	}
+	l.V(3).Info("chart artifact not found in the chart cache - downloading")
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, "download-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	digester := digest.SHA256.Digester()
+	err = pkg.fetch(io.MultiWriter(tmp, digester.Hash()))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	d := digester.Digest()
+	if pkg.digest != "" && d != pkg.digest {
+		return "", fmt.Errorf("chart package digest %s does not match the expected digest %s", d, pkg.digest)
+	}
+	filePath := cachedChartPath(dir, d, pkg.fileName)
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// cachedChartPath returns the path of the chart package with the digest in the chart cache
+func cachedChartPath(dir string, d digest.Digest, fileName string) string {
+	return filepath.Join(dir, d.Encoded(), fileName)
+}
+
+// helmChartVersion looks up the chart version in the repository index downloaded when the registry was added
+// and resolves the URL of its package, which may be relative to the repository URL or on another host
+func (c *Client) helmChartVersion(repoKey string, cfg *Config, chartName, version string) (*repo.ChartVersion, string, error) {
+	index, err := c.loadIndex(repoKey)
+	if err != nil {
+		return nil, "", err
+	}
+	cv, err := index.Get(chartName, version)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(cv.URLs) == 0 {
+		return nil, "", fmt.Errorf("chart %s version %s has no downloadable URLs", chartName, version)
+	}
+	chartURL, err := repo.ResolveReferenceURL(cfg.URL, cv.URLs[0])
+	if err != nil {
+		return nil, "", err
+	}
+	return cv, chartURL, nil
+}
+
+// helmChartPackage locates the package of the chart version in the helm repository
+func (c *Client) helmChartPackage(repoKey string, cfg *Config, chartName, version string) (*chartPackage, error) {
+	cv, chartURL, err := c.helmChartVersion(repoKey, cfg, chartName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &chartPackage{
+		fileName: chartFileName(chartName, version),
+		fetch: func(w io.Writer) error {
+			g, err := helmGetter(cfg)
+			if err != nil {
+				return err
+			}
+			b, err := g.Get(chartURL)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, b)
+			return err
+		},
+	}
+	if cv.Digest != "" {
+		pkg.digest = digest.NewDigestFromEncoded(digest.SHA256, cv.Digest)
+	}
+	return pkg, nil
+}
+
+// ociChartPackage looks up the chart content layer in the manifest of the chart version in the OCI registry
+func ociChartPackage(ctx context.Context, cfg *Config, chartName, version string) (*chartPackage, error) {
+	resolver, err := ociResolver(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ref := ociReference(cfg.URL, chartName, version)
+	name, desc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &ocispec.Manifest{}
+	if err := fetchJSON(ctx, fetcher, desc, manifest); err != nil {
+		return nil, fmt.Errorf("failed to fetch the manifest of %s: %w", ref, err)
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != HelmChartContentLayerMediaType && layer.MediaType != LegacyHelmChartContentLayerMediaType {
+			continue
+		}
+		layer := layer
+		return &chartPackage{
+			digest:   layer.Digest,
+			fileName: chartFileName(chartName, version),
+			fetch: func(w io.Writer) error {
+				rc, err := fetcher.Fetch(ctx, layer)
+				if err != nil {
+					return err
+				}
+				defer rc.Close()
+				_, err = io.Copy(w, rc)
+				return err
+			},
+		}, nil
+	}
+	return nil, fmt.Errorf("manifest of %s does not contain a chart content layer", ref)
+}
+
+// chartFileName returns the file name of the chart package
+func chartFileName(chartName, version string) string {
+	return fmt.Sprintf("%s-%s.tgz", chartName, version)
+}
+
+// helmGetter returns a getter for the helm repository authenticated with the credentials of the registry config
+func helmGetter(cfg *Config) (getter.Getter, error) {
+	return newHTTPGetter(cfg)
+}
@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/opencontainers/go-digest"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestClient_PullChart(t *testing.T) {
+	ctx := context.Background()
+
+	packages := make(map[string][]byte)
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		data, err := os.ReadFile(testChartPackage(t, version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		packages["/bookinfo-"+version+".tgz"] = data
+	}
+	var downloads int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := packages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&downloads, 1)
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	index := repo.NewIndexFile()
+	digests := map[string]string{
+		"1.0.0": digest.FromBytes(packages["/bookinfo-1.0.0.tgz"]).Encoded(),
+		// 1.1.0 is published with the digest of another package
+		"1.1.0": digest.FromBytes(packages["/bookinfo-1.0.0.tgz"]).Encoded(),
+	}
+	for version, d := range digests {
+		if err := index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "bookinfo", Version: version}, "bookinfo-"+version+".tgz", server.URL, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexPath := filepath.Join(t.TempDir(), "index.yaml")
+	if err := index.WriteFile(indexPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{
+		TargetDir:  t.TempDir(),
+		registries: map[string]*Config{"bookinfo": {Name: "bookinfo", URL: server.URL}},
+		indexFiles: map[string]string{"bookinfo": indexPath},
+	}
+
+	// Concurrent pulls of the same chart never read a partially written package
+	var wg sync.WaitGroup
+	paths := make([]string, 4)
+	errs := make([]error, 4)
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], _, errs[i] = c.PullChart(ctx, logr.Discard(), "bookinfo", "bookinfo", "1.0.0")
+		}(i)
+	}
+	wg.Wait()
+	for i := range paths {
+		if errs[i] != nil {
+			t.Fatalf("PullChart() error = %v", errs[i])
+		}
+		if paths[i] != paths[0] {
+			t.Errorf("PullChart() = %v, want %v", paths[i], paths[0])
+		}
+	}
+	if want := filepath.Join(c.TargetDir, cacheDir, digests["1.0.0"], "bookinfo-1.0.0.tgz"); paths[0] != want {
+		t.Errorf("PullChart() = %v, want %v", paths[0], want)
+	}
+
+	// The cached package is not downloaded again
+	before := atomic.LoadInt32(&downloads)
+	_, ch, err := c.PullChart(ctx, logr.Discard(), "bookinfo", "bookinfo", "1.0.0")
+	if err != nil {
+		t.Fatalf("PullChart() error = %v", err)
+	}
+	if ch.Metadata.Version != "1.0.0" {
+		t.Errorf("PullChart() chart version = %v, want %v", ch.Metadata.Version, "1.0.0")
+	}
+	if after := atomic.LoadInt32(&downloads); after != before {
+		t.Errorf("PullChart() downloaded the cached chart again, downloads = %v, want %v", after, before)
+	}
+
+	// The package is downloaded again if it was removed from the cache
+	if err := os.RemoveAll(filepath.Join(c.TargetDir, cacheDir)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.PullChart(ctx, logr.Discard(), "bookinfo", "bookinfo", "1.0.0"); err != nil {
+		t.Fatalf("PullChart() error = %v", err)
+	}
+
+	if _, _, err := c.PullChart(ctx, logr.Discard(), "bookinfo", "bookinfo", "1.1.0"); err == nil {
+		t.Errorf("PullChart() of a package not matching its digest error = nil, want error")
+	}
+	if _, _, err := c.PullChart(ctx, logr.Discard(), "bookinfo", "bookinfo", "2.0.0"); err == nil {
+		t.Errorf("PullChart() of an unknown version error = nil, want error")
+	}
+}
+
+func TestClient_PullChart_OCI(t *testing.T) {
+	ctx := context.Background()
+
+	reg := newTestOCIRegistry("charts/bookinfo")
+	config := reg.add(HelmChartConfigMediaType, []byte(`{"name":"bookinfo"}`), nil)
+	data, err := os.ReadFile(testChartPackage(t, "1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer := reg.add(HelmChartContentLayerMediaType, data, nil)
+	reg.tag(t, "1.0.0", config, layer)
+	server := httptest.NewServer(reg)
+	defer server.Close()
+	c := &Client{
+		TargetDir:  t.TempDir(),
+		registries: map[string]*Config{"bookinfo": {Name: "bookinfo", URL: "oci://" + server.Listener.Addr().String() + "/charts"}},
+	}
+
+	filePath, ch, err := c.PullChart(ctx, logr.Discard(), "bookinfo", "bookinfo", "1.0.0")
+	if err != nil {
+		t.Fatalf("PullChart() error = %v", err)
+	}
+	if want := filepath.Join(c.TargetDir, cacheDir, layer.Digest.Encoded(), "bookinfo-1.0.0.tgz"); filePath != want {
+		t.Errorf("PullChart() = %v, want %v", filePath, want)
+	}
+	if ch.Metadata.Name != "bookinfo" || ch.Metadata.Version != "1.0.0" {
+		t.Errorf("PullChart() chart = %s-%s, want bookinfo-1.0.0", ch.Metadata.Name, ch.Metadata.Version)
+	}
+
+	if _, _, err := c.PullChart(ctx, logr.Discard(), "bookinfo", "bookinfo", "2.0.0"); err == nil {
+		t.Errorf("PullChart() of an unknown version error = nil, want error")
+	}
+}
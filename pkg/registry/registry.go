@@ -4,21 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/chartmuseum/helm-push/pkg/chartmuseum"
 	"github.com/chartmuseum/helm-push/pkg/helm"
 	"github.com/go-logr/logr"
 	"github.com/gofrs/flock"
 	"gopkg.in/yaml.v2"
-	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/cli"
-	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/repo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -31,25 +28,34 @@ const (
 	defaultTargetDir = "/etc/orkestra/charts/pull/"
 )
 
+// The fields of the auth secret of a helm repository, OCI registry or staging repository
+const (
+	// UsernameSecretKey and PasswordSecretKey are the basic auth credentials
+	UsernameSecretKey = "username"
+	PasswordSecretKey = "password"
+	// AccessTokenSecretKey is the access token, sent as a bearer token unless the auth header is set
+	AccessTokenSecretKey = "token"
+	// AuthHeaderSecretKey is the name of the header the access token is sent in
+	AuthHeaderSecretKey = "authHeader"
+	// CertSecretKey and KeySecretKey are the PEM encoded TLS client certificate and key
+	CertSecretKey = "tls.crt"
+	KeySecretKey  = "tls.key"
+	// CASecretKey is the PEM encoded CA bundle that the server certificate is verified with
+	CASecretKey = "ca.crt"
+)
+
 var (
 	errEmptyKey         = errors.New("key cannot be an empty string")
 	errEmptyRegistries  = errors.New("registries map cannot be nil or empty")
 	errRegistryNotFound = errors.New("registry entry not found in registries map")
 )
 
-type helmActionConfig struct {
-	pull *action.Pull
-	push *chartmuseum.Client
-}
-
 type Client struct {
 	l logr.Logger
 	// rfile is the handle to the helm repo file configuration
 	rfile *repo.File
 	// repoFilePath is the location of the helm repo file
 	repoFilePath string
-	// cfg stores the helm pull and push configurations
-	cfg helmActionConfig
 	// TargetDir is the location where the downloaded chart is saved
 	TargetDir string
 	// settings
@@ -57,32 +63,30 @@ type Client struct {
 
 	// Registries maps the registry name to it's configuration data
 	registries map[string]*Config
+
+	// indexFiles maps the registry name to the repository index downloaded when the registry was added
+	indexFiles map[string]string
+
+	// mu guards the registries, the index files and the helm repo file
+	mu sync.RWMutex
 }
 
 // NewClient is the constructor for the registry client
 func NewClient(l logr.Logger, opts ...Option) (*Client, error) {
-	cm, err := chartmuseum.NewClient()
-	if err != nil {
-		return nil, err
-	}
-
 	c := &Client{
-		l:         l,
-		TargetDir: defaultTargetDir,
-		rfile:     repo.NewFile(),
-		cfg: helmActionConfig{
-			pull: action.NewPull(),
-			push: cm,
-		},
+		l:          l,
+		TargetDir:  defaultTargetDir,
+		rfile:      repo.NewFile(),
 		settings:   cli.New(),
 		registries: make(map[string]*Config),
+		indexFiles: make(map[string]string),
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
-	err = c.init()
+	err := c.init()
 	if err != nil {
 		return nil, err
 	}
@@ -125,61 +129,73 @@ func (c *Client) init() error {
 	if c.TargetDir == "" {
 		c.TargetDir = defaultTargetDir
 	}
-	// Set destination directory where we download the chart
-	c.cfg.pull.DestDir = c.TargetDir
-
-	// Initialize the pull and push clients
-	// Pull client config
-	actionCfg := new(action.Configuration)
-	helmDriver := "memory"
-
-	if err := actionCfg.Init(c.settings.RESTClientGetter(), c.settings.Namespace(), helmDriver, c.l.Info); err != nil {
-		return fmt.Errorf("unable to initialize action configuration: %w", err)
-	}
-
-	c.cfg.pull.Settings = c.settings
-
-	// Push Client
-	// no init required
 
 	return nil
 }
 
-func (c *Client) AddRepo(cfg *Config) error {
+// RepoKey returns the key the registry of the application is added under. The key is qualified with the name of
+// the ApplicationGroup, so that applications with the same name in different groups never share a registry config.
+func RepoKey(appGroupName, appName string) string {
+	return appGroupName + "_" + appName
+}
+
+// AddRepo adds the registry under the config name and downloads the index of the helm repositories.
+// The client keeps its own copy of the config, so that the config is not changed by the caller while
+// charts are pulled from the registry.
+func (c *Client) AddRepo(config *Config) error {
+	cfg := *config
+
+	// OCI registries don't serve a repository index
+	if orkestrav1alpha1.IsOCI(cfg.URL) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.registries[cfg.Name] = &cfg
+		return nil
+	}
+
+	// The credentials are not written to the helm repo file, the index is downloaded with the getter of the config
 	e := repo.Entry{
-		Name:     cfg.Name,
-		URL:      cfg.URL,
-		Username: cfg.Username,
-		Password: cfg.Password,
-		CertFile: cfg.CertFile,
-		KeyFile:  cfg.KeyFile,
-		CAFile:   cfg.CaFile,
+		Name: cfg.Name,
+		URL:  cfg.URL,
+
+		InsecureSkipTLSverify: cfg.InsecureSkipVerify,
 	}
 
-	r, err := repo.NewChartRepository(&e, getter.All(c.settings))
+	r, err := repo.NewChartRepository(&e, httpProviders(&cfg))
 	if err != nil {
 		return err
 	}
+	if c.settings.RepositoryCache != "" {
+		r.CachePath = c.settings.RepositoryCache
+	}
 
-	if _, err := r.DownloadIndexFile(); err != nil {
+	indexFile, err := r.DownloadIndexFile()
+	if err != nil {
 		return fmt.Errorf("%q is not a valid chart repository or cannot be reached: %w", cfg.URL, err)
 	}
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.indexFiles[cfg.Name] = indexFile
+
 	c.rfile.Update(&e)
 
 	if err := c.rfile.WriteFile(c.repoFilePath, 0644); err != nil {
 		return err
 	}
 
-	c.registries[cfg.Name] = cfg
+	c.registries[cfg.Name] = &cfg
 
 	return nil
 }
 
+// RegistryConfig returns a copy of the config of the registry added under the name
 func (c *Client) RegistryConfig(name string) (*Config, error) {
 	if name == "" {
 		return nil, errEmptyKey
 	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	if c.registries == nil {
 		return nil, errEmptyRegistries
 	}
@@ -188,75 +204,112 @@ func (c *Client) RegistryConfig(name string) (*Config, error) {
 		return nil, errRegistryNotFound
 	}
 
-	return v, nil
+	cfg := *v
+	return &cfg, nil
 }
 
-func chartURL(repo, chart, version string) string {
-	s := fmt.Sprintf("%s/%s-%s.tgz",
-		strings.Trim(repo, "/"),
-		strings.Trim(chart, "/"),
-		version,
-	)
-
-	// Validate the URL
-	if u, err := url.ParseRequestURI(s); u != nil || err != nil {
-		return s
+// loadIndex loads the repository index downloaded when the registry was added
+func (c *Client) loadIndex(repoKey string) (*repo.IndexFile, error) {
+	c.mu.RLock()
+	indexFile, ok := c.indexFiles[repoKey]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no repository index found for repoKey %s : %w", repoKey, errRegistryNotFound)
 	}
-	return ""
+	index, err := repo.LoadIndexFile(indexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the repository index of repoKey %s : %w", repoKey, err)
+	}
+	return index, nil
 }
 
 func SaveChartPackage(ch *chart.Chart, dir string) (string, error) {
 	return helm.CreateChartPackage(&helm.Chart{V3: ch}, dir)
 }
 
-func GetHelmRepoConfig(app *orkestrav1alpha1.Application, c client.Client) (*Config, error) {
+// GetHelmRepoConfig returns the config of the helm repository or OCI registry of the application chart with the
+// credentials of the auth secret, if any. The auth secret is read in the namespace of the reference or in the default
+// namespace if the reference has none, so that a rotated secret is picked up the next time the config is built.
+func GetHelmRepoConfig(app *orkestrav1alpha1.Application, defaultNamespace string, c client.Reader) (*Config, error) {
 	cfg := &Config{
 		Name: app.Name,
 		URL:  app.Spec.Chart.URL,
 	}
 
 	if app.Spec.Chart.AuthSecretRef != nil {
-		if app.Spec.Chart.AuthSecretRef.Namespace == "" {
-			app.Spec.Chart.AuthSecretRef.Namespace = "default"
-		}
-		creds := &v1.Secret{}
-		key := types.NamespacedName{
-			Name:      app.Spec.Chart.AuthSecretRef.Name,
-			Namespace: app.Spec.Chart.AuthSecretRef.Namespace,
+		if err := setCredentials(cfg, SecretKey(app.Spec.Chart.AuthSecretRef, defaultNamespace), c); err != nil {
+			return nil, err
 		}
+	}
+	return cfg, nil
+}
 
-		err := c.Get(context.Background(), key, creds)
-		if err != nil {
+// GetStagingRepoConfig returns the config of the staging repository with the credentials
+// of the auth secret, if any
+func GetStagingRepoConfig(name, url string, authSecretRef *v1.ObjectReference, c client.Reader) (*Config, error) {
+	cfg := &Config{
+		Name: name,
+		URL:  url,
+	}
+	if authSecretRef != nil {
+		if err := setCredentials(cfg, SecretKey(authSecretRef, ""), c); err != nil {
 			return nil, err
 		}
+	}
+	return cfg, nil
+}
 
-		data := creds.Data
+// SecretKey returns the key of the referenced secret, in the default namespace if the reference has no namespace
+func SecretKey(ref *v1.ObjectReference, defaultNamespace string) types.NamespacedName {
+	key := types.NamespacedName{
+		Name:      ref.Name,
+		Namespace: ref.Namespace,
+	}
+	if key.Namespace == "" {
+		key.Namespace = defaultNamespace
+	}
+	return key
+}
 
-		if v, ok := data["username"]; ok {
-			cfg.Username = string(v)
-		}
+// setCredentials sets the credentials of the auth secret on the config. The TLS client certificate,
+// client key and CA bundle are kept in memory and never written to the helm repo file.
+func setCredentials(cfg *Config, key types.NamespacedName, c client.Reader) error {
+	creds := &v1.Secret{}
+	err := c.Get(context.Background(), key, creds)
+	if err != nil {
+		return fmt.Errorf("failed to get the auth secret %s : %w", key, err)
+	}
 
-		if v, ok := data["password"]; ok {
-			cfg.Password = string(v)
-		}
+	data := creds.Data
 
-		if v, ok := data["username"]; ok {
-			cfg.Username = string(v)
-		}
+	if v, ok := data[UsernameSecretKey]; ok {
+		cfg.Username = string(v)
+	}
 
-		if v, ok := data["tls.crt"]; ok {
-			cfg.CertFile = string(v)
-		}
+	if v, ok := data[PasswordSecretKey]; ok {
+		cfg.Password = string(v)
+	}
 
-		if v, ok := data["tls.key"]; ok {
-			cfg.KeyFile = string(v)
-		}
+	if v, ok := data[AccessTokenSecretKey]; ok {
+		cfg.AccessToken = string(v)
+	}
 
-		if v, ok := data["ca.crt"]; ok {
-			cfg.CaFile = string(v)
-		}
+	if v, ok := data[AuthHeaderSecretKey]; ok {
+		cfg.AuthHeader = string(v)
 	}
-	return cfg, nil
+
+	if v, ok := data[CertSecretKey]; ok {
+		cfg.CertData = v
+	}
+
+	if v, ok := data[KeySecretKey]; ok {
+		cfg.KeyData = v
+	}
+
+	if v, ok := data[CASecretKey]; ok {
+		cfg.CaData = v
+	}
+	return nil
 }
 
 type CredentialsObjectReference struct {
@@ -0,0 +1,169 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// testHelmRepo serves a helm repository with a single bookinfo chart version that requires basic auth
+func testHelmRepo(t *testing.T, username, password, version string) *httptest.Server {
+	return httptest.NewServer(testHelmRepoHandler(t, version, func(r *http.Request) bool {
+		u, p, ok := r.BasicAuth()
+		return ok && u == username && p == password
+	}))
+}
+
+// testHelmRepoHandler serves a helm repository with a single bookinfo chart version to the authorized requests
+func testHelmRepoHandler(t *testing.T, version string, authorized func(r *http.Request) bool) http.Handler {
+	data, err := os.ReadFile(testChartPackage(t, version))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := repo.NewIndexFile()
+	if err := index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "bookinfo", Version: version}, "bookinfo-"+version+".tgz", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	indexPath := filepath.Join(t.TempDir(), "index.yaml")
+	if err := index.WriteFile(indexPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+	indexData, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/index.yaml":
+			_, _ = w.Write(indexData)
+		case "/bookinfo-" + version + ".tgz":
+			_, _ = w.Write(data)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// newTestClient returns a registry client that keeps the helm repo file, the repository indexes and the charts in a temporary directory
+func newTestClient(t *testing.T) *Client {
+	dir := t.TempDir()
+	settings := cli.New()
+	settings.RepositoryConfig = filepath.Join(dir, "repositories.yaml")
+	settings.RepositoryCache = filepath.Join(dir, "cache")
+	return &Client{
+		l:            logr.Discard(),
+		rfile:        repo.NewFile(),
+		repoFilePath: settings.RepositoryConfig,
+		TargetDir:    filepath.Join(dir, "charts"),
+		settings:     settings,
+		registries:   make(map[string]*Config),
+		indexFiles:   make(map[string]string),
+	}
+}
+
+func TestRepoKey(t *testing.T) {
+	if RepoKey("group-a", "bookinfo") == RepoKey("group-b", "bookinfo") {
+		t.Errorf("RepoKey() of the same application in different groups must differ")
+	}
+	if RepoKey("a-b", "c") == RepoKey("a", "b-c") {
+		t.Errorf("RepoKey() must not collide for different group and application names")
+	}
+}
+
+func TestClient_RegistryConfig_Copy(t *testing.T) {
+	c := &Client{registries: make(map[string]*Config)}
+	cfg := &Config{Name: "bookinfo", URL: "oci://registry.example.com/charts", Username: "user"}
+	if err := c.AddRepo(cfg); err != nil {
+		t.Fatalf("AddRepo() error = %v", err)
+	}
+	cfg.Username = "changed"
+
+	got, err := c.RegistryConfig("bookinfo")
+	if err != nil {
+		t.Fatalf("RegistryConfig() error = %v", err)
+	}
+	if got.Username != "user" {
+		t.Errorf("RegistryConfig() username = %v, want %v", got.Username, "user")
+	}
+	got.Password = "changed"
+	if got, _ := c.RegistryConfig("bookinfo"); got.Password != "" {
+		t.Errorf("RegistryConfig() password = %v, want empty", got.Password)
+	}
+}
+
+// TestClient_Concurrent adds and pulls from registries with different credentials concurrently, as the reconciles
+// of different ApplicationGroups do, and checks that the credentials and the charts never leak between them.
+// Run with -race to detect unsynchronized access to the client.
+func TestClient_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	const groups = 8
+	versions := make([]string, groups)
+	servers := make([]*httptest.Server, groups)
+	for i := range servers {
+		versions[i] = fmt.Sprintf("1.%d.0", i)
+		servers[i] = testHelmRepo(t, fmt.Sprintf("user-%d", i), fmt.Sprintf("password-%d", i), versions[i])
+		defer servers[i].Close()
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, groups*3)
+	for attempt := 0; attempt < 3; attempt++ {
+		for i := range servers {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				// Every group has a bookinfo application pulled from its own repository
+				key := RepoKey(fmt.Sprintf("group-%d", i), "bookinfo")
+				cfg := &Config{
+					Name:     key,
+					URL:      servers[i].URL,
+					Username: fmt.Sprintf("user-%d", i),
+					Password: fmt.Sprintf("password-%d", i),
+				}
+				if err := c.AddRepo(cfg); err != nil {
+					errs <- fmt.Errorf("AddRepo() of group %d error = %w", i, err)
+					return
+				}
+				version, err := c.ResolveVersion(ctx, key, "bookinfo", "*")
+				if err != nil {
+					errs <- fmt.Errorf("ResolveVersion() of group %d error = %w", i, err)
+					return
+				}
+				if version != versions[i] {
+					errs <- fmt.Errorf("ResolveVersion() of group %d = %v, want %v", i, version, versions[i])
+					return
+				}
+				_, ch, err := c.PullChart(ctx, logr.Discard(), key, "bookinfo", version)
+				if err != nil {
+					errs <- fmt.Errorf("PullChart() of group %d error = %w", i, err)
+					return
+				}
+				if ch.Metadata.Version != versions[i] {
+					errs <- fmt.Errorf("PullChart() of group %d chart version = %v, want %v", i, ch.Metadata.Version, versions[i])
+				}
+			}(i)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Masterminds/semver/v3"
+)
+
+// ResolveVersion returns the latest chart version of the registry that satisfies the version constraint.
+// Exact versions are returned as is, while version ranges are resolved against the repository index
+// downloaded when the registry was added, or against the tags of the OCI repository of the chart.
+func (c *Client) ResolveVersion(ctx context.Context, repoKey, chartName, constraint string) (string, error) {
+	if _, err := semver.StrictNewVersion(constraint); err == nil {
+		return constraint, nil
+	}
+
+	rCfg, err := c.RegistryConfig(repoKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to find registry with repoKey %s in registries map : %w", repoKey, err)
+	}
+
+	if v1alpha1.IsOCI(rCfg.URL) {
+		store := NewOCIStore(rCfg)
+		tags, err := store.tags(ctx, store.repository(chartName))
+		if err != nil {
+			return "", err
+		}
+		return latestMatching(tags, constraint)
+	}
+
+	index, err := c.loadIndex(repoKey)
+	if err != nil {
+		return "", err
+	}
+	cv, err := index.Get(chartName, constraint)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve chart %s version %q : %w", chartName, constraint, err)
+	}
+	return cv.Version, nil
+}
+
+// latestMatching returns the latest of the versions that satisfies the version constraint.
+// Versions that are not valid semantic versions are ignored.
+func latestMatching(versions []string, constraint string) (string, error) {
+	if constraint == "" {
+		constraint = "*"
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart version constraint %q : %w", constraint, err)
+	}
+	var matching []*semver.Version
+	for _, version := range versions {
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			continue
+		}
+		if c.Check(v) {
+			matching = append(matching, v)
+		}
+	}
+	if len(matching) == 0 {
+		return "", fmt.Errorf("no chart version found for constraint %q", constraint)
+	}
+	sort.Sort(sort.Reverse(semver.Collection(matching)))
+	return matching[0].Original(), nil
+}
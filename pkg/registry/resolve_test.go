@@ -0,0 +1,158 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func Test_latestMatching(t *testing.T) {
+	versions := []string{"1.1.0", "1.2.0", "1.2.3", "1.3.0-rc.1", "2.0.0", "latest"}
+	tests := []struct {
+		name       string
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "Any Version",
+			constraint: "*",
+			want:       "2.0.0",
+		},
+		{
+			name:       "Empty Constraint",
+			constraint: "",
+			want:       "2.0.0",
+		},
+		{
+			name:       "Patch Range",
+			constraint: "~1.2",
+			want:       "1.2.3",
+		},
+		{
+			name:       "Major Range Without Prereleases",
+			constraint: "^1.0.0",
+			want:       "1.2.3",
+		},
+		{
+			name:       "Prerelease Range",
+			constraint: "~1.3.0-0",
+			want:       "1.3.0-rc.1",
+		},
+		{
+			name:       "No Matching Version",
+			constraint: "~3.0",
+			wantErr:    true,
+		},
+		{
+			name:       "Invalid Constraint",
+			constraint: "not-a-version",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := latestMatching(versions, tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("latestMatching() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("latestMatching() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ResolveVersion(t *testing.T) {
+	ctx := context.Background()
+
+	index := repo.NewIndexFile()
+	for _, version := range []string{"1.1.0", "1.2.0", "1.2.3", "2.0.0"} {
+		if err := index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "bookinfo", Version: version}, "bookinfo-"+version+".tgz", "https://charts.example.com", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexFile := filepath.Join(t.TempDir(), "index.yaml")
+	if err := index.WriteFile(indexFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/charts/bookinfo/tags/list" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": "charts/bookinfo", "tags": []string{"1.1.0", "1.2.0", "1.2.5"}})
+	}))
+	defer server.Close()
+
+	c := &Client{
+		registries: map[string]*Config{
+			"helm": {Name: "helm", URL: "https://charts.example.com"},
+			"oci":  {Name: "oci", URL: "oci://" + server.Listener.Addr().String() + "/charts"},
+		},
+		indexFiles: map[string]string{"helm": indexFile},
+	}
+
+	tests := []struct {
+		name       string
+		repoKey    string
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "Exact Version",
+			repoKey:    "unknown",
+			constraint: "1.0.0",
+			want:       "1.0.0",
+		},
+		{
+			name:       "Helm Repository Range",
+			repoKey:    "helm",
+			constraint: "~1.2",
+			want:       "1.2.3",
+		},
+		{
+			name:       "Helm Repository Any Version",
+			repoKey:    "helm",
+			constraint: "*",
+			want:       "2.0.0",
+		},
+		{
+			name:       "Helm Repository No Matching Version",
+			repoKey:    "helm",
+			constraint: "~3.0",
+			wantErr:    true,
+		},
+		{
+			name:       "OCI Registry Range",
+			repoKey:    "oci",
+			constraint: "~1.2",
+			want:       "1.2.5",
+		},
+		{
+			name:       "Unknown Registry",
+			repoKey:    "unknown",
+			constraint: "*",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ResolveVersion(ctx, tt.repoKey, "bookinfo", tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
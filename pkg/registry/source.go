@@ -0,0 +1,225 @@
+package registry
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/Azure/Orkestra/pkg/metrics"
+	"github.com/Azure/Orkestra/pkg/utils"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	fluxsourcev1beta1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// SourceInterval is the interval at which the Flux sources of the charts check for updates
+const SourceInterval = 5 * time.Minute
+
+// chartSource is the Flux GitRepository or Bucket that fetches the chart of an application
+type chartSource interface {
+	client.Object
+	fluxsourcev1beta1.Source
+	GetStatusConditions() *[]metav1.Condition
+}
+
+// SourceName returns the name of the Flux source that fetches the chart of the application
+func SourceName(appGroupName, appName string) string {
+	return utils.ConvertToDNS1123(appGroupName + "-" + appName)
+}
+
+// PullSourceChart fetches the application chart from its Git repository or bucket through a Flux source
+// owned by the ApplicationGroup. The source is created in the namespace of the auth secret, or in the given
+// namespace if the chart is public. It returns a wrapped meta.ErrChartSourceNotReady until the source-controller
+// has produced the artifact of the current source spec. The chart is renamed to the chart name of the spec.
+func (c *Client) PullSourceChart(ctx context.Context, l logr.Logger, k client.Client, appGroup *v1alpha1.ApplicationGroup, application *v1alpha1.Application, namespace string) (_ string, _ *chart.Chart, err error) {
+	defer func(start time.Time) { metrics.RecordChartOperation(metrics.PullOperation, start, err) }(time.Now())
+
+	chartRef := application.Spec.Chart
+	l = l.WithValues("source-kind", chartRef.GetSourceKind(), "source-url", chartRef.URL, "source-path", chartRef.GetSourcePath())
+	l.V(3).Info("pulling chart from source")
+
+	source, err := ensureChartSource(ctx, k, appGroup, application, namespace)
+	if err != nil {
+		l.Error(err, "failed to create or update the chart source")
+		return "", nil, err
+	}
+	artifact, err := sourceArtifact(source)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ch, err := fetchSourceChart(ctx, artifact.URL, chartRef.GetSourcePath())
+	if err != nil {
+		l.Error(err, "failed to fetch chart from the source artifact")
+		return "", nil, fmt.Errorf("failed to fetch chart from %s %s/%s revision %s : %w", chartRef.GetSourceKind(), source.GetNamespace(), source.GetName(), artifact.Revision, err)
+	}
+	ch.Metadata.Name = chartRef.Name
+
+	if !(ch.Metadata.Type == "application" || ch.Metadata.Type == "") {
+		return "", nil, fmt.Errorf("%s charts are not installable", ch.Metadata.Type)
+	}
+
+	// The packages are kept apart from the charts pulled from the helm repositories with the same name and version
+	dir := filepath.Join(c.TargetDir, "sources", source.GetName())
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", nil, err
+	}
+	filePath, err := SaveChartPackage(ch, dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to save chart package : %w", err)
+	}
+	return filePath, ch, nil
+}
+
+// ensureChartSource creates or updates the Flux source of the application chart
+func ensureChartSource(ctx context.Context, k client.Client, appGroup *v1alpha1.ApplicationGroup, application *v1alpha1.Application, namespace string) (chartSource, error) {
+	chartRef := application.Spec.Chart
+	var secretRef *fluxmeta.LocalObjectReference
+	if chartRef.AuthSecretRef != nil {
+		secretRef = &fluxmeta.LocalObjectReference{Name: chartRef.AuthSecretRef.Name}
+		if chartRef.AuthSecretRef.Namespace != "" {
+			namespace = chartRef.AuthSecretRef.Namespace
+		}
+	}
+	objectMeta := metav1.ObjectMeta{Name: SourceName(appGroup.Name, application.Name), Namespace: namespace}
+	interval := metav1.Duration{Duration: SourceInterval}
+
+	var source chartSource
+	var mutate func()
+	switch chartRef.GetSourceKind() {
+	case fluxsourcev1beta1.GitRepositoryKind:
+		repository := &fluxsourcev1beta1.GitRepository{ObjectMeta: objectMeta}
+		source, mutate = repository, func() {
+			repository.Spec.URL = chartRef.URL
+			repository.Spec.SecretRef = secretRef
+			repository.Spec.Interval = interval
+			repository.Spec.Reference = chartRef.Git.Ref
+		}
+	case fluxsourcev1beta1.BucketKind:
+		bucket := &fluxsourcev1beta1.Bucket{ObjectMeta: objectMeta}
+		source, mutate = bucket, func() {
+			// The bucket endpoint is an address without the scheme, http:// endpoints are insecure
+			endpoint := strings.TrimPrefix(chartRef.URL, "https://")
+			bucket.Spec.Insecure = chartRef.Bucket.Insecure || strings.HasPrefix(endpoint, "http://")
+			bucket.Spec.Endpoint = strings.TrimSuffix(strings.TrimPrefix(endpoint, "http://"), "/")
+			bucket.Spec.Provider = chartRef.Bucket.Provider
+			if bucket.Spec.Provider == "" {
+				bucket.Spec.Provider = fluxsourcev1beta1.GenericBucketProvider
+			}
+			bucket.Spec.BucketName = chartRef.Bucket.BucketName
+			bucket.Spec.Region = chartRef.Bucket.Region
+			bucket.Spec.SecretRef = secretRef
+			bucket.Spec.Interval = interval
+		}
+	default:
+		return nil, fmt.Errorf("chart %s of application %s does not have a Git repository or bucket source", chartRef.Name, application.Name)
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, k, source, func() error {
+		mutate()
+		labels := source.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[v1alpha1.OwnershipLabel] = appGroup.Name
+		labels[v1alpha1.HeritageLabel] = v1alpha1.HeritageValue
+		source.SetLabels(labels)
+		return controllerutil.SetControllerReference(appGroup, source, k.Scheme())
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create or update %s %s/%s: %w", chartRef.GetSourceKind(), objectMeta.Namespace, objectMeta.Name, err)
+	}
+	return source, nil
+}
+
+// sourceArtifact returns the artifact of the source once the source-controller has fetched the current source spec
+func sourceArtifact(source chartSource) (*fluxsourcev1beta1.Artifact, error) {
+	var observedGeneration int64
+	switch s := source.(type) {
+	case *fluxsourcev1beta1.GitRepository:
+		observedGeneration = s.Status.ObservedGeneration
+	case *fluxsourcev1beta1.Bucket:
+		observedGeneration = s.Status.ObservedGeneration
+	}
+	ready := apimeta.FindStatusCondition(*source.GetStatusConditions(), fluxmeta.ReadyCondition)
+	if observedGeneration != source.GetGeneration() || ready == nil {
+		return nil, fmt.Errorf("%w: %s/%s has not been reconciled yet", meta.ErrChartSourceNotReady, source.GetNamespace(), source.GetName())
+	}
+	if ready.Status != metav1.ConditionTrue || source.GetArtifact() == nil {
+		return nil, fmt.Errorf("%w: %s/%s: %s", meta.ErrChartSourceNotReady, source.GetNamespace(), source.GetName(), ready.Message)
+	}
+	return source.GetArtifact(), nil
+}
+
+// fetchSourceChart downloads the tar.gz artifact of the source and loads the chart from the directory at the chart path
+func fetchSourceChart(ctx context.Context, artifactURL, chartPath string) (*chart.Chart, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, artifactURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download artifact %s: %s", artifactURL, resp.Status)
+	}
+	return loadArchiveChart(resp.Body, chartPath)
+}
+
+// loadArchiveChart loads the chart from the directory at the chart path of the tar.gz archive
+func loadArchiveChart(r io.Reader, chartPath string) (*chart.Chart, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	prefix := strings.Trim(path.Clean("/"+chartPath), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	var files []*loader.BufferedFile
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &loader.BufferedFile{Name: strings.TrimPrefix(name, prefix), Data: data})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no chart found at path %q", chartPath)
+	}
+	return loader.LoadFiles(files)
+}
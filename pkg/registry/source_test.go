@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/meta"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	fluxsourcev1beta1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// testArchive returns a tar.gz archive of a monorepo in the layout of the source-controller artifacts
+func testArchive(t *testing.T) []byte {
+	files := map[string]string{
+		"./README.md":                                 "# charts",
+		"./charts/bookinfo/Chart.yaml":                "apiVersion: v2\nname: bookinfo\nversion: 1.2.0\n",
+		"./charts/bookinfo/values.yaml":               "replicas: 1\n",
+		"./charts/bookinfo/templates/deployment.yaml": "kind: Deployment\n",
+		"./charts/ambassador/Chart.yaml":              "apiVersion: v2\nname: ambassador\nversion: 6.6.0\n",
+	}
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func Test_loadArchiveChart(t *testing.T) {
+	tests := []struct {
+		name      string
+		chartPath string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "Chart Directory",
+			chartPath: "charts/bookinfo",
+			want:      "1.2.0",
+		},
+		{
+			name:      "Chart Directory With Leading And Trailing Slashes",
+			chartPath: "./charts/bookinfo/",
+			want:      "1.2.0",
+		},
+		{
+			name:      "Missing Chart Directory",
+			chartPath: "charts/productpage",
+			wantErr:   true,
+		},
+		{
+			name:      "Repository Root Without Chart",
+			chartPath: "",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch, err := loadArchiveChart(bytes.NewReader(testArchive(t)), tt.chartPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadArchiveChart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && ch.Metadata.Version != tt.want {
+				t.Errorf("loadArchiveChart() version = %v, want %v", ch.Metadata.Version, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_PullSourceChart(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, v1alpha1.AddToScheme, fluxsourcev1beta1.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	k := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	archive := testArchive(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	appGroup := &v1alpha1.ApplicationGroup{ObjectMeta: metav1.ObjectMeta{Name: "demo", UID: "demo-uid"}}
+	application := &v1alpha1.Application{
+		DAG: v1alpha1.DAG{Name: "bookinfo"},
+		Spec: v1alpha1.ApplicationSpec{
+			Chart: &v1alpha1.ChartRef{
+				URL:  "https://github.com/Azure/orkestra",
+				Name: "bookinfo-monorepo",
+				Git: &v1alpha1.GitChartSource{
+					Ref:  &fluxsourcev1beta1.GitRepositoryRef{Branch: "main"},
+					Path: "charts/bookinfo",
+				},
+			},
+		},
+	}
+	c := &Client{TargetDir: t.TempDir()}
+
+	// The source is created on the first pull and the pull waits for its artifact
+	if _, _, err := c.PullSourceChart(ctx, logr.Discard(), k, appGroup, application, "orkestra"); !errors.Is(err, meta.ErrChartSourceNotReady) {
+		t.Fatalf("PullSourceChart() error = %v, want %v", err, meta.ErrChartSourceNotReady)
+	}
+	repository := &fluxsourcev1beta1.GitRepository{}
+	if err := k.Get(ctx, types.NamespacedName{Namespace: "orkestra", Name: SourceName("demo", "bookinfo")}, repository); err != nil {
+		t.Fatalf("failed to get the GitRepository: %v", err)
+	}
+	if repository.Spec.URL != "https://github.com/Azure/orkestra" || repository.Spec.Reference.Branch != "main" {
+		t.Errorf("GitRepository spec = %+v, want the chart repository and reference", repository.Spec)
+	}
+	if len(repository.OwnerReferences) != 1 || repository.OwnerReferences[0].Name != "demo" {
+		t.Errorf("GitRepository owner = %v, want the application group", repository.OwnerReferences)
+	}
+
+	repository.Status.ObservedGeneration = repository.Generation
+	repository.Status.Conditions = []metav1.Condition{{Type: fluxmeta.ReadyCondition, Status: metav1.ConditionTrue, Reason: "GitOperationSucceed"}}
+	repository.Status.Artifact = &fluxsourcev1beta1.Artifact{URL: server.URL + "/gitrepository/orkestra/demo-bookinfo/0f3c.tar.gz", Revision: "main/0f3c"}
+	if err := k.Update(ctx, repository); err != nil {
+		t.Fatalf("failed to update the GitRepository status: %v", err)
+	}
+
+	path, ch, err := c.PullSourceChart(ctx, logr.Discard(), k, appGroup, application, "orkestra")
+	if err != nil {
+		t.Fatalf("PullSourceChart() error = %v", err)
+	}
+	if ch.Name() != "bookinfo-monorepo" || ch.Metadata.Version != "1.2.0" {
+		t.Errorf("PullSourceChart() chart = %s-%s, want the chart renamed after the chart reference", ch.Name(), ch.Metadata.Version)
+	}
+	if path == "" {
+		t.Errorf("PullSourceChart() path is empty, want the path of the chart package")
+	}
+}
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/metrics"
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+const (
+	// ChartMuseumStagingStore stages the charts in a ChartMuseum helm repository
+	ChartMuseumStagingStore = "chartmuseum"
+	// OCIStagingStore stages the charts in an OCI registry
+	OCIStagingStore = "oci"
+	// LocalStagingStore stages the charts in a helm repository served by the controller
+	LocalStagingStore = "local"
+)
+
+// StagingStore is the backend of the staging repository that serves the modified
+// application and subcharts to the HelmReleases
+type StagingStore interface {
+	// Push uploads the chart package, overwriting the chart version if it is already staged
+	Push(ctx context.Context, pkgPath string, ch *chart.Chart) error
+
+	// Exists returns true if the chart version is staged
+	Exists(ctx context.Context, chartName, version string) (bool, error)
+
+	// Delete removes the chart version from the store, it is not an error if the chart version is not staged
+	Delete(ctx context.Context, chartName, version string) error
+
+	// List returns the staged versions of every chart in the store
+	List(ctx context.Context) (map[string][]string, error)
+
+	// URL returns the URL of the HelmRepository that the HelmReleases fetch the staged charts from
+	URL() string
+
+	// Ready returns an error if the store is not able to serve the staged charts
+	Ready(ctx context.Context) error
+}
+
+// NewStagingStore returns the staging store of the given kind. The registry config holds the URL and the
+// credentials of the ChartMuseum and OCI stores, while the local store keeps the charts in the given directory
+func NewStagingStore(kind string, cfg *Config, dir string) (StagingStore, error) {
+	switch kind {
+	case ChartMuseumStagingStore, "":
+		return NewChartMuseumStore(cfg), nil
+	case OCIStagingStore:
+		if !v1alpha1.IsOCI(cfg.URL) {
+			return nil, fmt.Errorf("the oci staging store requires an %s:// URL, got %q", v1alpha1.OCIScheme, cfg.URL)
+		}
+		return NewOCIStore(cfg), nil
+	case LocalStagingStore:
+		return NewLocalStore(dir, cfg.URL)
+	default:
+		return nil, fmt.Errorf("unknown staging store %q, must be one of %s, %s or %s", kind, ChartMuseumStagingStore, OCIStagingStore, LocalStagingStore)
+	}
+}
+
+// PushChart pushes the chart package to the staging store
+func PushChart(ctx context.Context, l logr.Logger, store StagingStore, pkgPath string, ch *chart.Chart) (err error) {
+	defer func(start time.Time) { metrics.RecordChartOperation(metrics.PushOperation, start, err) }(time.Now())
+
+	l = l.WithValues("chart-name", ch.Name(), "chart-version", ch.Metadata.Version)
+	l.V(3).Info("pushing chart")
+
+	if err := store.Push(ctx, pkgPath, ch); err != nil {
+		l.Error(err, "failed to push chart to the staging store")
+		return fmt.Errorf("failed to push chart package with Name %s Version %s to the staging store : %w", ch.Name(), ch.Metadata.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+func testChart(t *testing.T, dir string) (string, *chart.Chart) {
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "ambassador", Version: "6.6.0"},
+	}
+	path, err := SaveChartPackage(ch, dir)
+	if err != nil {
+		t.Fatalf("SaveChartPackage() error = %v", err)
+	}
+	return path, ch
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "http://orkestra.orkestra:8082")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	pkgPath, ch := testChart(t, t.TempDir())
+	if err := store.Push(ctx, pkgPath, ch); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, ".*.tmp")); len(tmp) != 0 {
+		t.Errorf("Push() left the temporary files %v", tmp)
+	}
+	if exists, err := store.Exists(ctx, "ambassador", "6.6.0"); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want the pushed chart to exist", exists, err)
+	}
+
+	server := httptest.NewServer(store)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/index.yaml")
+	if err != nil {
+		t.Fatalf("GET index.yaml error = %v", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read index.yaml: %v", err)
+	}
+	index := &repo.IndexFile{}
+	if err := yaml.Unmarshal(b, index); err != nil {
+		t.Fatalf("failed to unmarshal index.yaml: %v", err)
+	}
+	if !index.Has("ambassador", "6.6.0") {
+		t.Errorf("index.yaml = %s, want the pushed chart version", b)
+	}
+
+	if err := store.Delete(ctx, "ambassador", "6.6.0"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if exists, _ := store.Exists(ctx, "ambassador", "6.6.0"); exists {
+		t.Errorf("Exists() = true, want the deleted chart to be gone")
+	}
+	if err := store.Delete(ctx, "ambassador", "6.6.0"); err != nil {
+		t.Errorf("Delete() of a missing chart error = %v", err)
+	}
+}
+
+func TestLocalStore_ListenAndServe(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "http://orkestra.orkestra:8082")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	// The address is already bound, so the server fails to start
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	if err := store.ListenAndServe(ctx, l.Addr().String()); err == nil || ctx.Err() != nil {
+		t.Errorf("ListenAndServe() error = %v, want the bind error", err)
+	}
+}
+
+func TestChartMuseumStore(t *testing.T) {
+	ctx := context.Background()
+	charts := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/health":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/charts":
+			_, _ = w.Write([]byte(`{"ambassador":[{"name":"ambassador","version":"6.6.0"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/charts":
+			charts["/api/charts/ambassador/6.6.0"] = true
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && charts[r.URL.Path]:
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodDelete && charts[r.URL.Path]:
+			delete(charts, r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	store := NewChartMuseumStore(&Config{Name: "staging", URL: server.URL})
+	if err := store.Ready(ctx); err != nil {
+		t.Fatalf("Ready() error = %v", err)
+	}
+	pkgPath, ch := testChart(t, t.TempDir())
+	if err := store.Push(ctx, pkgPath, ch); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if exists, err := store.Exists(ctx, "ambassador", "6.6.0"); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want the pushed chart to exist", exists, err)
+	}
+	if charts, err := store.List(ctx); err != nil || len(charts["ambassador"]) != 1 || charts["ambassador"][0] != "6.6.0" {
+		t.Errorf("List() = %v, %v, want the pushed chart version", charts, err)
+	}
+	if err := store.Delete(ctx, "ambassador", "6.6.0"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if exists, err := store.Exists(ctx, "ambassador", "6.6.0"); err != nil || exists {
+		t.Errorf("Exists() = %v, %v, want the deleted chart to be gone", exists, err)
+	}
+}
+
+func TestNewStagingStore(t *testing.T) {
+	if _, err := NewStagingStore(OCIStagingStore, &Config{URL: "http://chartmuseum:8080"}, ""); err == nil {
+		t.Errorf("NewStagingStore() of an oci store with an http URL error = nil, want an error")
+	}
+	if _, err := NewStagingStore("s3", &Config{}, ""); err == nil {
+		t.Errorf("NewStagingStore() of an unknown store error = nil, want an error")
+	}
+	store, err := NewStagingStore(LocalStagingStore, &Config{URL: "http://orkestra.orkestra:8082"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStagingStore() error = %v", err)
+	}
+	if store.URL() != "http://orkestra.orkestra:8082" {
+		t.Errorf("URL() = %v, want the in-cluster URL of the local store", store.URL())
+	}
+}
@@ -0,0 +1,302 @@
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/containerd/containerd/remotes"
+	"github.com/go-logr/logr"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/provenance"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// KeyringSecretKey is the field of the verification secret that holds the PGP public keyring
+	KeyringSecretKey = "keyring"
+	// CosignPublicKeySecretKey is the field of the verification secret that holds the cosign public key
+	CosignPublicKeySecretKey = "cosign.pub"
+
+	// cosignSignatureAnnotation is the annotation of the cosign signature layers that holds the base64 encoded signature
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// maxManifestSize limits the size of the manifests and signature payloads read from the registry
+	maxManifestSize = 4 << 20
+)
+
+// GetVerificationKey returns the PGP public keyring or the cosign public key of the chart verification secret,
+// which is read in the default namespace if the secret reference has no namespace
+func GetVerificationKey(ctx context.Context, verify *v1alpha1.ChartVerification, provider, defaultNamespace string, c client.Reader) ([]byte, error) {
+	if verify.SecretRef == nil {
+		return nil, fmt.Errorf("%w: the verification secret is not set", meta.ErrChartVerificationFailed)
+	}
+	key := SecretKey(verify.SecretRef, defaultNamespace)
+	secret := &v1.Secret{}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("%w: failed to get the verification secret %s: %v", meta.ErrChartVerificationFailed, key, err)
+	}
+	field := KeyringSecretKey
+	if provider == v1alpha1.CosignVerificationProvider {
+		field = CosignPublicKeySecretKey
+	}
+	data, ok := secret.Data[field]
+	if !ok || len(data) == 0 {
+		return nil, fmt.Errorf("%w: the verification secret %s does not have the %q field", meta.ErrChartVerificationFailed, key, field)
+	}
+	return data, nil
+}
+
+// VerifyChart verifies the chart package at the file path, pulled from the registry, with the provenance file of the
+// chart and the PGP keyring or with the cosign signature of the chart and the cosign public key. It returns a wrapped
+// meta.ErrChartVerificationFailed if the chart is not signed or if its signature does not match the key.
+func (c *Client) VerifyChart(ctx context.Context, l logr.Logger, repoKey, chartName, version, filePath, provider string, key []byte) error {
+	l = l.WithValues("repo-key", repoKey, "chart-name", chartName, "chart-version", version, "provider", provider)
+	l.V(3).Info("verifying chart")
+
+	rCfg, err := c.RegistryConfig(repoKey)
+	if err != nil {
+		return fmt.Errorf("failed to find registry with repoKey %s in registries map : %w", repoKey, err)
+	}
+
+	switch provider {
+	case v1alpha1.PGPVerificationProvider:
+		if v1alpha1.IsOCI(rCfg.URL) {
+			return fmt.Errorf("%w: charts from OCI registries do not have provenance files", meta.ErrChartVerificationFailed)
+		}
+		var chartURL string
+		if _, chartURL, err = c.helmChartVersion(repoKey, rCfg, chartName, version); err == nil {
+			err = verifyProvenance(rCfg, chartURL, filePath, key)
+		}
+	case v1alpha1.CosignVerificationProvider:
+		if !v1alpha1.IsOCI(rCfg.URL) {
+			return fmt.Errorf("%w: cosign signatures are only supported for charts from OCI registries", meta.ErrChartVerificationFailed)
+		}
+		err = verifyCosignSignature(ctx, rCfg, chartName, version, filePath, key)
+	default:
+		return fmt.Errorf("%w: unknown verification provider %q", meta.ErrChartVerificationFailed, provider)
+	}
+	if err != nil {
+		l.Error(err, "failed to verify chart")
+		return fmt.Errorf("%w: chart %s-%s from repoKey %s : %v", meta.ErrChartVerificationFailed, chartName, version, repoKey, err)
+	}
+	return nil
+}
+
+// verifyProvenance downloads the provenance file published next to the chart package at the chart URL
+// and verifies the chart package at the file path against it and the keyring. The provenance file
+// is kept out of the chart cache, which is shared with the pulls of the other applications.
+func verifyProvenance(cfg *Config, chartURL, filePath string, keyring []byte) error {
+	g, err := helmGetter(cfg)
+	if err != nil {
+		return err
+	}
+	provURL := chartURL + ".prov"
+	prov, err := g.Get(provURL)
+	if err != nil {
+		return fmt.Errorf("failed to download the provenance file %s: %w", provURL, err)
+	}
+
+	dir, err := os.MkdirTemp("", "orkestra-provenance-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	provPath := filepath.Join(dir, filepath.Base(filePath)+".prov")
+	if err := os.WriteFile(provPath, prov.Bytes(), 0644); err != nil {
+		return err
+	}
+	keyringPath := filepath.Join(dir, "pubring.gpg")
+	if err := os.WriteFile(keyringPath, keyring, 0600); err != nil {
+		return err
+	}
+	sig, err := provenance.NewFromKeyring(keyringPath, "")
+	if err != nil {
+		return fmt.Errorf("failed to load the keyring: %w", err)
+	}
+	_, err = sig.Verify(filePath, provPath)
+	return err
+}
+
+// cosignPayload is the simple signing payload signed by cosign
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// verifyCosignSignature verifies that the manifest of the chart version is signed with the cosign public key
+// and that the chart package at the file path is the chart content layer of the signed manifest.
+// The signatures are stored by cosign in the same repository under the sha256-<manifest digest>.sig tag.
+func verifyCosignSignature(ctx context.Context, cfg *Config, chartName, version, filePath string, publicKey []byte) error {
+	pub, err := parsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	resolver, err := ociResolver(cfg)
+	if err != nil {
+		return err
+	}
+
+	ref := ociReference(cfg.URL, chartName, version)
+	name, desc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return err
+	}
+	manifest := &ocispec.Manifest{}
+	if err := fetchJSON(ctx, fetcher, desc, manifest); err != nil {
+		return fmt.Errorf("failed to fetch the manifest of %s: %w", ref, err)
+	}
+	if err := verifyChartLayer(manifest, filePath); err != nil {
+		return err
+	}
+
+	sigRef := ociReference(cfg.URL, chartName, strings.Replace(desc.Digest.String(), ":", "-", 1)+".sig")
+	sigName, sigDesc, err := resolver.Resolve(ctx, sigRef)
+	if err != nil {
+		return fmt.Errorf("no cosign signature found for %s: %w", ref, err)
+	}
+	sigFetcher, err := resolver.Fetcher(ctx, sigName)
+	if err != nil {
+		return err
+	}
+	sigManifest := &ocispec.Manifest{}
+	if err := fetchJSON(ctx, sigFetcher, sigDesc, sigManifest); err != nil {
+		return fmt.Errorf("failed to fetch the signature manifest %s: %w", sigRef, err)
+	}
+
+	for _, layer := range sigManifest.Layers {
+		signature, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		payload, err := fetchBlob(ctx, sigFetcher, layer)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the signature payload %s of %s: %w", layer.Digest, sigRef, err)
+		}
+		if err := verifyCosignPayload(pub, payload, signature, desc.Digest); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("no cosign signature of %s matches the public key", ref)
+}
+
+// verifyChartLayer checks that the chart package is the chart content layer of the manifest
+func verifyChartLayer(manifest *ocispec.Manifest, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	d, err := digest.FromReader(f)
+	if err != nil {
+		return err
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == HelmChartContentLayerMediaType || layer.MediaType == LegacyHelmChartContentLayerMediaType {
+			if layer.Digest != d {
+				return fmt.Errorf("chart package digest %s does not match the chart content layer %s", d, layer.Digest)
+			}
+			return nil
+		}
+	}
+	return errors.New("manifest does not contain a chart content layer")
+}
+
+// verifyCosignPayload verifies the base64 encoded signature of the payload and that the payload refers to the manifest digest
+func verifyCosignPayload(pub crypto.PublicKey, payload []byte, signature string, manifestDigest digest.Digest) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(pub, payload, sig); err != nil {
+		return err
+	}
+	p := &cosignPayload{}
+	if err := json.Unmarshal(payload, p); err != nil {
+		return err
+	}
+	if p.Critical.Image.DockerManifestDigest != manifestDigest.String() {
+		return fmt.Errorf("signature payload refers to manifest %s", p.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
+
+// parsePublicKey parses the PEM encoded public key generated by cosign
+func parsePublicKey(key []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("the cosign public key is not PEM encoded")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func verifySignature(pub crypto.PublicKey, payload, sig []byte) error {
+	hash := sha256.Sum256(payload)
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, hash[:], sig) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], sig)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, payload, sig) {
+			return errors.New("invalid ed25519 signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// fetchBlob fetches the content of the descriptor and verifies its digest
+func fetchBlob(ctx context.Context, fetcher remotes.Fetcher, desc ocispec.Descriptor) ([]byte, error) {
+	if desc.Size > maxManifestSize {
+		return nil, fmt.Errorf("%s exceeds the maximum size of %d bytes", desc.Digest, maxManifestSize)
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, maxManifestSize))
+	if err != nil {
+		return nil, err
+	}
+	if d := digest.FromBytes(b); d != desc.Digest {
+		return nil, fmt.Errorf("content digest %s does not match %s", d, desc.Digest)
+	}
+	return b, nil
+}
+
+func fetchJSON(ctx context.Context, fetcher remotes.Fetcher, desc ocispec.Descriptor, v interface{}) error {
+	b, err := fetchBlob(ctx, fetcher, desc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
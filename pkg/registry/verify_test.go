@@ -0,0 +1,297 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/go-logr/logr"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/crypto/openpgp"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func testChartPackage(t *testing.T, version string) string {
+	ch := &chart.Chart{Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "bookinfo", Version: version}}
+	pkgPath, err := SaveChartPackage(ch, t.TempDir())
+	if err != nil {
+		t.Fatalf("SaveChartPackage() error = %v", err)
+	}
+	return pkgPath
+}
+
+// testKeyring returns a PGP signer and its public keyring
+func testKeyring(t *testing.T) (*provenance.Signatory, []byte) {
+	entity, err := openpgp.NewEntity("orkestra", "test", "orkestra@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := &bytes.Buffer{}
+	if err := entity.Serialize(keyring); err != nil {
+		t.Fatal(err)
+	}
+	return &provenance.Signatory{Entity: entity}, keyring.Bytes()
+}
+
+func TestClient_VerifyChart_Provenance(t *testing.T) {
+	ctx := context.Background()
+	signer, keyring := testKeyring(t)
+	_, otherKeyring := testKeyring(t)
+
+	signedPath := testChartPackage(t, "1.0.0")
+	prov, err := signer.ClearSign(signedPath)
+	if err != nil {
+		t.Fatalf("ClearSign() error = %v", err)
+	}
+	// The index refers to the packages in another directory than the repository root
+	index := repo.NewIndexFile()
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		if err := index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "bookinfo", Version: version}, "packages/bookinfo-"+version+".tgz", "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexPath := filepath.Join(t.TempDir(), "index.yaml")
+	if err := index.WriteFile(indexPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+	indexData, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.yaml":
+			_, _ = w.Write(indexData)
+		case "/packages/bookinfo-1.0.0.tgz.prov":
+			_, _ = w.Write([]byte(prov))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	c := newTestClient(t)
+	if err := c.AddRepo(&Config{Name: "bookinfo", URL: server.URL}); err != nil {
+		t.Fatalf("AddRepo() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		version  string
+		filePath string
+		keyring  []byte
+		wantErr  bool
+	}{
+		{
+			name:     "Signed Chart",
+			version:  "1.0.0",
+			filePath: signedPath,
+			keyring:  keyring,
+		},
+		{
+			name:     "Signed Chart With Unknown Key",
+			version:  "1.0.0",
+			filePath: signedPath,
+			keyring:  otherKeyring,
+			wantErr:  true,
+		},
+		{
+			name:     "Tampered Chart",
+			version:  "1.0.0",
+			filePath: testChartPackage(t, "1.0.1"),
+			keyring:  keyring,
+			wantErr:  true,
+		},
+		{
+			name:     "Chart Without Provenance File",
+			version:  "1.1.0",
+			filePath: testChartPackage(t, "1.1.0"),
+			keyring:  keyring,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.VerifyChart(ctx, logr.Discard(), "bookinfo", "bookinfo", tt.version, tt.filePath, v1alpha1.PGPVerificationProvider, tt.keyring)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyChart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, meta.ErrChartVerificationFailed) {
+				t.Errorf("VerifyChart() error = %v, want %v", err, meta.ErrChartVerificationFailed)
+			}
+			// The provenance file is not written next to the package in the shared chart cache
+			if _, err := os.Stat(tt.filePath + ".prov"); !os.IsNotExist(err) {
+				t.Errorf("VerifyChart() wrote the provenance file next to the chart package %s", tt.filePath)
+			}
+		})
+	}
+}
+
+// testOCIRegistry is an in-memory registry that serves the manifests and blobs of a single repository
+type testOCIRegistry struct {
+	repository string
+	blobs      map[digest.Digest][]byte
+	mediaTypes map[digest.Digest]string
+	tags       map[string]digest.Digest
+}
+
+func newTestOCIRegistry(repository string) *testOCIRegistry {
+	return &testOCIRegistry{
+		repository: repository,
+		blobs:      make(map[digest.Digest][]byte),
+		mediaTypes: make(map[digest.Digest]string),
+		tags:       make(map[string]digest.Digest),
+	}
+}
+
+func (r *testOCIRegistry) add(mediaType string, data []byte, annotations map[string]string) ocispec.Descriptor {
+	d := digest.FromBytes(data)
+	r.blobs[d] = data
+	r.mediaTypes[d] = mediaType
+	return ocispec.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(data)), Annotations: annotations}
+}
+
+func (r *testOCIRegistry) tag(t *testing.T, tag string, config ocispec.Descriptor, layers ...ocispec.Descriptor) digest.Digest {
+	data, err := json.Marshal(ocispec.Manifest{Versioned: specs.Versioned{SchemaVersion: 2}, Config: config, Layers: layers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := r.add(ocispec.MediaTypeImageManifest, data, nil)
+	r.tags[tag] = desc.Digest
+	return desc.Digest
+}
+
+func (r *testOCIRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	prefix := "/v2/" + r.repository + "/"
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		http.NotFound(w, req)
+		return
+	}
+	kind, ref := filepath.Split(strings.TrimPrefix(req.URL.Path, prefix))
+	d, err := digest.Parse(ref)
+	if err != nil && kind == "manifests/" {
+		d = r.tags[ref]
+	}
+	data, ok := r.blobs[d]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", r.mediaTypes[d])
+	w.Header().Set("Docker-Content-Digest", d.String())
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
+
+// cosignSign returns the signature layer of the manifest digest signed with the key in the format of cosign
+func cosignSign(t *testing.T, r *testOCIRegistry, key *ecdsa.PrivateKey, manifestDigest digest.Digest) ocispec.Descriptor {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"%s"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, r.repository, manifestDigest))
+	hash := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.add("application/vnd.dev.cosign.simplesigning.v1+json", payload, map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)})
+}
+
+func testCosignKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestClient_VerifyChart_Cosign(t *testing.T) {
+	ctx := context.Background()
+	key, publicKey := testCosignKey(t)
+	otherKey, _ := testCosignKey(t)
+
+	reg := newTestOCIRegistry("charts/bookinfo")
+	config := reg.add(HelmChartConfigMediaType, []byte(`{"name":"bookinfo"}`), nil)
+	packages := make(map[string]string)
+	for _, version := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		packages[version] = testChartPackage(t, version)
+		data, err := os.ReadFile(packages[version])
+		if err != nil {
+			t.Fatal(err)
+		}
+		reg.tag(t, version, config, reg.add(HelmChartContentLayerMediaType, data, nil))
+	}
+	// 1.0.0 is signed with the key, 1.1.0 with another key and 1.2.0 is not signed
+	for version, signingKey := range map[string]*ecdsa.PrivateKey{"1.0.0": key, "1.1.0": otherKey} {
+		d := reg.tags[version]
+		reg.tag(t, strings.Replace(d.String(), ":", "-", 1)+".sig", config, cosignSign(t, reg, signingKey, d))
+	}
+	server := httptest.NewServer(reg)
+	defer server.Close()
+	c := &Client{registries: map[string]*Config{"bookinfo": {Name: "bookinfo", URL: "oci://" + server.Listener.Addr().String() + "/charts"}}}
+
+	tests := []struct {
+		name     string
+		version  string
+		filePath string
+		wantErr  bool
+	}{
+		{
+			name:     "Signed Chart",
+			version:  "1.0.0",
+			filePath: packages["1.0.0"],
+		},
+		{
+			name:     "Chart Signed With Another Key",
+			version:  "1.1.0",
+			filePath: packages["1.1.0"],
+			wantErr:  true,
+		},
+		{
+			name:     "Unsigned Chart",
+			version:  "1.2.0",
+			filePath: packages["1.2.0"],
+			wantErr:  true,
+		},
+		{
+			name:     "Package Not Matching The Signed Manifest",
+			version:  "1.0.0",
+			filePath: packages["1.2.0"],
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.VerifyChart(ctx, logr.Discard(), "bookinfo", "bookinfo", tt.version, tt.filePath, v1alpha1.CosignVerificationProvider, publicKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyChart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, meta.ErrChartVerificationFailed) {
+				t.Errorf("VerifyChart() error = %v, want %v", err, meta.ErrChartVerificationFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package revision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/meta"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// History stores every successfully rolled out ApplicationGroup spec as a ControllerRevision.
+// Since the ApplicationGroup is cluster scoped, the revisions are kept in the workflow namespace.
+type History struct {
+	client.Client
+	Namespace string
+}
+
+// NewHistory returns a History that keeps the revisions in the given namespace
+func NewHistory(c client.Client, namespace string) *History {
+	return &History{
+		Client:    c,
+		Namespace: namespace,
+	}
+}
+
+// Name returns the name of the ControllerRevision for the generation of the ApplicationGroup
+func Name(appGroupName string, generation int64) string {
+	return fmt.Sprintf("%s-%d", appGroupName, generation)
+}
+
+// Record stores the current spec of the ApplicationGroup as a revision, deletes the revisions past
+// the revision history limit and lists the remaining revisions in the ApplicationGroup status
+func (h *History) Record(ctx context.Context, appGroup *v1alpha1.ApplicationGroup) error {
+	data, err := json.Marshal(pinnedSpec(appGroup))
+	if err != nil {
+		return fmt.Errorf("failed to marshal the application group spec: %w", err)
+	}
+	revision := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      Name(appGroup.Name, appGroup.Generation),
+			Namespace: h.Namespace,
+			Labels: map[string]string{
+				v1alpha1.OwnershipLabel: appGroup.Name,
+				v1alpha1.HeritageLabel:  v1alpha1.HeritageValue,
+			},
+		},
+		Data:     runtime.RawExtension{Raw: data},
+		Revision: appGroup.Generation,
+	}
+	if err := controllerutil.SetControllerReference(appGroup, revision, h.Scheme()); err != nil {
+		return fmt.Errorf("unable to set ApplicationGroup as owner of ControllerRevision: %w", err)
+	}
+	if err := h.create(ctx, revision); err != nil {
+		return err
+	}
+
+	revisions, err := h.List(ctx, appGroup)
+	if err != nil {
+		return err
+	}
+	if limit := appGroup.GetRevisionHistoryLimit(); len(revisions) > limit {
+		for i := range revisions[limit:] {
+			if err := h.Delete(ctx, &revisions[limit+i]); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to DELETE controller revision %s: %w", revisions[limit+i].Name, err)
+			}
+		}
+		revisions = revisions[:limit]
+	}
+	appGroup.Status.Revisions = make([]v1alpha1.RevisionStatus, 0, len(revisions))
+	for _, revision := range revisions {
+		appGroup.Status.Revisions = append(appGroup.Status.Revisions, v1alpha1.RevisionStatus{
+			Name:              revision.Name,
+			Generation:        revision.Revision,
+			CreationTimestamp: revision.CreationTimestamp,
+		})
+	}
+	return nil
+}
+
+// create creates the revision, or replaces the existing revision of the generation if the chart
+// versions that it was rolled out with have changed, since the revision data is immutable
+func (h *History) create(ctx context.Context, revision *appsv1.ControllerRevision) error {
+	err := h.Create(ctx, revision)
+	if !errors.IsAlreadyExists(err) {
+		if err != nil {
+			return fmt.Errorf("failed to CREATE controller revision %s: %w", revision.Name, err)
+		}
+		return nil
+	}
+	existing := &appsv1.ControllerRevision{}
+	if err := h.Client.Get(ctx, client.ObjectKeyFromObject(revision), existing); err != nil {
+		return fmt.Errorf("failed to GET controller revision %s: %w", revision.Name, err)
+	}
+	if bytes.Equal(existing.Data.Raw, revision.Data.Raw) {
+		return nil
+	}
+	if err := h.Delete(ctx, existing); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to DELETE controller revision %s: %w", revision.Name, err)
+	}
+	if err := h.Create(ctx, revision); err != nil {
+		return fmt.Errorf("failed to CREATE controller revision %s: %w", revision.Name, err)
+	}
+	return nil
+}
+
+// List returns the revisions of the ApplicationGroup, starting from the most recent revision
+func (h *History) List(ctx context.Context, appGroup *v1alpha1.ApplicationGroup) ([]appsv1.ControllerRevision, error) {
+	revisions := &appsv1.ControllerRevisionList{}
+	listOption := client.MatchingLabels{
+		v1alpha1.OwnershipLabel: appGroup.Name,
+		v1alpha1.HeritageLabel:  v1alpha1.HeritageValue,
+	}
+	if err := h.Client.List(ctx, revisions, client.InNamespace(h.Namespace), listOption); err != nil {
+		return nil, fmt.Errorf("failed to list the controller revisions: %w", err)
+	}
+	sort.Slice(revisions.Items, func(i, j int) bool {
+		return revisions.Items[i].Revision > revisions.Items[j].Revision
+	})
+	return revisions.Items, nil
+}
+
+// Get returns the ApplicationGroup spec of the revision with the given generation
+func (h *History) Get(ctx context.Context, appGroup *v1alpha1.ApplicationGroup, generation int64) (*v1alpha1.ApplicationGroupSpec, error) {
+	revision := &appsv1.ControllerRevision{}
+	if err := h.Client.Get(ctx, types.NamespacedName{Namespace: h.Namespace, Name: Name(appGroup.Name, generation)}, revision); errors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: generation %d", meta.ErrRevisionNotFound, generation)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get controller revision for generation %d: %w", generation, err)
+	}
+	return decode(revision)
+}
+
+// Specs returns the ApplicationGroup specs of the retained revisions, starting from the most recent revision,
+// followed by the spec stored by the releases that predate the revision history if it is set
+func (h *History) Specs(ctx context.Context, appGroup *v1alpha1.ApplicationGroup) ([]*v1alpha1.ApplicationGroupSpec, error) {
+	revisions, err := h.List(ctx, appGroup)
+	if err != nil {
+		return nil, err
+	}
+	specs := make([]*v1alpha1.ApplicationGroupSpec, 0, len(revisions)+1)
+	for i := range revisions {
+		spec, err := decode(&revisions[i])
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	if lastSuccessful := appGroup.GetLastSuccessful(); lastSuccessful != nil {
+		specs = append(specs, lastSuccessful)
+	}
+	return specs, nil
+}
+
+// HasRevision checks if the ApplicationGroup has been successfully rolled out before
+func (h *History) HasRevision(ctx context.Context, appGroup *v1alpha1.ApplicationGroup) (bool, error) {
+	revisions, err := h.List(ctx, appGroup)
+	if err != nil {
+		return false, err
+	}
+	return len(revisions) > 0 || appGroup.GetLastSuccessful() != nil, nil
+}
+
+// RollbackSpec returns the spec that the rollback workflow rolls back to. This is the revision
+// requested by the RollbackToAnnotation if it is set, and the most recent revision otherwise.
+func (h *History) RollbackSpec(ctx context.Context, appGroup *v1alpha1.ApplicationGroup) (*v1alpha1.ApplicationGroupSpec, error) {
+	generation, ok, err := appGroup.GetRollbackTo()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return h.Get(ctx, appGroup, generation)
+	}
+	revisions, err := h.List(ctx, appGroup)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) > 0 {
+		return decode(&revisions[0])
+	}
+	// Fall back to the spec stored by the releases that predate the revision history
+	if lastSuccessful := appGroup.GetLastSuccessful(); lastSuccessful != nil {
+		return lastSuccessful, nil
+	}
+	return nil, meta.ErrPreviousSpecNotSet
+}
+
+// pinnedSpec returns a copy of the ApplicationGroup spec where the chart version ranges are replaced with
+// the versions that they were resolved to, so that rolling back to the revision restores the same charts
+func pinnedSpec(appGroup *v1alpha1.ApplicationGroup) *v1alpha1.ApplicationGroupSpec {
+	spec := appGroup.Spec.DeepCopy()
+	for i := range spec.Applications {
+		chart := spec.Applications[i].Spec.Chart
+		if chart == nil || chart.GetSourceKind() != "" || !chart.IsVersionRange() {
+			continue
+		}
+		if appStatus := appGroup.GetApplicationStatus(spec.Applications[i].Name); appStatus != nil && appStatus.Version != "" {
+			chart.Version = appStatus.Version
+		}
+	}
+	return spec
+}
+
+func decode(revision *appsv1.ControllerRevision) (*v1alpha1.ApplicationGroupSpec, error) {
+	spec := &v1alpha1.ApplicationGroupSpec{}
+	if err := json.Unmarshal(revision.Data.Raw, spec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal controller revision %s: %w", revision.Name, err)
+	}
+	return spec, nil
+}
@@ -0,0 +1,204 @@
+package revision
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testHistory(t *testing.T) *History {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return NewHistory(fake.NewClientBuilder().WithScheme(scheme).Build(), "orkestra")
+}
+
+func testAppGroup(generation int64, version string) *v1alpha1.ApplicationGroup {
+	return &v1alpha1.ApplicationGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "bookinfo",
+			UID:        "bookinfo-uid",
+			Generation: generation,
+		},
+		Spec: v1alpha1.ApplicationGroupSpec{
+			Applications: []v1alpha1.Application{
+				{
+					DAG: v1alpha1.DAG{Name: "ambassador"},
+					Spec: v1alpha1.ApplicationSpec{
+						Chart: &v1alpha1.ChartRef{Name: "ambassador", Version: version},
+					},
+				},
+			},
+		},
+	}
+}
+
+func chartVersion(spec *v1alpha1.ApplicationGroupSpec) string {
+	return spec.Applications[0].Spec.Chart.Version
+}
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	h := testHistory(t)
+	limit := int32(2)
+	var appGroup *v1alpha1.ApplicationGroup
+	for generation, version := range []string{"6.6.0", "6.7.0", "6.8.0"} {
+		appGroup = testAppGroup(int64(generation+1), version)
+		appGroup.Spec.RevisionHistoryLimit = &limit
+		if err := h.Record(ctx, appGroup); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	var got []int64
+	for _, revision := range appGroup.Status.Revisions {
+		got = append(got, revision.Generation)
+	}
+	if want := []int64{3, 2}; !cmp.Equal(got, want) {
+		t.Errorf("Record() status revisions diff = %v", cmp.Diff(got, want))
+	}
+	revisions, err := h.List(ctx, appGroup)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("List() = %d revisions, want the 2 revisions within the limit", len(revisions))
+	}
+	if revisions[0].OwnerReferences[0].Name != appGroup.Name {
+		t.Errorf("Record() owner = %v, want the application group", revisions[0].OwnerReferences)
+	}
+	if _, err := h.Get(ctx, appGroup, 1); !errors.Is(err, meta.ErrRevisionNotFound) {
+		t.Errorf("Get() of a pruned revision error = %v, want %v", err, meta.ErrRevisionNotFound)
+	}
+}
+
+func TestRecord_VersionRange(t *testing.T) {
+	ctx := context.Background()
+	h := testHistory(t)
+	appGroup := testAppGroup(1, "~6.6")
+	for _, resolved := range []string{"6.6.0", "6.6.1"} {
+		// The auto update rolls out the newly published chart version within the same generation
+		appGroup.Status.Applications = []v1alpha1.ApplicationStatus{{Name: "ambassador", ChartStatus: v1alpha1.ChartStatus{Version: resolved}}}
+		if err := h.Record(ctx, appGroup); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		spec, err := h.Get(ctx, appGroup, 1)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got := chartVersion(spec); got != resolved {
+			t.Errorf("Record() chart version = %v, want the resolved version %v", got, resolved)
+		}
+	}
+	if got := chartVersion(&appGroup.Spec); got != "~6.6" {
+		t.Errorf("Record() changed the application group chart version to %v", got)
+	}
+}
+
+func TestRecord_ReorderedApplications(t *testing.T) {
+	ctx := context.Background()
+	h := testHistory(t)
+	appGroup := testAppGroup(1, "~6.6")
+	appGroup.Spec.Applications = append([]v1alpha1.Application{
+		{
+			DAG: v1alpha1.DAG{Name: "bookinfo"},
+			Spec: v1alpha1.ApplicationSpec{
+				Chart: &v1alpha1.ChartRef{Name: "bookinfo", Version: "~1.0"},
+			},
+		},
+	}, appGroup.Spec.Applications...)
+	// The status still lists the applications in the order of the previous spec
+	appGroup.Status.Applications = []v1alpha1.ApplicationStatus{
+		{Name: "ambassador", ChartStatus: v1alpha1.ChartStatus{Version: "6.6.1"}},
+		{Name: "bookinfo", ChartStatus: v1alpha1.ChartStatus{Version: "1.0.2"}},
+	}
+	if err := h.Record(ctx, appGroup); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	spec, err := h.Get(ctx, appGroup, 1)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, application := range spec.Applications {
+		got[application.Name] = application.Spec.Chart.Version
+	}
+	if want := map[string]string{"bookinfo": "1.0.2", "ambassador": "6.6.1"}; !cmp.Equal(got, want) {
+		t.Errorf("Record() chart versions diff = %v", cmp.Diff(got, want))
+	}
+}
+
+func TestRollbackSpec(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		recorded    []string
+		annotations map[string]string
+		want        string
+		wantErr     error
+	}{
+		{
+			name:     "Most Recent Revision",
+			recorded: []string{"6.6.0", "6.7.0"},
+			want:     "6.7.0",
+		},
+		{
+			name:        "Requested Revision",
+			recorded:    []string{"6.6.0", "6.7.0"},
+			annotations: map[string]string{v1alpha1.RollbackToAnnotation: "1"},
+			want:        "6.6.0",
+		},
+		{
+			name:        "Unknown Requested Revision",
+			recorded:    []string{"6.6.0"},
+			annotations: map[string]string{v1alpha1.RollbackToAnnotation: "5"},
+			wantErr:     meta.ErrRevisionNotFound,
+		},
+		{
+			name:        "Legacy Last Successful Annotation",
+			annotations: map[string]string{v1alpha1.LastSuccessfulAnnotation: `{"applications":[{"name":"ambassador","spec":{"chart":{"name":"ambassador","version":"6.5.0"}}}]}`},
+			want:        "6.5.0",
+		},
+		{
+			name:    "No Revisions",
+			wantErr: meta.ErrPreviousSpecNotSet,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testHistory(t)
+			for generation, version := range tt.recorded {
+				if err := h.Record(ctx, testAppGroup(int64(generation+1), version)); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+			appGroup := testAppGroup(int64(len(tt.recorded)+1), "6.9.0")
+			appGroup.Annotations = tt.annotations
+			got, err := h.RollbackSpec(ctx, appGroup)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("RollbackSpec() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RollbackSpec() error = %v", err)
+			}
+			if chartVersion(got) != tt.want {
+				t.Errorf("RollbackSpec() chart version = %v, want %v", chartVersion(got), tt.want)
+			}
+		})
+	}
+}
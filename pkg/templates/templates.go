@@ -12,7 +12,8 @@ import (
 
 const (
 	EntrypointTemplateName = "entry"
-	ChartMuseumName        = "chartmuseum"
+	// DefaultStagingRepoName is the name of the HelmRepository of the staging repository
+	DefaultStagingRepoName = "chartmuseum"
 )
 
 func GenerateWorkflow(name, namespace string, parallelism *int64) *v1alpha13.Workflow {
@@ -38,16 +39,23 @@ func GenerateWorkflow(name, namespace string, parallelism *int64) *v1alpha13.Wor
 }
 
 type TemplateGenerator struct {
-	EntryTemplate v1alpha13.Template
-	Templates     []v1alpha13.Template
-	Namespace     string
-	Parallelism   *int64
+	EntryTemplate   v1alpha13.Template
+	Templates       []v1alpha13.Template
+	Namespace       string
+	Parallelism     *int64
+	StagingRepoName string
 }
 
-func NewTemplateGenerator(namespace string, parallelism *int64) *TemplateGenerator {
+// NewTemplateGenerator returns the template generator of the workflows whose HelmReleases are sourced
+// from the staging HelmRepository with the given name, or the DefaultStagingRepoName if it is empty
+func NewTemplateGenerator(namespace string, parallelism *int64, stagingRepoName string) *TemplateGenerator {
+	if stagingRepoName == "" {
+		stagingRepoName = DefaultStagingRepoName
+	}
 	return &TemplateGenerator{
-		Namespace:   namespace,
-		Parallelism: parallelism,
+		Namespace:       namespace,
+		Parallelism:     parallelism,
+		StagingRepoName: stagingRepoName,
 	}
 }
 
@@ -166,7 +174,7 @@ func (tg *TemplateGenerator) createHelmRelease(task *graph.TaskNode, graphName s
 					Version: task.ChartVersion,
 					SourceRef: fluxhelmv2beta1.CrossNamespaceObjectReference{
 						Kind:      fluxsourcev1beta1.HelmRepositoryKind,
-						Name:      ChartMuseumName,
+						Name:      tg.StagingRepoName,
 						Namespace: tg.Namespace,
 					},
 				},
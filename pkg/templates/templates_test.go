@@ -706,7 +706,7 @@ func Test_GenerateTemplates(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tg := NewTemplateGenerator(tt.args.namespace, tt.args.parallelism)
+			tg := NewTemplateGenerator(tt.args.namespace, tt.args.parallelism, "")
 			_ = tg.GenerateTemplates(tt.args.graph)
 
 			// Sort all the lists so that comparison is consistent
@@ -792,7 +792,7 @@ func Test_createHelmRelease(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tg := NewTemplateGenerator(tt.args.namespace, tt.args.parallelism)
+			tg := NewTemplateGenerator(tt.args.namespace, tt.args.parallelism, "")
 			got := tg.createHelmRelease(tt.args.taskNode, tt.args.graphName)
 			if !cmp.Equal(got, tt.want) {
 				t.Errorf("createHelmRelease() = %v", cmp.Diff(got, tt.want))
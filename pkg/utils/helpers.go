@@ -78,6 +78,19 @@ func HrToB64(hr *fluxhelmv2beta1.HelmRelease) string {
 	return base64.StdEncoding.EncodeToString([]byte(yaml))
 }
 
+// B64ToHr decodes the base64 encoded HelmRelease YAML passed to the workflow executors
+func B64ToHr(in string) (*fluxhelmv2beta1.HelmRelease, error) {
+	b, err := base64.StdEncoding.DecodeString(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the helmrelease: %w", err)
+	}
+	hr := &fluxhelmv2beta1.HelmRelease{}
+	if err := yaml.Unmarshal(b, hr); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the helmrelease: %w", err)
+	}
+	return hr, nil
+}
+
 func TemplateContainsYaml(ch *chart.Chart) (bool, error) {
 	if ch == nil {
 		return false, fmt.Errorf("chart cannot be nil")
@@ -3,6 +3,9 @@ package utils
 import (
 	"reflect"
 	"testing"
+
+	fluxhelmv2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestConvertToDNS1123(t *testing.T) {
@@ -239,3 +242,48 @@ func TestRemoveStringFromSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestB64ToHr(t *testing.T) {
+	hr := &fluxhelmv2beta1.HelmRelease{
+		TypeMeta: v1.TypeMeta{
+			Kind:       fluxhelmv2beta1.HelmReleaseKind,
+			APIVersion: fluxhelmv2beta1.GroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "ambassador",
+			Namespace: "ambassador",
+		},
+		Spec: fluxhelmv2beta1.HelmReleaseSpec{
+			ReleaseName:     "ambassador",
+			TargetNamespace: "ambassador",
+		},
+	}
+	tests := []struct {
+		name    string
+		in      string
+		want    *fluxhelmv2beta1.HelmRelease
+		wantErr bool
+	}{
+		{
+			name: "decoding an encoded helmrelease",
+			in:   HrToB64(hr),
+			want: hr,
+		},
+		{
+			name:    "decoding an invalid base64 string",
+			in:      "not-base64!",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := B64ToHr(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("B64ToHr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("B64ToHr() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
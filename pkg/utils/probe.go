@@ -3,7 +3,6 @@ package utils
 import (
 	"net"
 	"net/http"
-	"time"
 
 	"github.com/heptiolabs/healthcheck"
 )
@@ -12,12 +11,14 @@ type Probe struct {
 	health healthcheck.Handler
 }
 
-func ProbeHandler(chartmuseumAddress, endpoint string) (*Probe, error) {
+// ProbeHandler returns the liveness and readiness probes, the readiness check verifies
+// that the staging repository is up and serving traffic
+func ProbeHandler(stagingReady healthcheck.Check) (*Probe, error) {
 	health := healthcheck.NewHandler()
 	// Liveness check verifies that the number of goroutines are below threshold
 	health.AddLivenessCheck("goroutine-threshold", healthcheck.GoroutineCountCheck(100))
-	// Readiness check verifies that chartmuseum is up and serving traffic
-	health.AddReadinessCheck("chartmuseum-ready", healthcheck.HTTPGetCheck(chartmuseumAddress+"/"+endpoint, time.Second*5))
+	// Readiness check verifies that the staging repository is up and serving traffic
+	health.AddReadinessCheck("staging-ready", stagingReady)
 
 	return &Probe{
 		health: health,
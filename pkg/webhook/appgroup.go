@@ -0,0 +1,318 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/executor"
+	"github.com/Azure/Orkestra/pkg/graph"
+	"github.com/Azure/Orkestra/pkg/workflow"
+	"github.com/go-logr/logr"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// ValidateApplicationGroupPath is the path the validating webhook for the ApplicationGroup is served on
+	ValidateApplicationGroupPath = "/validate-orkestra-azure-microsoft-com-v1alpha1-applicationgroup"
+)
+
+// +kubebuilder:webhook:path=/validate-orkestra-azure-microsoft-com-v1alpha1-applicationgroup,mutating=false,failurePolicy=fail,sideEffects=None,groups=orkestra.azure.microsoft.com,resources=applicationgroups,verbs=create;update,versions=v1alpha1,name=vapplicationgroup.orkestra.azure.microsoft.com,admissionReviewVersions={v1,v1beta1}
+
+// ApplicationGroupValidator rejects ApplicationGroup specs that would otherwise only
+// fail during workflow template generation or inside of the Argo workflow
+type ApplicationGroupValidator struct {
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// Handle validates the ApplicationGroup in the admission request
+func (v *ApplicationGroupValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	appGroup := &v1alpha1.ApplicationGroup{}
+	if err := v.decoder.Decode(req, appGroup); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// Allow the finalizers to be removed from an ApplicationGroup that is being deleted
+	if !appGroup.DeletionTimestamp.IsZero() {
+		return admission.Allowed("")
+	}
+	if errs := ValidateApplicationGroup(appGroup); len(errs) > 0 {
+		v.Log.V(1).Info("rejecting invalid application group", v1alpha1.AppGroupNameKey, appGroup.Name, "errors", errs.ToAggregate().Error())
+		return admission.Denied(errs.ToAggregate().Error())
+	}
+	return admission.Allowed("")
+}
+
+// InjectDecoder injects the decoder into the validator
+func (v *ApplicationGroupValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// ValidateApplicationGroup checks the ApplicationGroup spec for missing fields, duplicate names
+// and dangling or cyclic dependencies between the applications, subcharts and executors
+func ValidateApplicationGroup(appGroup *v1alpha1.ApplicationGroup) field.ErrorList {
+	var errs field.ErrorList
+	appsPath := field.NewPath("spec", "applications")
+
+	appNames := make(map[string]bool)
+	for i, application := range appGroup.Spec.Applications {
+		appPath := appsPath.Index(i)
+		if appNames[application.Name] {
+			errs = append(errs, field.Duplicate(appPath.Child("name"), application.Name))
+		}
+		appNames[application.Name] = true
+		if application.Spec.Chart == nil {
+			errs = append(errs, field.Required(appPath.Child("spec", "chart"), "chart is required"))
+		} else if application.Spec.Chart.Git != nil && application.Spec.Chart.Bucket != nil {
+			errs = append(errs, field.Forbidden(appPath.Child("spec", "chart", "bucket"), "chart can only be fetched from either a Git repository or a bucket"))
+		} else if application.Spec.Chart.Verify != nil {
+			errs = append(errs, validateChartVerification(appPath.Child("spec", "chart", "verify"), application.Spec.Chart)...)
+		}
+		if application.Spec.Release == nil {
+			errs = append(errs, field.Required(appPath.Child("spec", "release"), "release is required"))
+		}
+		errs = append(errs, validateDuplicateNames(appPath.Child("spec", "subcharts"), subchartNames(application.Spec.Subcharts))...)
+		errs = append(errs, validateExecutors(appPath.Child("spec", "workflow"), application.Spec.Workflow)...)
+	}
+	errs = append(errs, validateRollbackTo(appGroup)...)
+	errs = append(errs, validateApprovals(appGroup)...)
+	// The graph can only be built once the required fields are present
+	if len(errs) > 0 {
+		return errs
+	}
+	return append(errs, validateGraph(appsPath, appGroup)...)
+}
+
+// validateRollbackTo checks that the rollback request annotation holds a generation. Whether the generation
+// is in the revision history is only known to the controller, which reports unknown revisions in the status.
+func validateRollbackTo(appGroup *v1alpha1.ApplicationGroup) field.ErrorList {
+	if _, _, err := appGroup.GetRollbackTo(); err != nil {
+		path := field.NewPath("metadata", "annotations").Key(v1alpha1.RollbackToAnnotation)
+		return field.ErrorList{field.Invalid(path, appGroup.Annotations[v1alpha1.RollbackToAnnotation], err.Error())}
+	}
+	return nil
+}
+
+// validateApprovals checks that the approval request annotation names the approval executors of the applications
+func validateApprovals(appGroup *v1alpha1.ApplicationGroup) field.ErrorList {
+	gates := make(map[string]bool)
+	for _, application := range appGroup.Spec.Applications {
+		for _, e := range application.Spec.Workflow {
+			if e.Type == v1alpha1.ApprovalExecutor {
+				gates[workflow.ApprovalName(application.Name, e.Name)] = true
+			}
+		}
+	}
+	var errs field.ErrorList
+	path := field.NewPath("metadata", "annotations").Key(v1alpha1.ApproveAnnotation)
+	for _, approval := range appGroup.GetApprovals() {
+		if !gates[approval] {
+			errs = append(errs, field.Invalid(path, approval, "must be the <application>/<executor> name of an approval executor"))
+		}
+	}
+	return errs
+}
+
+func validateExecutors(path *field.Path, workflow []v1alpha1.Executor) field.ErrorList {
+	var errs field.ErrorList
+	names := make([]string, 0, len(workflow))
+	for i, item := range workflow {
+		names = append(names, item.Name)
+		switch item.Type {
+		case v1alpha1.CustomExecutor:
+			if item.Image == nil {
+				errs = append(errs, field.Required(path.Index(i).Child("image"), "image is required for the custom executor"))
+			}
+		case v1alpha1.KeptnExecutor:
+			if err := validateKeptnParams(item.Params); err != nil {
+				errs = append(errs, field.Invalid(path.Index(i).Child("params"), string(item.Type), err.Error()))
+			}
+		case v1alpha1.CanaryExecutor:
+			if err := executor.ValidateCanaryParameters(item.Params); err != nil {
+				errs = append(errs, field.Invalid(path.Index(i).Child("params"), string(item.Type), err.Error()))
+			}
+		case v1alpha1.ApprovalExecutor:
+			if len(workflow) == 1 {
+				errs = append(errs, field.Invalid(path.Index(i).Child("type"), string(item.Type), "the approval executor must gate another executor of the workflow"))
+			}
+		case v1alpha1.HealthCheckExecutor:
+			if len(item.Dependencies) == 0 {
+				errs = append(errs, field.Invalid(path.Index(i).Child("dependencies"), item.Dependencies, "the healthcheck executor must depend on the executor deploying the application"))
+			}
+			if err := executor.ValidateHealthCheckParameters(item.Params); err != nil {
+				errs = append(errs, field.Invalid(path.Index(i).Child("params"), string(item.Type), err.Error()))
+			}
+		case v1alpha1.HelmTestExecutor:
+			if len(item.Dependencies) == 0 {
+				errs = append(errs, field.Invalid(path.Index(i).Child("dependencies"), item.Dependencies, "the helmtest executor must depend on the executor deploying the application"))
+			}
+			if err := executor.ValidateHelmTestParameters(item.Params); err != nil {
+				errs = append(errs, field.Invalid(path.Index(i).Child("params"), string(item.Type), err.Error()))
+			}
+		}
+	}
+	return append(errs, validateDuplicateNames(path, names)...)
+}
+
+func validateKeptnParams(params *apiextensionsv1.JSON) error {
+	if params == nil {
+		return fmt.Errorf("params with a configMapRef are required for the keptn executor")
+	}
+	keptnParams := &executor.KeptnParameters{}
+	if err := json.Unmarshal(params.Raw, keptnParams); err != nil {
+		return fmt.Errorf("failed to unmarshal the keptn executor params: %w", err)
+	}
+	if keptnParams.ConfigMapRef.Name == "" {
+		return fmt.Errorf("configMapRef.name is required for the keptn executor")
+	}
+	return nil
+}
+
+func validateDuplicateNames(path *field.Path, names []string) field.ErrorList {
+	var errs field.ErrorList
+	seen := make(map[string]bool)
+	for i, name := range names {
+		if seen[name] {
+			errs = append(errs, field.Duplicate(path.Index(i).Child("name"), name))
+		}
+		seen[name] = true
+	}
+	return errs
+}
+
+// validateGraph builds the forward graph for the ApplicationGroup and maps the
+// graph validation errors back to the fields of the ApplicationGroup spec
+func validateGraph(appsPath *field.Path, appGroup *v1alpha1.ApplicationGroup) field.ErrorList {
+	var errs field.ErrorList
+	g := graph.NewForwardGraph(withStagedSubcharts(appGroup))
+	err := g.Validate()
+	if err == nil {
+		return nil
+	}
+
+	appIndex := make(map[string]int)
+	taskApp := make(map[string]string)
+	for i, application := range appGroup.Spec.Applications {
+		appIndex[application.Name] = i
+		for taskName := range g.Nodes[application.Name].Tasks {
+			taskApp[taskName] = application.Name
+		}
+	}
+
+	graphErrs := []error{err}
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		graphErrs = agg.Errors()
+	}
+	for _, graphErr := range graphErrs {
+		var danglingErr *graph.DanglingDependencyError
+		var cycleErr *graph.CycleError
+		switch {
+		case errors.As(graphErr, &danglingErr):
+			errs = append(errs, danglingDependencyFieldError(appsPath, appIndex, taskApp, danglingErr)...)
+		case errors.As(graphErr, &cycleErr):
+			errs = append(errs, cycleFieldError(appsPath, appIndex, taskApp, cycleErr)...)
+		default:
+			errs = append(errs, field.Invalid(appsPath, "", graphErr.Error()))
+		}
+	}
+	return errs
+}
+
+func danglingDependencyFieldError(appsPath *field.Path, appIndex map[string]int, taskApp map[string]string, err *graph.DanglingDependencyError) field.ErrorList {
+	switch err.Level {
+	case graph.AppLevel:
+		return field.ErrorList{field.NotFound(appsPath.Index(appIndex[err.Node]).Child("dependencies"), err.Dependency)}
+	case graph.TaskLevel:
+		subchartsPath := appsPath.Index(appIndex[err.Scope]).Child("spec", "subcharts")
+		return field.ErrorList{field.NotFound(subchartsPath.Key(subchartName(err.Scope, err.Node)), subchartName(err.Scope, err.Dependency))}
+	default:
+		// Every task node of the application shares the same executors, so we only report the application task
+		appName := taskApp[err.Scope]
+		if !isApplicationTask(appName, err.Scope) {
+			return nil
+		}
+		workflowPath := appsPath.Index(appIndex[appName]).Child("spec", "workflow")
+		return field.ErrorList{field.NotFound(workflowPath.Key(err.Node), err.Dependency)}
+	}
+}
+
+func cycleFieldError(appsPath *field.Path, appIndex map[string]int, taskApp map[string]string, err *graph.CycleError) field.ErrorList {
+	switch err.Level {
+	case graph.AppLevel:
+		return field.ErrorList{field.Invalid(appsPath, strings.Join(err.Path, " -> "), "application dependency cycle detected")}
+	case graph.TaskLevel:
+		cycle := make([]string, 0, len(err.Path))
+		for _, node := range err.Path {
+			cycle = append(cycle, subchartName(err.Scope, node))
+		}
+		subchartsPath := appsPath.Index(appIndex[err.Scope]).Child("spec", "subcharts")
+		return field.ErrorList{field.Invalid(subchartsPath, strings.Join(cycle, " -> "), "subchart dependency cycle detected")}
+	default:
+		appName := taskApp[err.Scope]
+		if !isApplicationTask(appName, err.Scope) {
+			return nil
+		}
+		workflowPath := appsPath.Index(appIndex[appName]).Child("spec", "workflow")
+		return field.ErrorList{field.Invalid(workflowPath, strings.Join(err.Path, " -> "), "executor dependency cycle detected")}
+	}
+}
+
+func isApplicationTask(appName, taskName string) bool {
+	return taskName == appName+"-"+appName
+}
+
+// withStagedSubcharts returns a copy of the ApplicationGroup whose status marks every
+// declared subchart as staged so that the subchart task nodes are part of the forward graph
+func withStagedSubcharts(appGroup *v1alpha1.ApplicationGroup) *v1alpha1.ApplicationGroup {
+	staged := appGroup.DeepCopy()
+	staged.Status.Applications = make([]v1alpha1.ApplicationStatus, 0, len(staged.Spec.Applications))
+	for _, application := range staged.Spec.Applications {
+		status := v1alpha1.ApplicationStatus{
+			Name:      application.Name,
+			Subcharts: make(map[string]v1alpha1.ChartStatus),
+		}
+		for _, subchart := range application.Spec.Subcharts {
+			status.Subcharts[subchart.Name] = v1alpha1.ChartStatus{Staged: true}
+		}
+		staged.Status.Applications = append(staged.Status.Applications, status)
+	}
+	return staged
+}
+
+// subchartName strips the application prefix from the subchart task node name
+func subchartName(appName, taskName string) string {
+	return strings.TrimPrefix(taskName, appName+"-")
+}
+
+func subchartNames(subcharts []v1alpha1.DAG) []string {
+	names := make([]string, 0, len(subcharts))
+	for _, subchart := range subcharts {
+		names = append(names, subchart.Name)
+	}
+	return names
+}
+
+// validateChartVerification checks that the verification provider is supported by the chart repository
+func validateChartVerification(path *field.Path, chart *v1alpha1.ChartRef) field.ErrorList {
+	var errs field.ErrorList
+	if chart.Verify.SecretRef == nil {
+		errs = append(errs, field.Required(path.Child("secretRef"), "secretRef is required"))
+	}
+	switch provider := chart.GetVerificationProvider(); {
+	case chart.GetSourceKind() != "":
+		errs = append(errs, field.Forbidden(path, "charts from Git repositories and buckets cannot be verified"))
+	case provider == v1alpha1.PGPVerificationProvider && v1alpha1.IsOCI(chart.URL):
+		errs = append(errs, field.Invalid(path.Child("provider"), provider, "charts from OCI registries are verified with cosign"))
+	case provider == v1alpha1.CosignVerificationProvider && !v1alpha1.IsOCI(chart.URL):
+		errs = append(errs, field.Invalid(path.Child("provider"), provider, "cosign is only supported for charts from OCI registries"))
+	}
+	return errs
+}
@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func testApplication(name string, dependencies ...string) v1alpha1.Application {
+	return v1alpha1.Application{
+		DAG: v1alpha1.DAG{
+			Name:         name,
+			Dependencies: dependencies,
+		},
+		Spec: v1alpha1.ApplicationSpec{
+			Chart: &v1alpha1.ChartRef{
+				Name:    name,
+				Version: "0.1.0",
+			},
+			Release: &v1alpha1.Release{},
+		},
+	}
+}
+
+func testAppGroup(applications ...v1alpha1.Application) *v1alpha1.ApplicationGroup {
+	return &v1alpha1.ApplicationGroup{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "appgroup",
+		},
+		Spec: v1alpha1.ApplicationGroupSpec{
+			Applications: applications,
+		},
+	}
+}
+
+func Test_ValidateApplicationGroup(t *testing.T) {
+	withSubcharts := testApplication("application1")
+	withSubcharts.Spec.Subcharts = []v1alpha1.DAG{
+		{Name: "subchart1", Dependencies: []string{"subchart3"}},
+		{Name: "subchart2", Dependencies: []string{"subchart1"}},
+		{Name: "subchart3", Dependencies: []string{"subchart2"}},
+	}
+	withDanglingSubchart := testApplication("application1")
+	withDanglingSubchart.Spec.Subcharts = []v1alpha1.DAG{
+		{Name: "subchart1", Dependencies: []string{"subchart2"}},
+	}
+	withExecutorCycle := testApplication("application1")
+	withExecutorCycle.Spec.Workflow = []v1alpha1.Executor{
+		{DAG: v1alpha1.DAG{Name: "helmrelease", Dependencies: []string{"custom"}}, Type: v1alpha1.HelmReleaseExecutor},
+		{DAG: v1alpha1.DAG{Name: "custom", Dependencies: []string{"helmrelease"}}, Type: v1alpha1.CustomExecutor, Image: &corev1.Container{Image: "custom:latest"}},
+	}
+	withInvalidExecutors := testApplication("application1")
+	withInvalidExecutors.Spec.Workflow = []v1alpha1.Executor{
+		{DAG: v1alpha1.DAG{Name: "custom"}, Type: v1alpha1.CustomExecutor},
+		{DAG: v1alpha1.DAG{Name: "keptn"}, Type: v1alpha1.KeptnExecutor, Params: &apiextensionsv1.JSON{Raw: []byte(`{}`)}},
+	}
+	withValidExecutors := testApplication("application3", "application2")
+	withValidExecutors.Spec.Workflow = []v1alpha1.Executor{
+		{DAG: v1alpha1.DAG{Name: "helmrelease"}, Type: v1alpha1.HelmReleaseExecutor},
+		{DAG: v1alpha1.DAG{Name: "keptn", Dependencies: []string{"helmrelease"}}, Type: v1alpha1.KeptnExecutor, Params: &apiextensionsv1.JSON{Raw: []byte(`{"configMapRef":{"name":"keptn-config","namespace":"orkestra"}}`)}},
+	}
+	withGitAndBucket := testApplication("application1")
+	withGitAndBucket.Spec.Chart.Git = &v1alpha1.GitChartSource{Path: "charts/application1"}
+	withGitAndBucket.Spec.Chart.Bucket = &v1alpha1.BucketChartSource{BucketName: "charts", Path: "application1"}
+	withVerifiedChart := func(name, url, provider string) v1alpha1.Application {
+		application := testApplication(name)
+		application.Spec.Chart.URL = url
+		application.Spec.Chart.Verify = &v1alpha1.ChartVerification{Provider: provider, SecretRef: &corev1.ObjectReference{Name: "chart-keys"}}
+		return application
+	}
+	withVerifiedGitChart := withVerifiedChart("application1", "https://github.com/Azure/orkestra", "")
+	withVerifiedGitChart.Spec.Chart.Git = &v1alpha1.GitChartSource{Path: "charts/application1"}
+	withApproval := func(approvals string) *v1alpha1.ApplicationGroup {
+		application := testApplication("application1")
+		application.Spec.Workflow = []v1alpha1.Executor{
+			{DAG: v1alpha1.DAG{Name: "approval"}, Type: v1alpha1.ApprovalExecutor},
+			{DAG: v1alpha1.DAG{Name: "helmrelease", Dependencies: []string{"approval"}}, Type: v1alpha1.HelmReleaseExecutor},
+		}
+		appGroup := testAppGroup(application)
+		appGroup.Annotations = map[string]string{v1alpha1.ApproveAnnotation: approvals}
+		return appGroup
+	}
+	withCanary := func(params string) v1alpha1.Application {
+		application := testApplication("application1")
+		application.Spec.Workflow = []v1alpha1.Executor{{DAG: v1alpha1.DAG{Name: "canary"}, Type: v1alpha1.CanaryExecutor, Params: &apiextensionsv1.JSON{Raw: []byte(params)}}}
+		return application
+	}
+	withHealthCheck := func(dependencies []string, params string) v1alpha1.Application {
+		application := testApplication("application1")
+		application.Spec.Workflow = []v1alpha1.Executor{
+			{DAG: v1alpha1.DAG{Name: "helmrelease"}, Type: v1alpha1.HelmReleaseExecutor},
+			{DAG: v1alpha1.DAG{Name: "healthcheck", Dependencies: dependencies}, Type: v1alpha1.HealthCheckExecutor, Params: &apiextensionsv1.JSON{Raw: []byte(params)}},
+		}
+		return application
+	}
+	withHelmTest := func(params string) v1alpha1.Application {
+		application := testApplication("application1")
+		application.Spec.Workflow = []v1alpha1.Executor{
+			{DAG: v1alpha1.DAG{Name: "helmrelease"}, Type: v1alpha1.HelmReleaseExecutor},
+			{DAG: v1alpha1.DAG{Name: "helmtest", Dependencies: []string{"helmrelease"}}, Type: v1alpha1.HelmTestExecutor, Params: &apiextensionsv1.JSON{Raw: []byte(params)}},
+		}
+		return application
+	}
+	withOnlyApproval := testApplication("application1")
+	withOnlyApproval.Spec.Workflow = []v1alpha1.Executor{{DAG: v1alpha1.DAG{Name: "approval"}, Type: v1alpha1.ApprovalExecutor}}
+	withRollbackTo := func(generation string) *v1alpha1.ApplicationGroup {
+		appGroup := testAppGroup(testApplication("application1"))
+		appGroup.Annotations = map[string]string{v1alpha1.RollbackToAnnotation: generation}
+		return appGroup
+	}
+
+	tests := []struct {
+		name     string
+		appGroup *v1alpha1.ApplicationGroup
+		want     []field.ErrorType
+	}{
+		{
+			name:     "Valid Ordered Set of Applications",
+			appGroup: testAppGroup(testApplication("application1"), testApplication("application2", "application1"), withValidExecutors),
+			want:     nil,
+		},
+		{
+			name:     "Duplicate Application Names",
+			appGroup: testAppGroup(testApplication("application1"), testApplication("application1")),
+			want:     []field.ErrorType{field.ErrorTypeDuplicate},
+		},
+		{
+			name:     "Unknown Application Dependency",
+			appGroup: testAppGroup(testApplication("application1", "application3")),
+			want:     []field.ErrorType{field.ErrorTypeNotFound},
+		},
+		{
+			name:     "Application Dependency Cycle",
+			appGroup: testAppGroup(testApplication("application1", "application2"), testApplication("application2", "application1")),
+			want:     []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Subchart Dependency Cycle",
+			appGroup: testAppGroup(withSubcharts),
+			want:     []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Unknown Subchart Dependency",
+			appGroup: testAppGroup(withDanglingSubchart),
+			want:     []field.ErrorType{field.ErrorTypeNotFound},
+		},
+		{
+			name:     "Executor Dependency Cycle",
+			appGroup: testAppGroup(withExecutorCycle),
+			want:     []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Custom Executor Without Image and Keptn Executor Without ConfigMapRef",
+			appGroup: testAppGroup(withInvalidExecutors),
+			want:     []field.ErrorType{field.ErrorTypeRequired, field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Chart From Git Repository And Bucket",
+			appGroup: testAppGroup(withGitAndBucket),
+			want:     []field.ErrorType{field.ErrorTypeForbidden},
+		},
+		{
+			name:     "Verified Charts",
+			appGroup: testAppGroup(withVerifiedChart("application1", "https://charts.example.com", ""), withVerifiedChart("application2", "oci://registry.example.com/charts", "")),
+			want:     nil,
+		},
+		{
+			name:     "Cosign Verification Of Helm Repository Chart",
+			appGroup: testAppGroup(withVerifiedChart("application1", "https://charts.example.com", v1alpha1.CosignVerificationProvider)),
+			want:     []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Provenance Verification Of OCI Chart",
+			appGroup: testAppGroup(withVerifiedChart("application1", "oci://registry.example.com/charts", v1alpha1.PGPVerificationProvider)),
+			want:     []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Verification Of Git Repository Chart",
+			appGroup: testAppGroup(withVerifiedGitChart),
+			want:     []field.ErrorType{field.ErrorTypeForbidden},
+		},
+		{
+			name:     "Approval Gate",
+			appGroup: withApproval("application1/approval"),
+			want:     nil,
+		},
+		{
+			name:     "Approval Of Unknown Gates",
+			appGroup: withApproval("application1/approval, application1/helmrelease,application2/approval"),
+			want:     []field.ErrorType{field.ErrorTypeInvalid, field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Approval Gate Without Other Executors",
+			appGroup: testAppGroup(withOnlyApproval),
+			want:     []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Canary Executor",
+			appGroup: testAppGroup(withCanary(`{"provider":"flagger","ref":{"name":"application1"}}`)),
+			want:     nil,
+		},
+		{
+			name:     "Canary Executor With Unknown Provider",
+			appGroup: testAppGroup(withCanary(`{"provider":"istio","ref":{"name":"application1"}}`)),
+			want:     []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Health Check Executor",
+			appGroup: testAppGroup(withHealthCheck([]string{"helmrelease"}, `{"endpoints":["http://application1.default.svc/healthz"],"timeout":"10m"}`)),
+			want:     nil,
+		},
+		{
+			name:     "Health Check Executor Without Dependencies And With Invalid Endpoint",
+			appGroup: testAppGroup(withHealthCheck(nil, `{"endpoints":["application1.default.svc/healthz"]}`)),
+			want:     []field.ErrorType{field.ErrorTypeInvalid, field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Helm Test Executor",
+			appGroup: testAppGroup(withHelmTest(`{"timeout":"10m","logs":true,"filter":["name=application1-test-connection"]}`)),
+			want:     nil,
+		},
+		{
+			name:     "Helm Test Executor With Invalid Filter",
+			appGroup: testAppGroup(withHelmTest(`{"filter":["application1-test-connection"]}`)),
+			want:     []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:     "Rollback To Revision",
+			appGroup: withRollbackTo("2"),
+			want:     nil,
+		},
+		{
+			name:     "Rollback To Invalid Revision",
+			appGroup: withRollbackTo("latest"),
+			want:     []field.ErrorType{field.ErrorTypeInvalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateApplicationGroup(tt.appGroup)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("ValidateApplicationGroup() = %v, want %d errors", errs, len(tt.want))
+			}
+			for i, err := range errs {
+				if err.Type != tt.want[i] {
+					t.Errorf("ValidateApplicationGroup() error %d = %v, want type %v", i, err, tt.want[i])
+				}
+			}
+		})
+	}
+}
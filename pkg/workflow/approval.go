@@ -0,0 +1,115 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/utils"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ApprovalName returns the <application>/<executor> name of an approval gate
+func ApprovalName(application, executor string) string {
+	return fmt.Sprintf("%s/%s", application, executor)
+}
+
+// approvalNodes returns the IDs of the suspended approval nodes of the workflow keyed by the
+// name of their approval gate. The gate of an application suspends the nodes of its chart and
+// of each of its subcharts.
+func approvalNodes(appGroup *v1alpha1.ApplicationGroup, wf *v1alpha13.Workflow) map[string][]string {
+	appNames := make(map[string]string)
+	for appName, node := range ApplicationNodes(appGroup, wf) {
+		appNames[node.ID] = appName
+	}
+	executors := make(map[string]map[string]string)
+	for _, application := range appGroup.Spec.Applications {
+		executors[application.Name] = make(map[string]string)
+		for _, e := range application.Spec.Workflow {
+			if e.Type == v1alpha1.ApprovalExecutor {
+				executors[application.Name][utils.ConvertToDNS1123(e.Name)] = e.Name
+			}
+		}
+	}
+
+	nodes := make(map[string][]string)
+	for id, node := range wf.Status.Nodes {
+		if node.Type != v1alpha13.NodeTypeSuspend || node.Phase != v1alpha13.NodeRunning {
+			continue
+		}
+		// Walk up the boundaries of the task templates to the application node
+		appName, ok := "", false
+		for boundaryID := node.BoundaryID; boundaryID != "" && !ok; boundaryID = wf.Status.Nodes[boundaryID].BoundaryID {
+			appName, ok = appNames[boundaryID]
+		}
+		if !ok {
+			continue
+		}
+		if executor, ok := executors[appName][node.DisplayName]; ok {
+			name := ApprovalName(appName, executor)
+			nodes[name] = append(nodes[name], id)
+		}
+	}
+	return nodes
+}
+
+// PendingApprovals returns the approval gates of the workflow that are waiting to be approved
+// in the order of the applications and their executors
+func PendingApprovals(appGroup *v1alpha1.ApplicationGroup, wf *v1alpha13.Workflow) []v1alpha1.PendingApproval {
+	nodes := approvalNodes(appGroup, wf)
+	var approvals []v1alpha1.PendingApproval
+	for _, application := range appGroup.Spec.Applications {
+		for _, e := range application.Spec.Workflow {
+			ids, ok := nodes[ApprovalName(application.Name, e.Name)]
+			if !ok {
+				continue
+			}
+			approval := v1alpha1.PendingApproval{Application: application.Name, Executor: e.Name}
+			for _, id := range ids {
+				if startedAt := wf.Status.Nodes[id].StartedAt; approval.Since.IsZero() || startedAt.Before(&approval.Since) {
+					approval.Since = startedAt
+				}
+			}
+			approvals = append(approvals, approval)
+		}
+	}
+	return approvals
+}
+
+// Approve resumes the suspended nodes of the given approval gates of the workflow associated with the
+// workflow client. It returns the approval gates that are not pending, which are left untouched.
+func Approve(ctx context.Context, wfClient Client, approvals []string) ([]string, error) {
+	workflow, err := GetWorkflow(ctx, wfClient)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, fmt.Errorf("failed to get the workflow: %w", err)
+	} else if err != nil {
+		return approvals, nil
+	}
+	nodes := approvalNodes(wfClient.GetAppGroup(), workflow)
+	patch := client.MergeFrom(workflow.DeepCopy())
+	var notPending []string
+	for _, approval := range approvals {
+		ids, ok := nodes[approval]
+		if !ok {
+			notPending = append(notPending, approval)
+			continue
+		}
+		// Argo continues the workflow once the suspended nodes succeeded
+		for _, id := range ids {
+			node := workflow.Status.Nodes[id]
+			node.Phase = v1alpha13.NodeSucceeded
+			node.FinishedAt = metav1.Now()
+			workflow.Status.Nodes[id] = node
+		}
+	}
+	if len(notPending) == len(approvals) {
+		return notPending, nil
+	}
+	wfClient.GetLogger().Info("approving the workflow gates", "approvals", approvals)
+	if err := wfClient.GetClient().Patch(ctx, workflow, patch); err != nil {
+		return nil, fmt.Errorf("failed to patch the workflow: %w", err)
+	}
+	return notPending, nil
+}
@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testApprovalWorkflow() (*v1alpha1.ApplicationGroup, *v1alpha13.Workflow, metav1.Time) {
+	application := func(name string) v1alpha1.Application {
+		return v1alpha1.Application{
+			DAG: v1alpha1.DAG{Name: name},
+			Spec: v1alpha1.ApplicationSpec{
+				Workflow: []v1alpha1.Executor{
+					{DAG: v1alpha1.DAG{Name: "approval"}, Type: v1alpha1.ApprovalExecutor},
+					{DAG: v1alpha1.DAG{Name: "helmrelease", Dependencies: []string{"approval"}}, Type: v1alpha1.HelmReleaseExecutor},
+				},
+			},
+		}
+	}
+	appGroup := &v1alpha1.ApplicationGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "bookinfo"},
+		Spec: v1alpha1.ApplicationGroupSpec{
+			Applications: []v1alpha1.Application{application("ambassador"), application("bookinfo")},
+		},
+	}
+
+	started := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	node := func(id, displayName, boundaryID string) v1alpha13.NodeStatus {
+		return v1alpha13.NodeStatus{ID: id, DisplayName: displayName, BoundaryID: boundaryID, Phase: v1alpha13.NodeRunning}
+	}
+	approval := func(id, boundaryID string, phase v1alpha13.NodePhase, startedAt metav1.Time) v1alpha13.NodeStatus {
+		n := node(id, "approval", boundaryID)
+		n.Type, n.Phase, n.StartedAt = v1alpha13.NodeTypeSuspend, phase, startedAt
+		return n
+	}
+	wf := &v1alpha13.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "bookinfo", Namespace: "orkestra"},
+		Status: v1alpha13.WorkflowStatus{
+			Nodes: v1alpha13.Nodes{
+				"bookinfo": node("bookinfo", "bookinfo", ""),
+				// The gate of the ambassador chart and of its subchart are pending
+				"ambassador-id":                   node("ambassador-id", "ambassador", "bookinfo"),
+				"ambassador-task-id":              node("ambassador-task-id", "ambassador-ambassador", "ambassador-id"),
+				"ambassador-approval-id":          approval("ambassador-approval-id", "ambassador-task-id", v1alpha13.NodeRunning, metav1.NewTime(started.Add(time.Second))),
+				"ambassador-subchart-id":          node("ambassador-subchart-id", "ambassador-subchart", "ambassador-id"),
+				"ambassador-subchart-approval-id": approval("ambassador-subchart-approval-id", "ambassador-subchart-id", v1alpha13.NodeRunning, started),
+				// The gate of the bookinfo chart was already approved
+				"bookinfo-id":          node("bookinfo-id", "bookinfo", "bookinfo"),
+				"bookinfo-task-id":     node("bookinfo-task-id", "bookinfo-bookinfo", "bookinfo-id"),
+				"bookinfo-approval-id": approval("bookinfo-approval-id", "bookinfo-task-id", v1alpha13.NodeSucceeded, started),
+			},
+		},
+	}
+	return appGroup, wf, started
+}
+
+func TestPendingApprovals(t *testing.T) {
+	appGroup, wf, started := testApprovalWorkflow()
+	want := []v1alpha1.PendingApproval{{Application: "ambassador", Executor: "approval", Since: started}}
+	if got := PendingApprovals(appGroup, wf); !cmp.Equal(got, want) {
+		t.Errorf("PendingApprovals() diff = %v", cmp.Diff(got, want))
+	}
+}
+
+func TestApprove(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := v1alpha13.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	appGroup, wf, _ := testApprovalWorkflow()
+	k := fake.NewClientBuilder().WithScheme(scheme).WithObjects(wf).Build()
+	wfClient := NewBuilder(k, logr.Discard()).InNamespace("orkestra").Build(v1alpha1.Forward, appGroup)
+
+	notPending, err := Approve(ctx, wfClient, []string{"ambassador/approval", "bookinfo/approval"})
+	if err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if want := []string{"bookinfo/approval"}; !cmp.Equal(notPending, want) {
+		t.Errorf("Approve() not pending = %v, want %v", notPending, want)
+	}
+
+	got := &v1alpha13.Workflow{}
+	if err := k.Get(ctx, types.NamespacedName{Namespace: "orkestra", Name: "bookinfo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"ambassador-approval-id", "ambassador-subchart-approval-id"} {
+		if node := got.Status.Nodes[id]; node.Phase != v1alpha13.NodeSucceeded || node.FinishedAt.IsZero() {
+			t.Errorf("Approve() node %s phase = %v, finished at %v, want a succeeded node", id, node.Phase, node.FinishedAt)
+		}
+	}
+	if approvals := PendingApprovals(appGroup, got); len(approvals) != 0 {
+		t.Errorf("PendingApprovals() = %v after the approval, want none", approvals)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/Azure/Orkestra/pkg/utils"
 	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -55,9 +56,10 @@ type Client interface {
 }
 
 type ClientOptions struct {
-	Parallelism *int64
-	StagingRepo string
-	Namespace   string
+	Parallelism     *int64
+	StagingRepo     string
+	StagingRepoName string
+	Namespace       string
 }
 
 type Builder struct {
@@ -76,6 +78,9 @@ type ForwardWorkflowClient struct {
 
 	workflow *v1alpha13.Workflow
 	appGroup *v1alpha1.ApplicationGroup
+
+	// specHash is the hash of the spec rolled out by the workflow, see WithSpecHash
+	specHash string
 }
 
 type RollbackWorkflowClient struct {
@@ -123,11 +128,28 @@ func (builder *Builder) WithStagingRepo(stagingURL string) *Builder {
 	return builder
 }
 
+// WithStagingRepoName sets the name of the HelmRepository that the HelmReleases are sourced from
+func (builder *Builder) WithStagingRepoName(name string) *Builder {
+	builder.options.StagingRepoName = name
+	return builder
+}
+
 func (builder *Builder) InNamespace(namespace string) *Builder {
 	builder.options.Namespace = namespace
 	return builder
 }
 
+// WithSpecHash sets the hash that the forward workflow client annotates the workflow with. The reconciler
+// adds the undeclared subcharts of the applications to the spec before the workflow is generated,
+// so the hash of the spec is taken before and handed to the workflow client.
+// It has no effect on the reverse and rollback workflow clients
+func WithSpecHash(wfClient Client, specHash string) Client {
+	if forwardClient, ok := wfClient.(*ForwardWorkflowClient); ok {
+		forwardClient.specHash = specHash
+	}
+	return wfClient
+}
+
 func (builder *Builder) Build(clientType v1alpha1.WorkflowType, appGroup *v1alpha1.ApplicationGroup) Client {
 	switch clientType {
 	case v1alpha1.Forward:
@@ -216,6 +238,50 @@ func Suspend(ctx context.Context, wfClient Client) error {
 	return nil
 }
 
+// Resume clears the suspend flag on the workflow associated with the workflow client
+// if the workflow still exists on the cluster
+func Resume(ctx context.Context, wfClient Client) error {
+	// resume a workflow if it is not already finished and is suspended
+	workflow, err := GetWorkflow(ctx, wfClient)
+	if client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to resume the workflow: %w", err)
+	} else if err != nil || !workflow.Status.FinishedAt.IsZero() {
+		wfClient.GetLogger().Info("workflow not found, no need to resume")
+		return nil
+	}
+	if workflow.Spec.Suspend != nil && *workflow.Spec.Suspend {
+		wfClient.GetLogger().Info("resuming the workflow")
+		patch := client.MergeFrom(workflow.DeepCopy())
+		workflow.Spec.Suspend = nil
+		if err := wfClient.GetClient().Patch(ctx, workflow, patch); err != nil {
+			return fmt.Errorf("failed to patch the workflow: %w", err)
+		}
+		SetProgressing(wfClient.GetAppGroup(), wfClient.GetType())
+	}
+	return nil
+}
+
+// Adopt moves the workflow associated with the workflow client from the given generation of the
+// ApplicationGroup to its current generation and resumes it. It returns false if the workflow
+// doesn't exist or was submitted for another generation.
+func Adopt(ctx context.Context, wfClient Client, generation int64) (bool, error) {
+	workflow, err := GetWorkflow(ctx, wfClient)
+	if client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("failed to get the workflow: %w", err)
+	} else if err != nil || workflow.GetLabels()[v1alpha1.WorkflowAppGroupGenerationLabel] != strconv.FormatInt(generation, 10) {
+		return false, nil
+	}
+	patch := client.MergeFrom(workflow.DeepCopy())
+	workflow.GetLabels()[v1alpha1.WorkflowAppGroupGenerationLabel] = strconv.FormatInt(wfClient.GetAppGroup().Generation, 10)
+	if err := wfClient.GetClient().Patch(ctx, workflow, patch); err != nil {
+		return false, fmt.Errorf("failed to patch the workflow: %w", err)
+	}
+	if err := Resume(ctx, wfClient); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func GetWorkflow(ctx context.Context, wc Client) (*v1alpha13.Workflow, error) {
 	workflow := &v1alpha13.Workflow{}
 	err := wc.GetClient().Get(ctx, types.NamespacedName{Namespace: wc.GetNamespace(), Name: wc.GetName()}, workflow)
@@ -285,6 +351,37 @@ func IsSucceeded(ctx context.Context, wfClient Client) (bool, error) {
 	return false, nil
 }
 
+// ApplicationNodes returns the workflow nodes of the applications keyed by the application name
+func ApplicationNodes(appGroup *v1alpha1.ApplicationGroup, wf *v1alpha13.Workflow) map[string]v1alpha13.NodeStatus {
+	// The application nodes belong to the boundary of the entry template,
+	// which is identified by the workflow name
+	nodes := make(map[string]v1alpha13.NodeStatus)
+	for _, node := range wf.Status.Nodes {
+		if node.BoundaryID == wf.Name {
+			nodes[node.DisplayName] = node
+		}
+	}
+	appNodes := make(map[string]v1alpha13.NodeStatus)
+	for _, application := range appGroup.Spec.Applications {
+		if node, ok := nodes[utils.ConvertToDNS1123(application.Name)]; ok {
+			appNodes[application.Name] = node
+		}
+	}
+	return appNodes
+}
+
+// FailedApplications returns the names of the applications whose nodes failed in the workflow
+func FailedApplications(appGroup *v1alpha1.ApplicationGroup, wf *v1alpha13.Workflow) []string {
+	appNodes := ApplicationNodes(appGroup, wf)
+	var failedApps []string
+	for _, application := range appGroup.Spec.Applications {
+		if node, ok := appNodes[application.Name]; ok && node.FailedOrError() {
+			failedApps = append(failedApps, application.Name)
+		}
+	}
+	return failedApps
+}
+
 func ToConditionReason(nodePhase v1alpha13.WorkflowPhase) string {
 	switch nodePhase {
 	case v1alpha13.WorkflowFailed:
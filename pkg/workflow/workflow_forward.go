@@ -67,8 +67,12 @@ func (wc *ForwardWorkflowClient) Generate(ctx context.Context) error {
 
 	wc.workflow = templates.GenerateWorkflow(wc.appGroup.Name, wc.Namespace, wc.Parallelism)
 	graph := graph.NewForwardGraph(wc.GetAppGroup())
+	if err := graph.Validate(); err != nil {
+		wc.appGroup.DAGValidationFailed(err.Error())
+		return fmt.Errorf("failed to validate the graph: %w", err)
+	}
 
-	templateGenerator := templates.NewTemplateGenerator(wc.Namespace, wc.Parallelism)
+	templateGenerator := templates.NewTemplateGenerator(wc.Namespace, wc.Parallelism, wc.StagingRepoName)
 	if err := templateGenerator.GenerateTemplates(graph); err != nil {
 		return fmt.Errorf("failed to generate templates: %w", err)
 	}
@@ -81,6 +85,11 @@ func (wc *ForwardWorkflowClient) Submit(ctx context.Context) error {
 		return fmt.Errorf("failed to create the target namespaces: %w", err)
 	}
 	wc.workflow.Labels[v1alpha1.WorkflowTypeLabel] = string(v1alpha1.Forward)
+	specHash := wc.specHash
+	if specHash == "" {
+		specHash = wc.appGroup.SpecHash()
+	}
+	wc.workflow.Annotations = map[string]string{v1alpha1.SpecHashAnnotation: specHash}
 	if err := controllerutil.SetControllerReference(wc.appGroup, wc.workflow, wc.Scheme()); err != nil {
 		return fmt.Errorf("unable to set ApplicationGroup as owner of Argo Workflow: %w", err)
 	}
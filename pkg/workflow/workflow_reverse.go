@@ -25,7 +25,7 @@ func (wc *ReverseWorkflowClient) GetClient() client.Client {
 }
 
 func (wc *ReverseWorkflowClient) GetType() v1alpha1.WorkflowType {
-	return v1alpha1.Rollback
+	return v1alpha1.Reverse
 }
 
 func (wc *ReverseWorkflowClient) GetAppGroup() *v1alpha1.ApplicationGroup {
@@ -62,7 +62,7 @@ func (wc *ReverseWorkflowClient) Generate(ctx context.Context) error {
 	wc.workflow = templates.GenerateWorkflow(wc.GetName(), wc.Namespace, wc.Parallelism)
 	graph := graph.NewReverseGraph(wc.GetAppGroup())
 
-	templateGenerator := templates.NewTemplateGenerator(wc.Namespace, wc.Parallelism)
+	templateGenerator := templates.NewTemplateGenerator(wc.Namespace, wc.Parallelism, wc.StagingRepoName)
 	if err := templateGenerator.GenerateTemplates(graph); err != nil {
 		return fmt.Errorf("failed to generate templates: %w", err)
 	}
@@ -0,0 +1,35 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/meta"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuilder_Build_Type(t *testing.T) {
+	tests := []struct {
+		wfType        v1alpha1.WorkflowType
+		wantCondition string
+	}{
+		{wfType: v1alpha1.Forward, wantCondition: meta.ForwardWorkflowSucceededCondition},
+		{wfType: v1alpha1.Reverse, wantCondition: meta.ReverseWorkflowSucceededCondition},
+		{wfType: v1alpha1.Rollback, wantCondition: meta.RollbackWorkflowSucceededCondition},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.wfType), func(t *testing.T) {
+			appGroup := &v1alpha1.ApplicationGroup{ObjectMeta: metav1.ObjectMeta{Name: "bookinfo"}}
+			wfClient := NewBuilder(nil, logr.Discard()).Build(tt.wfType, appGroup)
+			if got := wfClient.GetType(); got != tt.wfType {
+				t.Fatalf("GetType() = %v, want %v", got, tt.wfType)
+			}
+			// The workflow client sets the condition of its own workflow type
+			SetProgressing(appGroup, wfClient.GetType())
+			if condition := meta.GetResourceCondition(appGroup, tt.wantCondition); condition == nil {
+				t.Errorf("SetProgressing() conditions = %v, want the %s condition", appGroup.Status.Conditions, tt.wantCondition)
+			}
+		})
+	}
+}
@@ -7,10 +7,10 @@ import (
 	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 
 	"github.com/Azure/Orkestra/pkg/graph"
+	"github.com/Azure/Orkestra/pkg/revision"
 	"github.com/Azure/Orkestra/pkg/templates"
 
 	"github.com/Azure/Orkestra/api/v1alpha1"
-	"github.com/Azure/Orkestra/pkg/meta"
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -53,21 +53,13 @@ func (wc *RollbackWorkflowClient) Generate(ctx context.Context) error {
 		return fmt.Errorf("applicationGroup object cannot be nil")
 	}
 
-	rollbackAppGroup := wc.appGroup.DeepCopy()
-	lastSuccessful := wc.appGroup.GetLastSuccessful()
-	if lastSuccessful == nil {
-		return meta.ErrPreviousSpecNotSet
-	}
-	rollbackAppGroup.Spec = *lastSuccessful
-
-	currGraph := graph.NewForwardGraph(wc.appGroup)
-	lastGraph := graph.NewForwardGraph(rollbackAppGroup)
-	diffGraph := graph.Diff(currGraph, lastGraph)
-
 	wc.workflow = templates.GenerateWorkflow(wc.GetName(), wc.Namespace, wc.Parallelism)
-	combinedGraph := graph.Combine(lastGraph, diffGraph.Reverse())
+	combinedGraph, err := RollbackGraph(ctx, wc)
+	if err != nil {
+		return err
+	}
 
-	templateGenerator := templates.NewTemplateGenerator(wc.Namespace, wc.Parallelism)
+	templateGenerator := templates.NewTemplateGenerator(wc.Namespace, wc.Parallelism, wc.StagingRepoName)
 	if err := templateGenerator.GenerateTemplates(combinedGraph); err != nil {
 		return fmt.Errorf("failed to generate templates: %w", err)
 	}
@@ -75,6 +67,35 @@ func (wc *RollbackWorkflowClient) Generate(ctx context.Context) error {
 	return nil
 }
 
+// RollbackGraph returns the graph that the rollback workflow of the workflow client is generated from.
+// It rolls back to the requested revision, or to the last successful spec if none was requested.
+// When remediating with a partial rollback, the rollback is limited to the failed applications of
+// the forward workflow and their dependents. Requested rollbacks always roll back all the applications.
+func RollbackGraph(ctx context.Context, wfClient Client) (*graph.Graph, error) {
+	appGroup := wfClient.GetAppGroup()
+	rollbackSpec, err := revision.NewHistory(wfClient.GetClient(), wfClient.GetNamespace()).RollbackSpec(ctx, appGroup)
+	if err != nil {
+		return nil, err
+	}
+	rollbackAppGroup := appGroup.DeepCopy()
+	rollbackAppGroup.Spec = *rollbackSpec
+
+	if _, ok := appGroup.Annotations[v1alpha1.RollbackToAnnotation]; ok || appGroup.GetRollbackMode() != v1alpha1.PartialRollback {
+		return graph.NewRollbackGraph(appGroup, rollbackAppGroup), nil
+	}
+	forwardWorkflow, err := GetWorkflow(ctx, NewClientFromClient(wfClient, v1alpha1.Forward))
+	if client.IgnoreNotFound(err) != nil {
+		return nil, fmt.Errorf("failed to get the forward workflow: %w", err)
+	}
+	failedApps := FailedApplications(appGroup, forwardWorkflow)
+	if len(failedApps) == 0 {
+		wfClient.GetLogger().Info("no failed applications found in the forward workflow, rolling back all the applications")
+		return graph.NewRollbackGraph(appGroup, rollbackAppGroup), nil
+	}
+	wfClient.GetLogger().Info("rolling back the failed applications and their dependents", "failed", failedApps)
+	return graph.NewPartialRollbackGraph(appGroup, rollbackAppGroup, failedApps), nil
+}
+
 func (wc *RollbackWorkflowClient) Submit(ctx context.Context) error {
 	wc.workflow.Labels[v1alpha1.WorkflowTypeLabel] = string(v1alpha1.Rollback)
 	if err := controllerutil.SetControllerReference(wc.appGroup, wc.workflow, wc.Scheme()); err != nil {
@@ -0,0 +1,106 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/Orkestra/api/v1alpha1"
+	"github.com/Azure/Orkestra/pkg/templates"
+	v1alpha13 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFailedApplications(t *testing.T) {
+	appGroup := &v1alpha1.ApplicationGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "bookinfo"},
+		Spec: v1alpha1.ApplicationGroupSpec{
+			Applications: []v1alpha1.Application{
+				{DAG: v1alpha1.DAG{Name: "ambassador"}},
+				{DAG: v1alpha1.DAG{Name: "bookinfo"}},
+				{DAG: v1alpha1.DAG{Name: "istio_base"}},
+			},
+		},
+	}
+	tests := []struct {
+		name  string
+		nodes v1alpha13.Nodes
+		want  []string
+	}{
+		{
+			name: "No Failed Applications",
+			nodes: v1alpha13.Nodes{
+				"ambassador-id": {ID: "ambassador-id", BoundaryID: "bookinfo", DisplayName: "ambassador", Phase: v1alpha13.NodeSucceeded},
+			},
+		},
+		{
+			name: "Failed And Errored Applications",
+			nodes: v1alpha13.Nodes{
+				"ambassador-id": {ID: "ambassador-id", BoundaryID: "bookinfo", DisplayName: "ambassador", Phase: v1alpha13.NodeSucceeded},
+				"bookinfo-id":   {ID: "bookinfo-id", BoundaryID: "bookinfo", DisplayName: "bookinfo", Phase: v1alpha13.NodeFailed},
+				"istio-base-id": {ID: "istio-base-id", BoundaryID: "bookinfo", DisplayName: "istio-base", Phase: v1alpha13.NodeError},
+			},
+			want: []string{"bookinfo", "istio_base"},
+		},
+		{
+			name: "Failed Task Nodes Are Not Applications",
+			nodes: v1alpha13.Nodes{
+				"ambassador-id":      {ID: "ambassador-id", BoundaryID: "bookinfo", DisplayName: "ambassador", Phase: v1alpha13.NodeRunning},
+				"ambassador-task-id": {ID: "ambassador-task-id", BoundaryID: "ambassador-id", DisplayName: "bookinfo", Phase: v1alpha13.NodeFailed},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &v1alpha13.Workflow{
+				ObjectMeta: metav1.ObjectMeta{Name: "bookinfo"},
+				Status:     v1alpha13.WorkflowStatus{Nodes: tt.nodes},
+			}
+			if got := FailedApplications(appGroup, wf); !cmp.Equal(got, tt.want) {
+				t.Errorf("FailedApplications() diff = %v", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestForwardWorkflowClient_Submit_SpecHash(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha13.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	appGroup := &v1alpha1.ApplicationGroup{ObjectMeta: metav1.ObjectMeta{Name: "bookinfo", UID: "bookinfo-uid"}}
+	app := v1alpha1.Application{DAG: v1alpha1.DAG{Name: "bookinfo"}}
+	app.Spec.Chart = &v1alpha1.ChartRef{Name: "bookinfo", Version: "v1"}
+	app.Spec.Release = &v1alpha1.Release{TargetNamespace: "bookinfo"}
+	appGroup.Spec.Applications = []v1alpha1.Application{app}
+	specHash := appGroup.SpecHash()
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	wfClient := WithSpecHash(NewBuilder(c, logr.Discard()).InNamespace("orkestra").Build(v1alpha1.Forward, appGroup), specHash)
+	forwardClient := wfClient.(*ForwardWorkflowClient)
+	forwardClient.workflow = templates.GenerateWorkflow(appGroup.Name, "orkestra", nil)
+
+	// The undeclared subcharts are added to the spec while the workflow is generated
+	appGroup.Spec.Applications[0].Spec.Subcharts = []v1alpha1.DAG{{Name: "productpage"}}
+	if err := wfClient.Submit(ctx); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	got, err := GetWorkflow(ctx, wfClient)
+	if err != nil {
+		t.Fatalf("GetWorkflow() error = %v", err)
+	}
+	if got.Annotations[v1alpha1.SpecHashAnnotation] != specHash {
+		t.Errorf("Submit() spec hash = %v, want the hash %v of the spec before it was reconciled", got.Annotations[v1alpha1.SpecHashAnnotation], specHash)
+	}
+}